@@ -1,5 +1,7 @@
 package audit
 
+import "time"
+
 // Niveles de severidad para eventos de auditoría.
 const (
 	SeverityInfo     = "info"
@@ -38,4 +40,7 @@ type AuditEvent struct { //nolint:revive
 	ErrorMessage   string
 	Severity       string
 	Category       string
+	// OccurredAt es el momento en que ocurrió la acción. Si es cero, el
+	// adaptador usa la hora de persistencia.
+	OccurredAt time.Time
 }
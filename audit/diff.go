@@ -0,0 +1,161 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operaciones de un Change.
+const (
+	DiffOpAdd     = "add"
+	DiffOpRemove  = "remove"
+	DiffOpReplace = "replace"
+)
+
+// ChangesDiffKey es la clave de AuditEvent.Changes donde WithDiff guarda la lista de cambios.
+const ChangesDiffKey = "diff"
+
+// Change es una diferencia a nivel de campo entre dos versiones de un recurso.
+// Path usa notación con puntos sobre los nombres JSON ("guardian.email",
+// "items.2.name"); los índices de listas son segmentos numéricos.
+type Change struct {
+	Path string `json:"path"`
+	Op   string `json:"op"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// Diff compara dos structs o mapas y retorna los cambios campo a campo,
+// ordenados por Path (los índices numéricos, en orden numérico). Ambos valores se normalizan vía JSON, por lo que se
+// respetan los tags json (nombres, omitempty, "-") y los MarshalJSON propios.
+// Un valor nil equivale a un objeto vacío (creación o borrado completo).
+func Diff(before, after any) ([]Change, error) {
+	b, err := normalizeForDiff(before)
+	if err != nil {
+		return nil, fmt.Errorf("audit: normalizando before: %w", err)
+	}
+	a, err := normalizeForDiff(after)
+	if err != nil {
+		return nil, fmt.Errorf("audit: normalizando after: %w", err)
+	}
+
+	changes := make([]Change, 0)
+	diffValues("", b, a, &changes)
+	sort.SliceStable(changes, func(i, j int) bool { return pathLess(changes[i].Path, changes[j].Path) })
+	return changes, nil
+}
+
+// WithDiff calcula el diff entre before y after, aplica la política de
+// redacción del ResourceType del evento y lo guarda en
+// Changes[ChangesDiffKey]. A diferencia de WithChanges, no persiste los
+// objetos completos. Debe aplicarse después de fijar ResourceType.
+// Si policy es nil se usa DefaultRedactionPolicy.
+func WithDiff(before, after any, policy *RedactionPolicy) AuditOption {
+	return func(e *AuditEvent) {
+		changes, err := Diff(before, after)
+		if err != nil {
+			e.Changes = map[string]any{"diff_error": err.Error()}
+			return
+		}
+		p := policy
+		if p == nil {
+			p = DefaultRedactionPolicy()
+		}
+		e.Changes = map[string]any{ChangesDiffKey: p.Redact(e.ResourceType, changes)}
+	}
+}
+
+func normalizeForDiff(v any) (any, error) {
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return map[string]any{}, nil
+	}
+	return out, nil
+}
+
+func diffValues(path string, before, after any, changes *[]Change) {
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			diffMaps(path, b, a, changes)
+			return
+		}
+	case []any:
+		if a, ok := after.([]any); ok {
+			diffSlices(path, b, a, changes)
+			return
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Path: path, Op: DiffOpReplace, Old: before, New: after})
+	}
+}
+
+func diffMaps(path string, before, after map[string]any, changes *[]Change) {
+	for key, bv := range before {
+		av, ok := after[key]
+		if !ok {
+			*changes = append(*changes, Change{Path: joinPath(path, key), Op: DiffOpRemove, Old: bv})
+			continue
+		}
+		diffValues(joinPath(path, key), bv, av, changes)
+	}
+	for key, av := range after {
+		if _, ok := before[key]; !ok {
+			*changes = append(*changes, Change{Path: joinPath(path, key), Op: DiffOpAdd, New: av})
+		}
+	}
+}
+
+func diffSlices(path string, before, after []any, changes *[]Change) {
+	for i := 0; i < len(before) || i < len(after); i++ {
+		p := joinPath(path, strconv.Itoa(i))
+		switch {
+		case i >= len(after):
+			*changes = append(*changes, Change{Path: p, Op: DiffOpRemove, Old: before[i]})
+		case i >= len(before):
+			*changes = append(*changes, Change{Path: p, Op: DiffOpAdd, New: after[i]})
+		default:
+			diffValues(p, before[i], after[i], changes)
+		}
+	}
+}
+
+// pathLess compara dos rutas segmento a segmento; dos segmentos numéricos se
+// comparan como números, para que "items.2" quede antes que "items.10".
+func pathLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		ai, aErr := strconv.Atoi(as[i])
+		bi, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil && ai != bi {
+			return ai < bi
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
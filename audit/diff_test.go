@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type diffGuardian struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone,omitempty"`
+	Internal string `json:"-"`
+}
+
+type diffStudent struct {
+	ID           string         `json:"id"`
+	FirstName    string         `json:"first_name"`
+	Grade        int            `json:"grade"`
+	PasswordHash string         `json:"password_hash"`
+	Guardian     *diffGuardian  `json:"guardian,omitempty"`
+	Tags         []string       `json:"tags"`
+	Extra        map[string]any `json:"extra,omitempty"`
+}
+
+func TestDiff_StructsFollowJSONTags(t *testing.T) {
+	before := diffStudent{
+		ID: "s1", FirstName: "Ana", Grade: 3, PasswordHash: "h1",
+		Guardian: &diffGuardian{Name: "Luis", Email: "l@x.com", Internal: "a"},
+		Tags:     []string{"a", "b"},
+	}
+	after := before
+	after.FirstName = "Ana María"
+	after.Grade = 4
+	after.Guardian = &diffGuardian{Name: "Luis", Email: "luis@x.com", Phone: "555", Internal: "b"}
+	after.Tags = []string{"a"}
+
+	changes, err := Diff(before, after)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Change{
+		{Path: "first_name", Op: DiffOpReplace, Old: "Ana", New: "Ana María"},
+		{Path: "grade", Op: DiffOpReplace, Old: float64(3), New: float64(4)},
+		{Path: "guardian.email", Op: DiffOpReplace, Old: "l@x.com", New: "luis@x.com"},
+		{Path: "guardian.phone", Op: DiffOpAdd, New: "555"},
+		{Path: "tags.1", Op: DiffOpRemove, Old: "b"},
+	}, changes)
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	s := diffStudent{ID: "s1", Tags: []string{"a"}}
+	changes, err := Diff(s, s)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestDiff_CreationAndTypeChange(t *testing.T) {
+	changes, err := Diff(nil, map[string]any{"name": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "name", Op: DiffOpAdd, New: "x"}}, changes)
+
+	changes, err = Diff(map[string]any{"v": []any{1}}, map[string]any{"v": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{Path: "v", Op: DiffOpReplace, Old: []any{float64(1)}, New: "1"}}, changes)
+}
+
+func TestDiff_UnmarshalableValue(t *testing.T) {
+	_, err := Diff(map[string]any{"ch": make(chan int)}, nil)
+	assert.Error(t, err)
+}
+
+func TestRedactionPolicy_DefaultsAndPerResource(t *testing.T) {
+	policy := DefaultRedactionPolicy().
+		ForResource("guardian", "email", "phone").
+		ForResource("student", "guardian.*", "contacts.*.document_number")
+
+	assert.True(t, policy.IsSensitive("user", "password_hash"))
+	assert.True(t, policy.IsSensitive("user", "profile.refresh_token"))
+	assert.True(t, policy.IsSensitive("service_client", "client_secret"))
+	assert.False(t, policy.IsSensitive("user", "email"))
+	assert.True(t, policy.IsSensitive("guardian", "email"))
+	assert.True(t, policy.IsSensitive("student", "guardian.email"))
+	assert.True(t, policy.IsSensitive("student", "contacts.0.document_number"))
+	assert.False(t, policy.IsSensitive("student", "contacts.0.name"))
+	assert.False(t, policy.IsSensitive("student", "first_name"))
+}
+
+func TestRedactionPolicy_RedactNestedValues(t *testing.T) {
+	policy := DefaultRedactionPolicy().ForResource("student", "guardian.email")
+	changes := []Change{
+		{Path: "password_hash", Op: DiffOpReplace, Old: "h1", New: "h2"},
+		{Path: "guardian", Op: DiffOpAdd, New: map[string]any{"name": "Luis", "email": "l@x.com"}},
+		{Path: "grade", Op: DiffOpReplace, Old: float64(3), New: float64(4)},
+	}
+
+	redacted := policy.Redact("student", changes)
+
+	assert.Equal(t, RedactedValue, redacted[0].Old)
+	assert.Equal(t, RedactedValue, redacted[0].New)
+	assert.Equal(t, map[string]any{"name": "Luis", "email": RedactedValue}, redacted[1].New)
+	assert.Nil(t, redacted[1].Old)
+	assert.Equal(t, changes[2], redacted[2])
+	// La entrada original no se modifica.
+	assert.Equal(t, "l@x.com", changes[1].New.(map[string]any)["email"])
+}
+
+func TestRedactionPolicy_PatternCoversSubtree(t *testing.T) {
+	policy := NewRedactionPolicy().ForResource("service_client", "credentials")
+
+	assert.True(t, policy.IsSensitive("service_client", "credentials"))
+	assert.True(t, policy.IsSensitive("service_client", "credentials.token"))
+	assert.True(t, policy.IsSensitive("service_client", "credentials.keys.0"))
+	assert.False(t, policy.IsSensitive("service_client", "credentials_url"))
+
+	redacted := policy.Redact("service_client", []Change{
+		{Path: "credentials.token", Op: DiffOpReplace, Old: "t1", New: "t2"},
+	})
+	assert.Equal(t, RedactedValue, redacted[0].Old)
+	assert.Equal(t, RedactedValue, redacted[0].New)
+}
+
+func TestDiff_SortsListIndexesNumerically(t *testing.T) {
+	items := make([]any, 12)
+	for _, i := range []int{1, 2, 10} {
+		items[i] = "x"
+	}
+	before := map[string]any{"items": make([]any, 12)}
+	after := map[string]any{"items": items}
+
+	changes, err := Diff(before, after)
+	require.NoError(t, err)
+
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.Path
+	}
+	assert.Equal(t, []string{"items.1", "items.2", "items.10"}, paths)
+}
+
+func TestWithDiff(t *testing.T) {
+	policy := DefaultRedactionPolicy().ForResource("student", "guardian.email")
+	before := diffStudent{ID: "s1", PasswordHash: "h1", Guardian: &diffGuardian{Email: "a@x.com"}}
+	after := diffStudent{ID: "s1", PasswordHash: "h2", Guardian: &diffGuardian{Email: "b@x.com"}}
+
+	event := AuditEvent{ResourceType: "student"}
+	WithDiff(before, after, policy)(&event)
+
+	require.NotNil(t, event.Changes)
+	assert.Equal(t, []Change{
+		{Path: "guardian.email", Op: DiffOpReplace, Old: RedactedValue, New: RedactedValue},
+		{Path: "password_hash", Op: DiffOpReplace, Old: RedactedValue, New: RedactedValue},
+	}, event.Changes[ChangesDiffKey])
+}
+
+func TestWithDiff_Error(t *testing.T) {
+	event := AuditEvent{}
+	WithDiff(map[string]any{"ch": make(chan int)}, nil, nil)(&event)
+	assert.Contains(t, event.Changes, "diff_error")
+}
+
+func TestWithDiff_SharedOptionIsConcurrencySafe(t *testing.T) {
+	opt := WithDiff(diffStudent{PasswordHash: "h1"}, diffStudent{PasswordHash: "h2"}, nil)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := AuditEvent{ResourceType: "student"}
+			opt(&event)
+			assert.Equal(t, []Change{
+				{Path: "password_hash", Op: DiffOpReplace, Old: RedactedValue, New: RedactedValue},
+			}, event.Changes[ChangesDiffKey])
+		}()
+	}
+	wg.Wait()
+}
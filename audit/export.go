@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormat es el formato de salida de Export.
+type ExportFormat string
+
+// Formatos de exportación soportados.
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// ContentType retorna el MIME type del formato.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// exportRecord es la representación estable de un evento exportado.
+type exportRecord struct {
+	ID             string         `json:"id"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+	ServiceName    string         `json:"service_name"`
+	ActorID        string         `json:"actor_id"`
+	ActorEmail     string         `json:"actor_email"`
+	ActorRole      string         `json:"actor_role"`
+	ActorIP        string         `json:"actor_ip,omitempty"`
+	ActorUserAgent string         `json:"actor_user_agent,omitempty"`
+	SchoolID       string         `json:"school_id,omitempty"`
+	UnitID         string         `json:"unit_id,omitempty"`
+	Action         string         `json:"action"`
+	ResourceType   string         `json:"resource_type"`
+	ResourceID     string         `json:"resource_id,omitempty"`
+	PermissionUsed string         `json:"permission_used,omitempty"`
+	RequestMethod  string         `json:"request_method,omitempty"`
+	RequestPath    string         `json:"request_path,omitempty"`
+	RequestID      string         `json:"request_id,omitempty"`
+	StatusCode     int            `json:"status_code,omitempty"`
+	Severity       string         `json:"severity"`
+	Category       string         `json:"category"`
+	ErrorMessage   string         `json:"error_message,omitempty"`
+	Changes        map[string]any `json:"changes,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+}
+
+// csvHeader define el orden de columnas del CSV exportado.
+var csvHeader = []string{
+	"id", "occurred_at", "service_name", "actor_id", "actor_email", "actor_role",
+	"actor_ip", "actor_user_agent", "school_id", "unit_id", "action", "resource_type",
+	"resource_id", "permission_used", "request_method", "request_path", "request_id",
+	"status_code", "severity", "category", "error_message", "changes", "metadata",
+}
+
+// Export vuelca a w todos los eventos que cumplen q en el formato indicado,
+// usando Reader.Stream para no materializar el resultado en memoria.
+// Retorna el número de eventos escritos.
+func Export(ctx context.Context, r Reader, q AuditQuery, format ExportFormat, w io.Writer) (int, error) {
+	switch format {
+	case ExportCSV:
+		return exportCSV(ctx, r, q, w)
+	case ExportNDJSON:
+		return exportNDJSON(ctx, r, q, w)
+	default:
+		return 0, fmt.Errorf("audit: formato de exportación no soportado: %q", format)
+	}
+}
+
+func exportNDJSON(ctx context.Context, r Reader, q AuditQuery, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	count := 0
+	err := r.Stream(ctx, q, func(rec AuditRecord) error {
+		if err := enc.Encode(toExportRecord(rec)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+func exportCSV(ctx context.Context, r Reader, q AuditQuery, w io.Writer) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return 0, err
+	}
+	count := 0
+	err := r.Stream(ctx, q, func(rec AuditRecord) error {
+		row, err := csvRow(toExportRecord(rec))
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	cw.Flush()
+	if err == nil {
+		err = cw.Error()
+	}
+	return count, err
+}
+
+func csvRow(e exportRecord) ([]string, error) {
+	changes, err := jsonCell(e.Changes)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := jsonCell(e.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	status := ""
+	if e.StatusCode != 0 {
+		status = strconv.Itoa(e.StatusCode)
+	}
+	row := []string{
+		e.ID, e.OccurredAt.Format(time.RFC3339Nano), e.ServiceName, e.ActorID, e.ActorEmail, e.ActorRole,
+		e.ActorIP, e.ActorUserAgent, e.SchoolID, e.UnitID, e.Action, e.ResourceType,
+		e.ResourceID, e.PermissionUsed, e.RequestMethod, e.RequestPath, e.RequestID,
+		status, e.Severity, e.Category, e.ErrorMessage, changes, metadata,
+	}
+	for i := range row {
+		row[i] = csvSafe(row[i])
+	}
+	return row, nil
+}
+
+// csvSafe neutraliza valores que una hoja de cálculo interpretaría como
+// fórmula (inyección CSV), anteponiendo una comilla simple.
+func csvSafe(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + v
+	}
+	return v
+}
+
+func jsonCell(v map[string]any) (string, error) {
+	if len(v) == 0 {
+		return "", nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func toExportRecord(r AuditRecord) exportRecord {
+	return exportRecord{
+		ID:             r.ID,
+		OccurredAt:     r.OccurredAt.UTC(),
+		ServiceName:    r.ServiceName,
+		ActorID:        r.ActorID,
+		ActorEmail:     r.ActorEmail,
+		ActorRole:      r.ActorRole,
+		ActorIP:        r.ActorIP,
+		ActorUserAgent: r.ActorUserAgent,
+		SchoolID:       r.SchoolID,
+		UnitID:         r.UnitID,
+		Action:         r.Action,
+		ResourceType:   r.ResourceType,
+		ResourceID:     r.ResourceID,
+		PermissionUsed: r.PermissionUsed,
+		RequestMethod:  r.RequestMethod,
+		RequestPath:    r.RequestPath,
+		RequestID:      r.RequestID,
+		StatusCode:     r.StatusCode,
+		Severity:       r.Severity,
+		Category:       r.Category,
+		ErrorMessage:   r.ErrorMessage,
+		Changes:        r.Changes,
+		Metadata:       r.Metadata,
+	}
+}
@@ -0,0 +1,162 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Predicate decide si un sink debe recibir un evento.
+type Predicate func(AuditEvent) bool
+
+// MatchSeverity acepta eventos con alguna de las severidades indicadas.
+func MatchSeverity(severities ...string) Predicate {
+	return func(e AuditEvent) bool { return slices.Contains(severities, e.Severity) }
+}
+
+// MatchCategory acepta eventos con alguna de las categorías indicadas.
+func MatchCategory(categories ...string) Predicate {
+	return func(e AuditEvent) bool { return slices.Contains(categories, e.Category) }
+}
+
+// MatchAction acepta eventos con alguna de las acciones indicadas. Un patrón
+// terminado en ".*" acepta el prefijo (p. ej. "user.*" acepta "user.login").
+func MatchAction(actions ...string) Predicate {
+	return func(e AuditEvent) bool {
+		for _, a := range actions {
+			if prefix, ok := strings.CutSuffix(a, "*"); ok && strings.HasSuffix(prefix, ".") {
+				if strings.HasPrefix(e.Action, prefix) {
+					return true
+				}
+			} else if a == e.Action {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllOf acepta el evento si todos los predicados lo aceptan.
+func AllOf(predicates ...Predicate) Predicate {
+	return func(e AuditEvent) bool {
+		for _, p := range predicates {
+			if !p(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf acepta el evento si algún predicado lo acepta.
+func AnyOf(predicates ...Predicate) Predicate {
+	return func(e AuditEvent) bool {
+		for _, p := range predicates {
+			if p(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ErrorLogger es el subconjunto de logger.Logger (edugo-shared/logger) que usa
+// FanOutAuditLogger para reportar fallos de sinks. *slog.Logger también lo satisface.
+type ErrorLogger interface {
+	Error(msg string, fields ...any)
+}
+
+// Sink es un destino de FanOutAuditLogger.
+type Sink struct {
+	// Name identifica el sink en logs y errores.
+	Name string
+	// Logger recibe los eventos aceptados.
+	Logger AuditLogger
+	// Filter, si no es nil, decide qué eventos recibe el sink.
+	Filter Predicate
+	// Required hace que un fallo del sink se retorne desde Log. Los fallos de
+	// sinks no requeridos solo se reportan en el logger.
+	Required bool
+}
+
+// SinkError es el error de un sink requerido retornado por FanOutAuditLogger.Log.
+type SinkError struct {
+	Sink string
+	Err  error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("audit: sink %q: %v", e.Sink, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+// FanOutAuditLogger despacha cada evento a varios sinks en paralelo. Cada
+// sink recibe solo los eventos que acepta su Filter; un fallo o panic de un
+// sink no afecta a los demás y se reporta en el ErrorLogger.
+type FanOutAuditLogger struct { //nolint:revive
+	sinks []Sink
+	log   ErrorLogger
+}
+
+// NewFanOutAuditLogger crea un FanOutAuditLogger. Si log es nil se usa slog.Default().
+// Los sinks sin Logger se ignoran.
+func NewFanOutAuditLogger(log ErrorLogger, sinks ...Sink) *FanOutAuditLogger {
+	if log == nil {
+		log = slog.Default()
+	}
+	active := make([]Sink, 0, len(sinks))
+	for _, s := range sinks {
+		if s.Logger != nil {
+			active = append(active, s)
+		}
+	}
+	return &FanOutAuditLogger{sinks: active, log: log}
+}
+
+// Log envía el evento a los sinks que lo aceptan y espera a que terminen.
+// Retorna los fallos de sinks requeridos (unidos con errors.Join, cada uno
+// como *SinkError); los de sinks opcionales solo se registran.
+func (f *FanOutAuditLogger) Log(ctx context.Context, event AuditEvent) error {
+	errs := make([]error, len(f.sinks))
+	var wg sync.WaitGroup
+	for i, s := range f.sinks {
+		if s.Filter != nil && !s.Filter(event) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := logToSink(ctx, s, event); err != nil {
+				f.log.Error("audit: fallo al registrar evento en sink",
+					"sink", s.Name,
+					"action", event.Action,
+					"resource_type", event.ResourceType,
+					"required", s.Required,
+					"error", err.Error(),
+				)
+				if s.Required {
+					errs[i] = &SinkError{Sink: s.Name, Err: err}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// logToSink aísla los panics del sink convirtiéndolos en error.
+func logToSink(ctx context.Context, s Sink, event AuditEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.Logger.Log(ctx, event)
+}
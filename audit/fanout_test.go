@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingLogger struct {
+	mu     sync.Mutex
+	events []AuditEvent
+	err    error
+	panics bool
+}
+
+func (r *recordingLogger) Log(_ context.Context, e AuditEvent) error {
+	if r.panics {
+		panic("sink roto")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return r.err
+}
+
+type capturingErrorLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (c *capturingErrorLogger) Error(msg string, fields ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, fmt.Sprint(append([]any{msg}, fields...)...))
+}
+
+func TestPredicates(t *testing.T) {
+	login := AuditEvent{Action: "user.login", Severity: SeverityCritical, Category: CategoryAuth}
+	update := AuditEvent{Action: "update", Severity: SeverityInfo, Category: CategoryData}
+
+	assert.True(t, MatchSeverity(SeverityCritical)(login))
+	assert.False(t, MatchSeverity(SeverityCritical)(update))
+	assert.True(t, MatchCategory(CategoryAuth, CategoryAdmin)(login))
+	assert.True(t, MatchAction("user.*")(login))
+	assert.False(t, MatchAction("user.*")(update))
+	assert.True(t, MatchAction("update")(update))
+	assert.False(t, MatchAction("user*")(login), "el comodín solo aplica tras un punto")
+
+	critAuth := AllOf(MatchSeverity(SeverityCritical), MatchCategory(CategoryAuth))
+	assert.True(t, critAuth(login))
+	assert.False(t, critAuth(update))
+	assert.True(t, AnyOf(critAuth, MatchAction("update"))(update))
+}
+
+func TestFanOutAuditLogger_RoutesByFilter(t *testing.T) {
+	primary := &recordingLogger{}
+	siem := &recordingLogger{}
+	fanout := NewFanOutAuditLogger(&capturingErrorLogger{},
+		Sink{Name: "postgres", Logger: primary, Required: true},
+		Sink{Name: "siem", Logger: siem, Filter: AllOf(MatchSeverity(SeverityCritical), MatchCategory(CategoryAuth))},
+	)
+
+	ctx := context.Background()
+	require.NoError(t, fanout.Log(ctx, AuditEvent{Action: "update", Severity: SeverityInfo, Category: CategoryData}))
+	require.NoError(t, fanout.Log(ctx, AuditEvent{Action: "user.login", Severity: SeverityCritical, Category: CategoryAuth}))
+
+	assert.Len(t, primary.events, 2)
+	require.Len(t, siem.events, 1)
+	assert.Equal(t, "user.login", siem.events[0].Action)
+}
+
+func TestFanOutAuditLogger_IsolatesOptionalFailures(t *testing.T) {
+	primary := &recordingLogger{}
+	errLog := &capturingErrorLogger{}
+	fanout := NewFanOutAuditLogger(errLog,
+		Sink{Name: "postgres", Logger: primary, Required: true},
+		Sink{Name: "rabbit", Logger: &recordingLogger{err: errors.New("canal cerrado")}},
+		Sink{Name: "broken", Logger: &recordingLogger{panics: true}},
+	)
+
+	err := fanout.Log(context.Background(), AuditEvent{Action: "create"})
+
+	assert.NoError(t, err, "los fallos de sinks opcionales no se propagan")
+	assert.Len(t, primary.events, 1)
+	require.Len(t, errLog.messages, 2)
+	assert.Contains(t, fmt.Sprint(errLog.messages), "canal cerrado")
+	assert.Contains(t, fmt.Sprint(errLog.messages), "panic: sink roto")
+}
+
+func TestFanOutAuditLogger_ReturnsRequiredFailures(t *testing.T) {
+	cause := errors.New("db caída")
+	other := &recordingLogger{}
+	fanout := NewFanOutAuditLogger(&capturingErrorLogger{},
+		Sink{Name: "postgres", Logger: &recordingLogger{err: cause}, Required: true},
+		Sink{Name: "siem", Logger: other},
+		Sink{Name: "sin-logger"},
+	)
+
+	err := fanout.Log(context.Background(), AuditEvent{Action: "delete"})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cause)
+	var sinkErr *SinkError
+	require.ErrorAs(t, err, &sinkErr)
+	assert.Equal(t, "postgres", sinkErr.Sink)
+	assert.Len(t, other.events, 1, "el resto de sinks recibe el evento igualmente")
+}
@@ -0,0 +1,299 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+)
+
+// Errores del BatchAuditLogger.
+var (
+	// ErrQueueFull indica que la cola estaba llena y el evento fue descartado (OverflowDrop).
+	ErrQueueFull = errors.New("audit: cola de auditoría llena, evento descartado")
+	// ErrBatchLoggerClosed indica que el logger ya fue cerrado y no acepta eventos.
+	ErrBatchLoggerClosed = errors.New("audit: batch logger cerrado")
+	// ErrShutdownTimeout indica que el drenado de la cola no terminó a tiempo.
+	ErrShutdownTimeout = errors.New("audit: timeout drenando la cola de auditoría")
+)
+
+// Motivos de descarte reportados a BatchMetricsRecorder.
+const (
+	DropReasonQueueFull       = "queue_full"
+	DropReasonClosed          = "closed"
+	DropReasonContextCanceled = "context_canceled"
+	DropReasonFlushError      = "flush_error"
+)
+
+// OverflowPolicy define qué hace Log cuando la cola está llena.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop descarta el evento y retorna ErrQueueFull sin bloquear el request.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock espera a que haya espacio en la cola o a que el contexto se cancele (backpressure).
+	OverflowBlock
+)
+
+// Valores por defecto de BatchConfig.
+const (
+	DefaultBatchQueueSize       = 10000
+	DefaultBatchSize            = 200
+	DefaultBatchFlushInterval   = time.Second
+	DefaultBatchFlushTimeout    = 5 * time.Second
+	DefaultBatchShutdownTimeout = 10 * time.Second
+)
+
+// BatchWriter persiste un lote de eventos. PostgresAuditLogger lo implementa con LogBatch.
+type BatchWriter interface {
+	LogBatch(ctx context.Context, events []audit.AuditEvent) error
+}
+
+// BatchMetricsRecorder es la interfaz mínima para emitir métricas del pipeline
+// sin introducir una dependencia de build hacia edugo-shared/metrics.
+// *metrics.Metrics la satisface.
+type BatchMetricsRecorder interface {
+	RecordAuditEventDropped(reason string, count int)
+	RecordAuditQueueOverflow(blocked bool)
+	SetAuditQueueDepth(depth int)
+	RecordAuditBatchFlush(size int, duration time.Duration, err error)
+}
+
+// BatchConfig configura el BatchAuditLogger. Los valores cero usan los defaults.
+type BatchConfig struct {
+	QueueSize       int            // Capacidad máxima de la cola en memoria
+	BatchSize       int            // Eventos por lote; al alcanzarlo se hace flush inmediato
+	FlushInterval   time.Duration  // Intervalo máximo entre flushes con eventos pendientes
+	FlushTimeout    time.Duration  // Timeout de cada escritura de lote
+	ShutdownTimeout time.Duration  // Tiempo máximo de drenado en Close
+	Overflow        OverflowPolicy // Política cuando la cola está llena
+
+	// Metrics recibe contadores de descarte/overflow y profundidad de cola. Opcional.
+	Metrics BatchMetricsRecorder
+	// OnFlushError se invoca cuando un lote no pudo persistirse. Opcional.
+	OnFlushError func(err error, events []audit.AuditEvent)
+}
+
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultBatchQueueSize
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = DefaultBatchFlushInterval
+	}
+	if c.FlushTimeout <= 0 {
+		c.FlushTimeout = DefaultBatchFlushTimeout
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = DefaultBatchShutdownTimeout
+	}
+	return c
+}
+
+// BatchAuditLogger es un decorador de audit.AuditLogger que encola eventos en
+// memoria y los persiste en lotes desde una goroutine propia, sacando el
+// INSERT del camino del request.
+//
+// Debe cerrarse con Close (o Shutdown) para drenar la cola; se integra con
+// lifecycle.Manager registrando Close como cleanup:
+//
+//	batch := postgres.NewBatchAuditLogger(pgLogger, postgres.BatchConfig{Metrics: m})
+//	lifecycleManager.RegisterSimple("audit-batch", batch.Close)
+type BatchAuditLogger struct {
+	writer BatchWriter
+	config BatchConfig
+	queue  chan audit.AuditEvent
+
+	mu     sync.RWMutex
+	closed bool
+	// closing se cierra al iniciar Shutdown, antes de tomar mu, para liberar a
+	// los productores bloqueados en OverflowBlock que retienen el RLock.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	stop     chan struct{}
+	done     chan struct{}
+	errMu    sync.Mutex
+	firstErr error
+}
+
+// NewBatchAuditLogger crea el decorador e inicia la goroutine de flush.
+func NewBatchAuditLogger(writer BatchWriter, config BatchConfig) *BatchAuditLogger {
+	config = config.withDefaults()
+	l := &BatchAuditLogger{
+		writer:  writer,
+		config:  config,
+		queue:   make(chan audit.AuditEvent, config.QueueSize),
+		closing: make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Log encola el evento para persistencia asíncrona. Si OccurredAt es cero se
+// fija al momento de encolar, para que el lote conserve la hora real del evento.
+// Con OverflowDrop retorna ErrQueueFull si la cola está llena; con OverflowBlock
+// espera espacio hasta que ctx se cancele o el logger se cierre.
+func (l *BatchAuditLogger) Log(ctx context.Context, event audit.AuditEvent) error {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		l.recordDropped(DropReasonClosed, 1)
+		return ErrBatchLoggerClosed
+	}
+
+	select {
+	case l.queue <- event:
+		return nil
+	default:
+	}
+
+	if l.config.Overflow != OverflowBlock {
+		l.recordOverflow(false)
+		l.recordDropped(DropReasonQueueFull, 1)
+		return ErrQueueFull
+	}
+
+	l.recordOverflow(true)
+	select {
+	case l.queue <- event:
+		return nil
+	case <-ctx.Done():
+		l.recordDropped(DropReasonContextCanceled, 1)
+		return ctx.Err()
+	case <-l.closing:
+		l.recordDropped(DropReasonClosed, 1)
+		return ErrBatchLoggerClosed
+	}
+}
+
+// Pending retorna el número de eventos en cola pendientes de flush.
+func (l *BatchAuditLogger) Pending() int {
+	return len(l.queue)
+}
+
+// Close deja de aceptar eventos, drena la cola y espera el último flush
+// durante como máximo ShutdownTimeout. Es idempotente.
+func (l *BatchAuditLogger) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.ShutdownTimeout)
+	defer cancel()
+	return l.Shutdown(ctx)
+}
+
+// Shutdown es como Close pero con un contexto controlado por el caller.
+// Retorna ErrShutdownTimeout si el contexto vence antes de terminar el drenado,
+// o el error del primer lote que no se pudo escribir, aunque los siguientes sí
+// se hayan escrito.
+func (l *BatchAuditLogger) Shutdown(ctx context.Context) error {
+	l.closeOnce.Do(func() {
+		close(l.closing)
+		l.mu.Lock()
+		l.closed = true
+		l.mu.Unlock()
+		close(l.stop)
+	})
+
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		return ErrShutdownTimeout
+	}
+
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	return l.firstErr
+}
+
+// run acumula eventos y hace flush por tamaño o por intervalo.
+func (l *BatchAuditLogger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]audit.AuditEvent, 0, l.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		l.flush(batch)
+		batch = make([]audit.AuditEvent, 0, l.config.BatchSize)
+	}
+
+	for {
+		select {
+		case event := <-l.queue:
+			batch = append(batch, event)
+			if len(batch) >= l.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-l.stop:
+			// Tras cerrar, ningún productor retiene el RLock: la cola ya no crece.
+			for {
+				select {
+				case event := <-l.queue:
+					batch = append(batch, event)
+					if len(batch) >= l.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush escribe un lote y reporta métricas; un lote fallido se descarta.
+func (l *BatchAuditLogger) flush(batch []audit.AuditEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.config.FlushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := l.writer.LogBatch(ctx, batch)
+
+	if m := l.config.Metrics; m != nil {
+		m.RecordAuditBatchFlush(len(batch), time.Since(start), err)
+		m.SetAuditQueueDepth(len(l.queue))
+	}
+
+	if err != nil {
+		l.errMu.Lock()
+		if l.firstErr == nil {
+			l.firstErr = err
+		}
+		l.errMu.Unlock()
+
+		l.recordDropped(DropReasonFlushError, len(batch))
+		if l.config.OnFlushError != nil {
+			l.config.OnFlushError(err, batch)
+		}
+	}
+}
+
+func (l *BatchAuditLogger) recordDropped(reason string, count int) {
+	if l.config.Metrics != nil {
+		l.config.Metrics.RecordAuditEventDropped(reason, count)
+	}
+}
+
+func (l *BatchAuditLogger) recordOverflow(blocked bool) {
+	if l.config.Metrics != nil {
+		l.config.Metrics.RecordAuditQueueOverflow(blocked)
+	}
+}
@@ -0,0 +1,310 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+)
+
+type fakeBatchWriter struct {
+	mu      sync.Mutex
+	batches [][]audit.AuditEvent
+	err     error
+	// failures, si es > 0, limita err a las primeras llamadas.
+	failures int
+	block    chan struct{}
+}
+
+func (w *fakeBatchWriter) LogBatch(_ context.Context, events []audit.AuditEvent) error {
+	if w.block != nil {
+		<-w.block
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches = append(w.batches, events)
+	err := w.err
+	if w.failures > 0 {
+		w.failures--
+		if w.failures == 0 {
+			w.err = nil
+		}
+	}
+	return err
+}
+
+func (w *fakeBatchWriter) total() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, b := range w.batches {
+		n += len(b)
+	}
+	return n
+}
+
+type spyBatchMetrics struct {
+	mu        sync.Mutex
+	dropped   map[string]int
+	overflows int
+	flushes   int
+}
+
+func (m *spyBatchMetrics) RecordAuditEventDropped(reason string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.dropped == nil {
+		m.dropped = make(map[string]int)
+	}
+	m.dropped[reason] += count
+}
+
+func (m *spyBatchMetrics) RecordAuditQueueOverflow(bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overflows++
+}
+
+func (m *spyBatchMetrics) SetAuditQueueDepth(int) {}
+
+func (m *spyBatchMetrics) RecordAuditBatchFlush(int, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flushes++
+}
+
+func TestBatchAuditLoggerFlushesBySize(t *testing.T) {
+	writer := &fakeBatchWriter{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{BatchSize: 3, FlushInterval: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		if err := logger.Log(context.Background(), audit.AuditEvent{Action: "create"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for writer.total() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if writer.total() != 3 {
+		t.Fatalf("expected 3 events flushed by size, got %d", writer.total())
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
+
+func TestBatchAuditLoggerFlushesByInterval(t *testing.T) {
+	writer := &fakeBatchWriter{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer logger.Close() //nolint:errcheck
+
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "update"})
+
+	deadline := time.Now().Add(time.Second)
+	for writer.total() < 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if writer.total() != 1 {
+		t.Fatalf("expected event flushed by interval, got %d", writer.total())
+	}
+}
+
+func TestBatchAuditLoggerStampsOccurredAt(t *testing.T) {
+	writer := &fakeBatchWriter{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{})
+
+	before := time.Now().UTC()
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "delete"})
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if len(writer.batches) != 1 || writer.batches[0][0].OccurredAt.Before(before) {
+		t.Fatalf("expected OccurredAt stamped at enqueue time, got %+v", writer.batches)
+	}
+}
+
+func TestBatchAuditLoggerDropsWhenQueueFull(t *testing.T) {
+	writer := &fakeBatchWriter{block: make(chan struct{})}
+	spy := &spyBatchMetrics{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{QueueSize: 1, BatchSize: 1, Metrics: spy})
+
+	// El primer evento queda bloqueado en el writer; el segundo ocupa la cola.
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "a"})
+	deadline := time.Now().Add(time.Second)
+	for logger.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "b"})
+
+	err := logger.Log(context.Background(), audit.AuditEvent{Action: "c"})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(writer.block)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+
+	if spy.dropped[DropReasonQueueFull] != 1 || spy.overflows != 1 {
+		t.Errorf("expected 1 drop and 1 overflow, got %v / %d", spy.dropped, spy.overflows)
+	}
+	if writer.total() != 2 {
+		t.Errorf("expected 2 persisted events, got %d", writer.total())
+	}
+}
+
+func TestBatchAuditLoggerBlockRespectsContext(t *testing.T) {
+	writer := &fakeBatchWriter{block: make(chan struct{})}
+	spy := &spyBatchMetrics{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{QueueSize: 1, BatchSize: 1, Overflow: OverflowBlock, Metrics: spy})
+
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "a"})
+	deadline := time.Now().Add(time.Second)
+	for logger.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "b"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := logger.Log(ctx, audit.AuditEvent{Action: "c"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error, got %v", err)
+	}
+
+	close(writer.block)
+	_ = logger.Close()
+
+	if spy.dropped[DropReasonContextCanceled] != 1 {
+		t.Errorf("expected 1 context drop, got %v", spy.dropped)
+	}
+}
+
+func TestBatchAuditLoggerShutdownReleasesBlockedProducers(t *testing.T) {
+	writer := &fakeBatchWriter{block: make(chan struct{})}
+	defer close(writer.block)
+	logger := NewBatchAuditLogger(writer, BatchConfig{QueueSize: 1, BatchSize: 1, Overflow: OverflowBlock})
+
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "a"})
+	deadline := time.Now().Add(time.Second)
+	for logger.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "b"})
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- logger.Log(context.Background(), audit.AuditEvent{Action: "c"}) }()
+	time.Sleep(20 * time.Millisecond) // deja al productor bloqueado en el envío
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := logger.Shutdown(ctx); !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("expected ErrShutdownTimeout, got %v", err)
+	}
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, ErrBatchLoggerClosed) {
+			t.Errorf("expected ErrBatchLoggerClosed for the blocked producer, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked producer was not released by Shutdown")
+	}
+}
+
+func TestBatchAuditLoggerCloseDrainsQueue(t *testing.T) {
+	writer := &fakeBatchWriter{}
+	logger := NewBatchAuditLogger(writer, BatchConfig{BatchSize: 4, FlushInterval: time.Hour})
+
+	for i := 0; i < 10; i++ {
+		_ = logger.Log(context.Background(), audit.AuditEvent{Action: "create"})
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if writer.total() != 10 {
+		t.Fatalf("expected 10 events drained, got %d", writer.total())
+	}
+
+	if err := logger.Log(context.Background(), audit.AuditEvent{}); !errors.Is(err, ErrBatchLoggerClosed) {
+		t.Errorf("expected ErrBatchLoggerClosed after close, got %v", err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Errorf("Close should be idempotent, got %v", err)
+	}
+}
+
+func TestBatchAuditLoggerReportsFlushErrors(t *testing.T) {
+	writer := &fakeBatchWriter{err: errors.New("db down")}
+	spy := &spyBatchMetrics{}
+	var failed int
+	logger := NewBatchAuditLogger(writer, BatchConfig{
+		Metrics:      spy,
+		OnFlushError: func(_ error, events []audit.AuditEvent) { failed += len(events) },
+	})
+
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "create"})
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "update"})
+
+	if err := logger.Close(); err == nil {
+		t.Fatal("expected flush error on close")
+	}
+	if failed != 2 || spy.dropped[DropReasonFlushError] != 2 {
+		t.Errorf("expected 2 failed events, got handler=%d metrics=%v", failed, spy.dropped)
+	}
+}
+
+func TestBatchAuditLoggerCloseReportsFirstFlushError(t *testing.T) {
+	writer := &fakeBatchWriter{err: errors.New("db down"), failures: 1}
+	logger := NewBatchAuditLogger(writer, BatchConfig{BatchSize: 2, FlushInterval: time.Hour})
+
+	for i := 0; i < 4; i++ {
+		_ = logger.Log(context.Background(), audit.AuditEvent{Action: "create"})
+	}
+	// El primer lote falla y el segundo no: Close igual debe reportar el fallo.
+	if err := logger.Close(); err == nil {
+		t.Fatal("expected the failed batch to be reported on close")
+	}
+}
+
+func TestBatchAuditLoggerShutdownTimeout(t *testing.T) {
+	writer := &fakeBatchWriter{block: make(chan struct{})}
+	logger := NewBatchAuditLogger(writer, BatchConfig{})
+	_ = logger.Log(context.Background(), audit.AuditEvent{Action: "create"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := logger.Shutdown(ctx); !errors.Is(err, ErrShutdownTimeout) {
+		t.Fatalf("expected ErrShutdownTimeout, got %v", err)
+	}
+	close(writer.block)
+}
+
+func TestPostgresAuditLoggerImplementsBatchWriter(t *testing.T) {
+	var _ BatchWriter = NewPostgresAuditLogger(nil, "svc")
+	batch := NewBatchAuditLogger(&fakeBatchWriter{}, BatchConfig{})
+	defer batch.Close() //nolint:errcheck
+	var _ audit.AuditLogger = batch
+}
+
+func TestLogBatchEmptyIsNoop(t *testing.T) {
+	logger := NewPostgresAuditLogger(nil, "svc")
+	if err := logger.LogBatch(context.Background(), nil); err != nil {
+		t.Fatalf("expected nil error for empty batch, got %v", err)
+	}
+}
+
+func TestToDBModelUsesOccurredAt(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	model := internal.ToDBModel(audit.AuditEvent{OccurredAt: at})
+	if !model.CreatedAt.Equal(at) {
+		t.Errorf("expected CreatedAt %v, got %v", at, model.CreatedAt)
+	}
+}
@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+	"gorm.io/gorm"
+)
+
+// Motivos de ruptura reportados por VerifyChain.
+const (
+	// BreakHashMismatch: el contenido del evento no coincide con su hash (fila editada).
+	BreakHashMismatch = internal.BreakHashMismatch
+	// BreakPrevMismatch: prev_hash no apunta al hash del evento anterior.
+	BreakPrevMismatch = internal.BreakPrevMismatch
+	// BreakSeqGap: falta un evento en la secuencia (fila borrada).
+	BreakSeqGap = internal.BreakSeqGap
+)
+
+// verifyPageSize es el número de filas leídas por página al verificar.
+const verifyPageSize = 1000
+
+// ChainBreak describe el primer eslabón roto encontrado por VerifyChain.
+type ChainBreak struct {
+	ChainKey string // Partición "servicio|school_id"
+	EventID  string // ID de la fila donde se detectó la ruptura
+	Seq      int64  // chain_seq de la fila
+	Reason   string // BreakHashMismatch, BreakPrevMismatch o BreakSeqGap
+	Expected string
+	Actual   string
+}
+
+// ChainReport resume una verificación de la cadena de hashes.
+type ChainReport struct {
+	From     time.Time
+	To       time.Time
+	Chains   int         // Particiones recorridas
+	Verified int         // Eventos verificados correctamente
+	Break    *ChainBreak // Primera ruptura; nil si la cadena está íntegra
+}
+
+// OK indica si la verificación no encontró rupturas.
+func (r *ChainReport) OK() bool {
+	return r.Break == nil
+}
+
+type chainLink struct {
+	ChainSeq int64
+	Hash     string
+}
+
+// insertChained sella y persiste los registros dentro de una transacción.
+// Un advisory lock por partición serializa escritores concurrentes para
+// que dos eventos nunca compartan el mismo eslabón previo.
+func (l *PostgresAuditLogger) insertChained(ctx context.Context, records []internal.AuditEventDB) error {
+	now := time.Now()
+	byKey := make(map[string][]int)
+	for i := range records {
+		if records[i].CreatedAt.IsZero() {
+			records[i].CreatedAt = now
+		}
+		key := internal.ChainKeyFor(records[i].ServiceName, records[i].SchoolID)
+		byKey[key] = append(byKey[key], i)
+	}
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	// Orden estable de locks para evitar deadlocks entre lotes.
+	sort.Strings(keys)
+
+	return l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error; err != nil {
+				return fmt.Errorf("audit: lock de cadena %q: %w", key, err)
+			}
+			var last chainLink
+			err := tx.Raw(
+				"SELECT chain_seq, hash FROM audit.events WHERE chain_key = ? ORDER BY chain_seq DESC LIMIT 1", key,
+			).Scan(&last).Error
+			if err != nil {
+				return fmt.Errorf("audit: último eslabón de %q: %w", key, err)
+			}
+			for _, i := range byKey[key] {
+				if err := internal.SealRecord(&records[i], key, last.ChainSeq, last.Hash); err != nil {
+					return fmt.Errorf("audit: sellando evento: %w", err)
+				}
+				last = chainLink{ChainSeq: *records[i].ChainSeq, Hash: *records[i].Hash}
+			}
+		}
+		return tx.CreateInBatches(&records, insertBatchSize).Error
+	})
+}
+
+// VerifyChain recorre los eventos encadenados con created_at en [from, to) y
+// reporta el primer eslabón roto. Un from cero verifica desde el inicio y un
+// to cero no pone límite superior. Las filas sin hash (anteriores a habilitar
+// WithHashChain) se ignoran.
+//
+// Cada partición se verifica desde su primer evento dentro del rango hasta el
+// último, anclando en el eslabón inmediatamente anterior. El borrado de los
+// últimos eventos de una partición no es detectable sin un ancla externa.
+func (l *PostgresAuditLogger) VerifyChain(ctx context.Context, from, to time.Time) (*ChainReport, error) {
+	report := &ChainReport{From: from, To: to}
+	db := l.db.WithContext(ctx)
+
+	bounds := db.Model(&internal.AuditEventDB{}).
+		Select("chain_key, MIN(chain_seq) AS lo, MAX(chain_seq) AS hi").
+		Where("hash IS NOT NULL").
+		Group("chain_key")
+	if !from.IsZero() {
+		bounds = bounds.Where("created_at >= ?", from.UTC())
+	}
+	if !to.IsZero() {
+		bounds = bounds.Where("created_at < ?", to.UTC())
+	}
+
+	verifier := &internal.ChainVerifier{}
+	lastKey, lastSeq := "", int64(0)
+	for {
+		var rows []internal.AuditEventDB
+		err := db.Table("audit.events AS e").
+			Select("e.*").
+			Joins("JOIN (?) AS b ON e.chain_key = b.chain_key AND e.chain_seq BETWEEN b.lo AND b.hi", bounds).
+			Where("(e.chain_key, e.chain_seq) > (?, ?)", lastKey, lastSeq).
+			Order("e.chain_key, e.chain_seq").
+			Limit(verifyPageSize).
+			Find(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("audit: leyendo cadena: %w", err)
+		}
+
+		for _, row := range rows {
+			key := *row.ChainKey
+			if key != lastKey && *row.ChainSeq > 1 {
+				if brk, err := anchorChain(db, verifier, key, *row.ChainSeq-1); err != nil || brk != nil {
+					report.Break = brk
+					fillReport(report, verifier)
+					return report, err
+				}
+			}
+			brk, err := verifier.Next(row)
+			if err != nil {
+				return nil, fmt.Errorf("audit: verificando evento %s: %w", row.ID, err)
+			}
+			if brk != nil {
+				report.Break = toChainBreak(brk)
+				fillReport(report, verifier)
+				return report, nil
+			}
+			lastKey, lastSeq = key, *row.ChainSeq
+		}
+
+		if len(rows) < verifyPageSize {
+			break
+		}
+	}
+
+	fillReport(report, verifier)
+	return report, nil
+}
+
+// anchorChain carga el eslabón previo a la ventana de verificación de una partición.
+func anchorChain(db *gorm.DB, v *internal.ChainVerifier, key string, seq int64) (*ChainBreak, error) {
+	var prev chainLink
+	result := db.Raw("SELECT chain_seq, hash FROM audit.events WHERE chain_key = ? AND chain_seq = ?", key, seq).
+		Scan(&prev)
+	if result.Error != nil {
+		return nil, fmt.Errorf("audit: anclando cadena %q: %w", key, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		// El eslabón pudo archivarse junto con su partición.
+		found, err := archivedAnchor(db, key, seq, &prev)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			v.Anchor(key, prev.ChainSeq, prev.Hash)
+			return nil, nil
+		}
+		return &ChainBreak{
+			ChainKey: key,
+			Seq:      seq,
+			Reason:   BreakSeqGap,
+			Expected: strconv.FormatInt(seq, 10),
+		}, nil
+	}
+	v.Anchor(key, prev.ChainSeq, prev.Hash)
+	return nil, nil
+}
+
+// archivedAnchor busca el eslabón en audit.archive_chain_anchors, que
+// RetentionManager llena al archivar particiones con HashChain. Si la tabla no
+// existe, el eslabón se considera ausente.
+func archivedAnchor(db *gorm.DB, key string, seq int64, link *chainLink) (bool, error) {
+	var exists bool
+	if err := db.Raw("SELECT to_regclass('audit.archive_chain_anchors') IS NOT NULL").Scan(&exists).Error; err != nil {
+		return false, fmt.Errorf("audit: buscando anclas archivadas: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+	result := db.Raw("SELECT chain_seq, hash FROM audit.archive_chain_anchors WHERE chain_key = ? AND chain_seq = ?", key, seq).
+		Scan(link)
+	if result.Error != nil {
+		return false, fmt.Errorf("audit: anclando cadena archivada %q: %w", key, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func fillReport(report *ChainReport, v *internal.ChainVerifier) {
+	report.Chains = v.Chains
+	report.Verified = v.Verified
+}
+
+func toChainBreak(b *internal.ChainBreak) *ChainBreak {
+	return &ChainBreak{
+		ChainKey: b.ChainKey,
+		EventID:  b.EventID,
+		Seq:      b.Seq,
+		Reason:   b.Reason,
+		Expected: b.Expected,
+		Actual:   b.Actual,
+	}
+}
@@ -0,0 +1,153 @@
+package postgres
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+)
+
+func sealedChain(t *testing.T, n int) []internal.AuditEventDB {
+	t.Helper()
+	school := "School-1"
+	records := make([]internal.AuditEventDB, n)
+	lastSeq, lastHash := int64(0), ""
+	for i := range records {
+		records[i] = internal.ToDBModel(audit.AuditEvent{
+			ActorID:      "actor-1",
+			ActorEmail:   "actor@example.com",
+			ActorRole:    "admin",
+			ServiceName:  "admin-api",
+			SchoolID:     school,
+			Action:       "update",
+			ResourceType: "user",
+			Changes:      map[string]any{"count": i, "name": "x"},
+			OccurredAt:   time.Date(2026, 3, 1, 10, 0, i, 123456789, time.UTC),
+		})
+		records[i].ID = string(rune('a' + i))
+		key := internal.ChainKeyFor(records[i].ServiceName, records[i].SchoolID)
+		if err := internal.SealRecord(&records[i], key, lastSeq, lastHash); err != nil {
+			t.Fatalf("seal failed: %v", err)
+		}
+		lastSeq, lastHash = *records[i].ChainSeq, *records[i].Hash
+	}
+	return records
+}
+
+func verifyAll(t *testing.T, records []internal.AuditEventDB) *internal.ChainBreak {
+	t.Helper()
+	v := &internal.ChainVerifier{}
+	for _, r := range records {
+		brk, err := v.Next(r)
+		if err != nil {
+			t.Fatalf("verify failed: %v", err)
+		}
+		if brk != nil {
+			return brk
+		}
+	}
+	return nil
+}
+
+func TestSealRecordLinksToPrevious(t *testing.T) {
+	records := sealedChain(t, 3)
+
+	if *records[0].PrevHash != internal.GenesisHash {
+		t.Errorf("first event should link to genesis, got %s", *records[0].PrevHash)
+	}
+	if *records[1].PrevHash != *records[0].Hash || *records[2].PrevHash != *records[1].Hash {
+		t.Error("events should link to the previous hash")
+	}
+	if *records[2].ChainSeq != 3 {
+		t.Errorf("expected chain_seq 3, got %d", *records[2].ChainSeq)
+	}
+	if *records[0].ChainKey != "admin-api|school-1" {
+		t.Errorf("unexpected chain key %q", *records[0].ChainKey)
+	}
+	if records[0].CreatedAt.Nanosecond()%1000 != 0 {
+		t.Error("CreatedAt should be truncated to microseconds")
+	}
+}
+
+func TestComputeHashIsStableAcrossJSONRoundTrip(t *testing.T) {
+	records := sealedChain(t, 1)
+
+	// Simula la relectura desde JSONB: los enteros vuelven como float64.
+	raw, _ := json.Marshal(records[0].Changes)
+	var reread map[string]any
+	_ = json.Unmarshal(raw, &reread)
+	records[0].Changes = reread
+
+	hash, err := internal.ComputeHash(records[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash != *records[0].Hash {
+		t.Error("hash should survive a JSON round trip")
+	}
+}
+
+func TestChainVerifierAcceptsIntactChain(t *testing.T) {
+	if brk := verifyAll(t, sealedChain(t, 5)); brk != nil {
+		t.Fatalf("expected intact chain, got %+v", brk)
+	}
+}
+
+func TestChainVerifierDetectsEditedEvent(t *testing.T) {
+	records := sealedChain(t, 4)
+	records[2].Action = "delete"
+
+	brk := verifyAll(t, records)
+	if brk == nil || brk.Reason != BreakHashMismatch || brk.Seq != 3 {
+		t.Fatalf("expected hash mismatch at seq 3, got %+v", brk)
+	}
+}
+
+func TestChainVerifierDetectsDeletedEvent(t *testing.T) {
+	records := sealedChain(t, 4)
+	records = append(records[:1], records[2:]...)
+
+	brk := verifyAll(t, records)
+	if brk == nil || brk.Reason != BreakSeqGap || brk.Seq != 3 {
+		t.Fatalf("expected seq gap at seq 3, got %+v", brk)
+	}
+}
+
+func TestChainVerifierDetectsRehashedEvent(t *testing.T) {
+	records := sealedChain(t, 3)
+	// Un atacante edita la fila y recalcula su hash, pero no el de las siguientes.
+	records[1].Action = "delete"
+	hash, _ := internal.ComputeHash(records[1])
+	records[1].Hash = &hash
+
+	brk := verifyAll(t, records)
+	if brk == nil || brk.Reason != BreakPrevMismatch || brk.Seq != 3 {
+		t.Fatalf("expected prev mismatch at seq 3, got %+v", brk)
+	}
+}
+
+func TestChainVerifierAnchorsMidChain(t *testing.T) {
+	records := sealedChain(t, 4)
+	v := &internal.ChainVerifier{}
+	v.Anchor(*records[1].ChainKey, *records[1].ChainSeq, *records[1].Hash)
+
+	for _, r := range records[2:] {
+		if brk, err := v.Next(r); err != nil || brk != nil {
+			t.Fatalf("expected anchored chain to verify, got %+v %v", brk, err)
+		}
+	}
+	if v.Verified != 2 || v.Chains != 1 {
+		t.Errorf("expected 2 verified in 1 chain, got %d/%d", v.Verified, v.Chains)
+	}
+}
+
+func TestWithHashChainOption(t *testing.T) {
+	if NewPostgresAuditLogger(nil, "svc").hashChain {
+		t.Error("hash chain should be disabled by default")
+	}
+	if !NewPostgresAuditLogger(nil, "svc", WithHashChain()).hashChain {
+		t.Error("WithHashChain should enable the hash chain")
+	}
+}
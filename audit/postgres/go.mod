@@ -3,7 +3,7 @@ module github.com/EduGoGroup/edugo-shared/audit/postgres
 go 1.25.0
 
 require (
-	github.com/EduGoGroup/edugo-shared/audit v0.900.0
+	github.com/EduGoGroup/edugo-shared/audit v0.900.1
 	github.com/gin-gonic/gin v1.12.0
 	gorm.io/gorm v1.31.1
 )
@@ -41,3 +41,5 @@ require (
 	golang.org/x/text v0.37.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
 )
+
+replace github.com/EduGoGroup/edugo-shared/audit => ../
@@ -0,0 +1,121 @@
+package internal
+
+import "time"
+
+// ArchiveRecord es una línea del NDJSON que archiva RetentionManager: los
+// campos de audit.Export más las columnas de la cadena de hashes, para que
+// un mes archivado se pueda verificar. Changes y Metadata no usan omitempty:
+// null y {} producen hashes distintos.
+type ArchiveRecord struct {
+	ID             string         `json:"id"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+	ServiceName    string         `json:"service_name"`
+	ActorID        string         `json:"actor_id"`
+	ActorEmail     string         `json:"actor_email"`
+	ActorRole      string         `json:"actor_role"`
+	ActorIP        string         `json:"actor_ip,omitempty"`
+	ActorUserAgent string         `json:"actor_user_agent,omitempty"`
+	SchoolID       string         `json:"school_id,omitempty"`
+	UnitID         string         `json:"unit_id,omitempty"`
+	Action         string         `json:"action"`
+	ResourceType   string         `json:"resource_type"`
+	ResourceID     string         `json:"resource_id,omitempty"`
+	PermissionUsed string         `json:"permission_used,omitempty"`
+	RequestMethod  string         `json:"request_method,omitempty"`
+	RequestPath    string         `json:"request_path,omitempty"`
+	RequestID      string         `json:"request_id,omitempty"`
+	StatusCode     int            `json:"status_code,omitempty"`
+	Severity       string         `json:"severity"`
+	Category       string         `json:"category"`
+	ErrorMessage   string         `json:"error_message,omitempty"`
+	Changes        map[string]any `json:"changes"`
+	Metadata       map[string]any `json:"metadata"`
+	ChainKey       string         `json:"chain_key,omitempty"`
+	ChainSeq       int64          `json:"chain_seq,omitempty"`
+	PrevHash       string         `json:"prev_hash,omitempty"`
+	Hash           string         `json:"hash,omitempty"`
+}
+
+// ToArchiveRecord convierte una fila al formato archivado.
+func ToArchiveRecord(r AuditEventDB) ArchiveRecord {
+	a := ArchiveRecord{
+		ID:             r.ID,
+		OccurredAt:     r.CreatedAt.UTC(),
+		ServiceName:    r.ServiceName,
+		ActorID:        r.ActorID,
+		ActorEmail:     r.ActorEmail,
+		ActorRole:      r.ActorRole,
+		ActorIP:        deref(r.ActorIP),
+		ActorUserAgent: deref(r.ActorUserAgent),
+		SchoolID:       deref(r.SchoolID),
+		UnitID:         deref(r.UnitID),
+		Action:         r.Action,
+		ResourceType:   r.ResourceType,
+		ResourceID:     deref(r.ResourceID),
+		PermissionUsed: deref(r.PermissionUsed),
+		RequestMethod:  deref(r.RequestMethod),
+		RequestPath:    deref(r.RequestPath),
+		RequestID:      deref(r.RequestID),
+		Severity:       r.Severity,
+		Category:       r.Category,
+		ErrorMessage:   deref(r.ErrorMessage),
+		Changes:        r.Changes,
+		Metadata:       r.Metadata,
+		ChainKey:       deref(r.ChainKey),
+		PrevHash:       deref(r.PrevHash),
+		Hash:           deref(r.Hash),
+	}
+	if r.StatusCode != nil {
+		a.StatusCode = *r.StatusCode
+	}
+	if r.ChainSeq != nil {
+		a.ChainSeq = *r.ChainSeq
+	}
+	return a
+}
+
+// ToDBModel reconstruye la fila desde una línea archivada, con los mismos
+// valores que usa ComputeHash.
+func (a ArchiveRecord) ToDBModel() AuditEventDB {
+	r := AuditEventDB{
+		ID:             a.ID,
+		ActorID:        a.ActorID,
+		ActorEmail:     a.ActorEmail,
+		ActorRole:      a.ActorRole,
+		ActorIP:        ptr(a.ActorIP),
+		ActorUserAgent: ptr(a.ActorUserAgent),
+		SchoolID:       ptr(a.SchoolID),
+		UnitID:         ptr(a.UnitID),
+		ServiceName:    a.ServiceName,
+		Action:         a.Action,
+		ResourceType:   a.ResourceType,
+		ResourceID:     ptr(a.ResourceID),
+		PermissionUsed: ptr(a.PermissionUsed),
+		RequestMethod:  ptr(a.RequestMethod),
+		RequestPath:    ptr(a.RequestPath),
+		RequestID:      ptr(a.RequestID),
+		Changes:        a.Changes,
+		Metadata:       a.Metadata,
+		ErrorMessage:   ptr(a.ErrorMessage),
+		CreatedAt:      a.OccurredAt,
+		Severity:       a.Severity,
+		Category:       a.Category,
+		ChainKey:       ptr(a.ChainKey),
+		PrevHash:       ptr(a.PrevHash),
+		Hash:           ptr(a.Hash),
+	}
+	if a.StatusCode != 0 {
+		r.StatusCode = &a.StatusCode
+	}
+	if a.ChainSeq != 0 {
+		r.ChainSeq = &a.ChainSeq
+	}
+	return r
+}
+
+func ptr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,242 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenesisHash es el prev_hash del primer evento de cada cadena.
+const GenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// Motivos de ruptura detectados por ChainVerifier.
+const (
+	BreakHashMismatch = "hash_mismatch" // el contenido del evento fue alterado
+	BreakPrevMismatch = "prev_mismatch" // prev_hash no coincide con el hash del evento anterior
+	BreakSeqGap       = "seq_gap"       // falta al menos un evento (borrado) en la secuencia
+)
+
+// canonicalEvent fija el orden y formato de los campos que entran al hash.
+// Cualquier cambio aquí invalida las cadenas existentes.
+type canonicalEvent struct {
+	ChainKey       string `json:"chain_key"`
+	ChainSeq       int64  `json:"chain_seq"`
+	PrevHash       string `json:"prev_hash"`
+	CreatedAt      string `json:"created_at"`
+	ActorID        string `json:"actor_id"`
+	ActorEmail     string `json:"actor_email"`
+	ActorRole      string `json:"actor_role"`
+	ActorIP        string `json:"actor_ip"`
+	ActorUserAgent string `json:"actor_user_agent"`
+	SchoolID       string `json:"school_id"`
+	UnitID         string `json:"unit_id"`
+	ServiceName    string `json:"service_name"`
+	Action         string `json:"action"`
+	ResourceType   string `json:"resource_type"`
+	ResourceID     string `json:"resource_id"`
+	PermissionUsed string `json:"permission_used"`
+	RequestMethod  string `json:"request_method"`
+	RequestPath    string `json:"request_path"`
+	RequestID      string `json:"request_id"`
+	StatusCode     int    `json:"status_code"`
+	Changes        any    `json:"changes"`
+	Metadata       any    `json:"metadata"`
+	ErrorMessage   string `json:"error_message"`
+	Severity       string `json:"severity"`
+	Category       string `json:"category"`
+}
+
+// ChainKeyFor retorna la partición de la cadena: servicio + escuela.
+func ChainKeyFor(serviceName string, schoolID *string) string {
+	school := ""
+	if schoolID != nil {
+		school = strings.ToLower(*schoolID)
+	}
+	return serviceName + "|" + school
+}
+
+// ChainTime normaliza la marca de tiempo a la precisión de PostgreSQL
+// (microsegundos, UTC) para que el hash sea reproducible al releer la fila.
+func ChainTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// ComputeHash calcula el SHA-256 de la forma canónica del registro. Usa
+// ChainKey, ChainSeq y PrevHash del propio registro, que deben estar fijados.
+func ComputeHash(r AuditEventDB) (string, error) {
+	changes, err := canonicalJSON(r.Changes)
+	if err != nil {
+		return "", err
+	}
+	metadata, err := canonicalJSON(r.Metadata)
+	if err != nil {
+		return "", err
+	}
+
+	c := canonicalEvent{
+		ChainKey:       deref(r.ChainKey),
+		PrevHash:       deref(r.PrevHash),
+		CreatedAt:      ChainTime(r.CreatedAt).Format(time.RFC3339Nano),
+		ActorID:        r.ActorID,
+		ActorEmail:     r.ActorEmail,
+		ActorRole:      r.ActorRole,
+		ActorIP:        deref(r.ActorIP),
+		ActorUserAgent: deref(r.ActorUserAgent),
+		SchoolID:       strings.ToLower(deref(r.SchoolID)),
+		UnitID:         strings.ToLower(deref(r.UnitID)),
+		ServiceName:    r.ServiceName,
+		Action:         r.Action,
+		ResourceType:   r.ResourceType,
+		ResourceID:     strings.ToLower(deref(r.ResourceID)),
+		PermissionUsed: deref(r.PermissionUsed),
+		RequestMethod:  deref(r.RequestMethod),
+		RequestPath:    deref(r.RequestPath),
+		RequestID:      strings.ToLower(deref(r.RequestID)),
+		Changes:        changes,
+		Metadata:       metadata,
+		ErrorMessage:   deref(r.ErrorMessage),
+		Severity:       r.Severity,
+		Category:       r.Category,
+	}
+	if r.ChainSeq != nil {
+		c.ChainSeq = *r.ChainSeq
+	}
+	if r.StatusCode != nil {
+		c.StatusCode = *r.StatusCode
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SealRecord fija los campos de la cadena en el registro y calcula su hash
+// a partir del último eslabón conocido (lastSeq=0 y lastHash="" para cadena nueva).
+func SealRecord(r *AuditEventDB, chainKey string, lastSeq int64, lastHash string) error {
+	if lastHash == "" {
+		lastHash = GenesisHash
+	}
+	seq := lastSeq + 1
+	r.CreatedAt = ChainTime(r.CreatedAt)
+	r.ChainKey = &chainKey
+	r.ChainSeq = &seq
+	r.PrevHash = &lastHash
+
+	hash, err := ComputeHash(*r)
+	if err != nil {
+		return err
+	}
+	r.Hash = &hash
+	return nil
+}
+
+// ChainBreak describe el primer eslabón roto encontrado.
+type ChainBreak struct {
+	ChainKey string
+	EventID  string
+	Seq      int64
+	Reason   string
+	Expected string
+	Actual   string
+}
+
+// ChainVerifier recorre filas ordenadas por (chain_key, chain_seq) y detecta
+// la primera ruptura. Es puro: no accede a la base de datos.
+type ChainVerifier struct {
+	currentKey string
+	lastSeq    int64
+	lastHash   string
+	started    bool
+	Verified   int
+	Chains     int
+}
+
+// Anchor fija el eslabón previo conocido para una cadena cuyo recorrido no
+// empieza en chain_seq=1 (por ejemplo, al verificar un rango de fechas).
+func (v *ChainVerifier) Anchor(chainKey string, seq int64, hash string) {
+	v.currentKey = chainKey
+	v.lastSeq = seq
+	v.lastHash = hash
+	v.started = true
+	v.Chains++
+}
+
+// Next verifica una fila. Retorna la ruptura si la fila no encadena correctamente.
+func (v *ChainVerifier) Next(r AuditEventDB) (*ChainBreak, error) {
+	key := deref(r.ChainKey)
+	if !v.started || key != v.currentKey {
+		v.currentKey = key
+		v.lastSeq = 0
+		v.lastHash = GenesisHash
+		v.started = true
+		v.Chains++
+	}
+
+	seq := int64(0)
+	if r.ChainSeq != nil {
+		seq = *r.ChainSeq
+	}
+	brk := &ChainBreak{ChainKey: key, EventID: r.ID, Seq: seq}
+
+	if seq != v.lastSeq+1 {
+		brk.Reason = BreakSeqGap
+		brk.Expected = formatSeq(v.lastSeq + 1)
+		brk.Actual = formatSeq(seq)
+		return brk, nil
+	}
+	if deref(r.PrevHash) != v.lastHash {
+		brk.Reason = BreakPrevMismatch
+		brk.Expected = v.lastHash
+		brk.Actual = deref(r.PrevHash)
+		return brk, nil
+	}
+	computed, err := ComputeHash(r)
+	if err != nil {
+		return nil, err
+	}
+	if computed != deref(r.Hash) {
+		brk.Reason = BreakHashMismatch
+		brk.Expected = computed
+		brk.Actual = deref(r.Hash)
+		return brk, nil
+	}
+
+	v.lastSeq = seq
+	v.lastHash = computed
+	v.Verified++
+	return nil, nil
+}
+
+// canonicalJSON normaliza un valor pasándolo por un ciclo JSON, de modo que
+// el hash calculado al escribir coincida con el de la fila releída de JSONB.
+func canonicalJSON(v map[string]any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatSeq(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -15,6 +15,7 @@ func ToDBModel(event audit.AuditEvent) AuditEventDB {
 		Metadata:     event.Metadata,
 		Severity:     event.Severity,
 		Category:     event.Category,
+		CreatedAt:    event.OccurredAt,
 	}
 	if event.ActorIP != "" {
 		r.ActorIP = &event.ActorIP
@@ -51,3 +52,48 @@ func ToDBModel(event audit.AuditEvent) AuditEventDB {
 	}
 	return r
 }
+
+// FromDBModel convierte una fila AuditEventDB a audit.AuditRecord.
+func FromDBModel(r AuditEventDB) audit.AuditRecord {
+	rec := audit.AuditRecord{
+		ID: r.ID,
+		AuditEvent: audit.AuditEvent{
+			ActorID:        r.ActorID,
+			ActorEmail:     r.ActorEmail,
+			ActorRole:      r.ActorRole,
+			ActorIP:        deref(r.ActorIP),
+			ActorUserAgent: deref(r.ActorUserAgent),
+			SchoolID:       deref(r.SchoolID),
+			UnitID:         deref(r.UnitID),
+			ServiceName:    r.ServiceName,
+			Action:         r.Action,
+			ResourceType:   r.ResourceType,
+			ResourceID:     deref(r.ResourceID),
+			PermissionUsed: deref(r.PermissionUsed),
+			RequestMethod:  deref(r.RequestMethod),
+			RequestPath:    deref(r.RequestPath),
+			RequestID:      deref(r.RequestID),
+			Changes:        r.Changes,
+			Metadata:       r.Metadata,
+			ErrorMessage:   deref(r.ErrorMessage),
+			Severity:       r.Severity,
+			Category:       r.Category,
+			OccurredAt:     r.CreatedAt,
+		},
+	}
+	if r.StatusCode != nil {
+		rec.StatusCode = *r.StatusCode
+	}
+	return rec
+}
+
+// ToColdPeriod convierte una entrada del manifiesto de archivo a audit.ColdPeriod.
+func ToColdPeriod(m ArchiveManifestDB) audit.ColdPeriod {
+	return audit.ColdPeriod{
+		From:       m.PeriodStart.UTC(),
+		To:         m.PeriodEnd.UTC(),
+		Location:   m.ObjectKey,
+		Events:     m.EventCount,
+		ArchivedAt: m.ArchivedAt.UTC(),
+	}
+}
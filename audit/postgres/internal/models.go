@@ -27,9 +27,35 @@ type AuditEventDB struct {
 	CreatedAt      time.Time      `gorm:"column:created_at;autoCreateTime"`
 	Severity       string         `gorm:"column:severity;not null;default:info"`
 	Category       string         `gorm:"column:category;not null;default:data"`
+	ChainKey       *string        `gorm:"column:chain_key"`
+	ChainSeq       *int64         `gorm:"column:chain_seq"`
+	PrevHash       *string        `gorm:"column:prev_hash"`
+	Hash           *string        `gorm:"column:hash"`
 }
 
+// ChainColumns son las columnas de la cadena de hashes. Se omiten en los
+// INSERT cuando la cadena no está habilitada, para no exigir la migración.
+var ChainColumns = []string{"chain_key", "chain_seq", "prev_hash", "hash"}
+
 // TableName especifica el nombre de la tabla en la base de datos.
 func (AuditEventDB) TableName() string {
 	return "audit.events"
 }
+
+// ArchiveManifestDB es el modelo GORM para audit.archive_manifest: un registro
+// por partición mensual archivada en object storage.
+type ArchiveManifestDB struct {
+	PeriodStart time.Time `gorm:"column:period_start;primaryKey"`
+	PeriodEnd   time.Time `gorm:"column:period_end;not null"`
+	ObjectKey   string    `gorm:"column:object_key;not null"`
+	EventCount  int64     `gorm:"column:event_count;not null"`
+	SizeBytes   int64     `gorm:"column:size_bytes;not null"`
+	SHA256      string    `gorm:"column:sha256;not null"`
+	Encrypted   bool      `gorm:"column:encrypted;not null"`
+	ArchivedAt  time.Time `gorm:"column:archived_at;autoCreateTime"`
+}
+
+// TableName especifica el nombre de la tabla en la base de datos.
+func (ArchiveManifestDB) TableName() string {
+	return "audit.archive_manifest"
+}
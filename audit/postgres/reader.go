@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+	"gorm.io/gorm"
+)
+
+// PostgresAuditReader implementa audit.Reader sobre la tabla audit.events.
+// Pagina por keyset (created_at DESC, id DESC), por lo que el costo de una
+// página no depende de su posición. Se recomienda el índice:
+//
+//	CREATE INDEX events_created_at_id_idx ON audit.events (created_at DESC, id DESC);
+type PostgresAuditReader struct { //nolint:revive
+	db *gorm.DB
+}
+
+// NewPostgresAuditReader crea un lector de eventos de auditoría.
+func NewPostgresAuditReader(db *gorm.DB) *PostgresAuditReader {
+	return &PostgresAuditReader{db: db}
+}
+
+// Query retorna una página de eventos que cumplen el filtro.
+func (r *PostgresAuditReader) Query(ctx context.Context, q audit.AuditQuery) (audit.AuditPage, error) {
+	limit := audit.NormalizeLimit(q.Limit)
+
+	tx, err := r.filtered(ctx, q)
+	if err != nil {
+		return audit.AuditPage{}, err
+	}
+
+	var rows []internal.AuditEventDB
+	if err := tx.Limit(limit + 1).Find(&rows).Error; err != nil {
+		return audit.AuditPage{}, fmt.Errorf("audit: consultando eventos: %w", err)
+	}
+
+	page := audit.AuditPage{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = audit.EncodeCursor(last.CreatedAt, last.ID)
+	}
+	page.Records = make([]audit.AuditRecord, len(rows))
+	for i, row := range rows {
+		page.Records[i] = internal.FromDBModel(row)
+	}
+	return page, nil
+}
+
+// Stream recorre los eventos fila a fila con un cursor de base de datos.
+// q.Limit igual a 0 recorre todo el resultado.
+func (r *PostgresAuditReader) Stream(ctx context.Context, q audit.AuditQuery, fn func(audit.AuditRecord) error) error {
+	tx, err := r.filtered(ctx, q)
+	if err != nil {
+		return err
+	}
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		return fmt.Errorf("audit: abriendo stream de eventos: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		var row internal.AuditEventDB
+		if err := tx.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("audit: leyendo evento: %w", err)
+		}
+		if err := fn(internal.FromDBModel(row)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ColdPeriods retorna los meses archivados por RetentionManager que se
+// solapan con [from, to), según audit.archive_manifest.
+func (r *PostgresAuditReader) ColdPeriods(ctx context.Context, from, to time.Time) ([]audit.ColdPeriod, error) {
+	tx := r.db.WithContext(ctx).Model(&internal.ArchiveManifestDB{})
+	if !from.IsZero() {
+		tx = tx.Where("period_end > ?", from.UTC())
+	}
+	if !to.IsZero() {
+		tx = tx.Where("period_start < ?", to.UTC())
+	}
+
+	var rows []internal.ArchiveManifestDB
+	if err := tx.Order("period_start").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("audit: consultando manifiesto de archivo: %w", err)
+	}
+	periods := make([]audit.ColdPeriod, len(rows))
+	for i, row := range rows {
+		periods[i] = internal.ToColdPeriod(row)
+	}
+	return periods, nil
+}
+
+// filtered construye la consulta con filtros, cursor y orden.
+func (r *PostgresAuditReader) filtered(ctx context.Context, q audit.AuditQuery) (*gorm.DB, error) {
+	tx := r.db.WithContext(ctx).Model(&internal.AuditEventDB{})
+
+	equals := []struct{ column, value string }{
+		{"actor_id", q.ActorID},
+		{"school_id", q.SchoolID},
+		{"unit_id", q.UnitID},
+		{"resource_type", q.ResourceType},
+		{"resource_id", q.ResourceID},
+	}
+	for _, f := range equals {
+		if f.value != "" {
+			tx = tx.Where(f.column+" = ?", f.value)
+		}
+	}
+	if len(q.Actions) > 0 {
+		tx = tx.Where("action IN ?", q.Actions)
+	}
+	if len(q.Severities) > 0 {
+		tx = tx.Where("severity IN ?", q.Severities)
+	}
+	if len(q.Categories) > 0 {
+		tx = tx.Where("category IN ?", q.Categories)
+	}
+	if !q.From.IsZero() {
+		tx = tx.Where("created_at >= ?", q.From.UTC())
+	}
+	if !q.To.IsZero() {
+		tx = tx.Where("created_at < ?", q.To.UTC())
+	}
+	if q.Cursor != "" {
+		at, id, err := audit.DecodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where("(created_at, id) < (?, ?)", at, id)
+	}
+
+	return tx.Order("created_at DESC, id DESC"), nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+func querySQL(t *testing.T, q audit.AuditQuery) string {
+	t.Helper()
+	reader := NewPostgresAuditReader(dryRunDB(t))
+	tx, err := reader.filtered(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rows []internal.AuditEventDB
+	stmt := tx.Limit(10).Find(&rows).Statement
+	return stmt.SQL.String()
+}
+
+func TestReaderBuildsFilters(t *testing.T) {
+	sql := querySQL(t, audit.AuditQuery{
+		ActorID:      "actor-1",
+		SchoolID:     "school-1",
+		ResourceType: "user",
+		Actions:      []string{"create", "delete"},
+		Severities:   []string{audit.SeverityCritical},
+		Categories:   []string{audit.CategoryAuth},
+		From:         time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:           time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	for _, fragment := range []string{
+		"actor_id = ?", "school_id = ?", "resource_type = ?",
+		"action IN (?,?)", "severity IN (?)", "category IN (?)",
+		"created_at >= ?", "created_at < ?",
+		"ORDER BY created_at DESC, id DESC",
+	} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("expected %q in SQL: %s", fragment, sql)
+		}
+	}
+	if strings.Contains(sql, "unit_id") || strings.Contains(sql, "resource_id") {
+		t.Errorf("empty filters should not appear in SQL: %s", sql)
+	}
+}
+
+func TestReaderAppliesCursor(t *testing.T) {
+	cursor := audit.EncodeCursor(time.Now(), "evt-1")
+	sql := querySQL(t, audit.AuditQuery{Cursor: cursor})
+	if !strings.Contains(sql, "(created_at, id) < (?, ?)") {
+		t.Errorf("expected keyset condition in SQL: %s", sql)
+	}
+}
+
+func TestReaderRejectsInvalidCursor(t *testing.T) {
+	reader := NewPostgresAuditReader(dryRunDB(t))
+	_, err := reader.Query(context.Background(), audit.AuditQuery{Cursor: "%%%"})
+	if !errors.Is(err, audit.ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestFromDBModelRoundTrip(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := audit.AuditEvent{
+		ActorID:      "actor-1",
+		ActorEmail:   "a@example.com",
+		ActorRole:    "admin",
+		SchoolID:     "school-1",
+		ServiceName:  "svc",
+		Action:       "update",
+		ResourceType: "user",
+		ResourceID:   "user-9",
+		StatusCode:   200,
+		Severity:     audit.SeverityWarning,
+		Category:     audit.CategoryAdmin,
+		OccurredAt:   at,
+	}
+	row := internal.ToDBModel(event)
+	row.ID = "evt-1"
+
+	rec := internal.FromDBModel(row)
+	if rec.ID != "evt-1" || rec.AuditEvent.SchoolID != "school-1" || rec.StatusCode != 200 || !rec.OccurredAt.Equal(at) {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if rec.ActorIP != "" || rec.UnitID != "" {
+		t.Errorf("nil columns should map to empty strings: %+v", rec)
+	}
+}
+
+func TestPostgresAuditReaderImplementsReader(t *testing.T) {
+	var _ audit.Reader = NewPostgresAuditReader(nil)
+}
@@ -0,0 +1,581 @@
+package postgres
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults de RetentionConfig.
+const (
+	DefaultRetentionMaxAge = 365 * 24 * time.Hour
+	DefaultPremakeMonths   = 3
+	DefaultArchivePrefix   = "audit/events"
+)
+
+// partitionPrefix es el prefijo de las particiones mensuales (events_pYYYYMM).
+const partitionPrefix = "events_p"
+
+var (
+	// ErrArchiveStoreRequired indica que RetentionConfig.Store no fue configurado.
+	ErrArchiveStoreRequired = errors.New("audit: RetentionConfig.Store es requerido")
+	// ErrArchiveNotFound indica que la ubicación no figura en el manifiesto.
+	ErrArchiveNotFound = errors.New("audit: archivo no registrado en el manifiesto")
+	// ErrArchiveChecksum indica que el archivo descargado no coincide con el manifiesto.
+	ErrArchiveChecksum = errors.New("audit: checksum del archivo no coincide")
+	// ErrArchiveSealerRequired indica que el archivo está cifrado y no hay Sealer.
+	ErrArchiveSealerRequired = errors.New("audit: el archivo está cifrado y no hay RetentionConfig.Sealer")
+)
+
+// ArchiveStore es el subconjunto de storage.Client que usa la retención.
+// Cualquier storage.Client de edugo-shared/storage (p. ej. s3) lo satisface.
+type ArchiveStore interface {
+	Upload(ctx context.Context, key string, content io.Reader) error
+	Download(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// ArchiveSealer cifra los archivos antes de subirlos.
+// *envelope.Envelope de edugo-shared/crypto/envelope lo satisface.
+type ArchiveSealer interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(blob []byte) ([]byte, error)
+}
+
+// RetentionConfig configura el RetentionManager.
+type RetentionConfig struct {
+	// Store recibe los archivos NDJSON comprimidos. Requerido.
+	Store ArchiveStore
+	// Sealer, si no es nil, cifra cada archivo antes de subirlo.
+	Sealer ArchiveSealer
+	// KeyPrefix es el prefijo de las claves en Store (default DefaultArchivePrefix).
+	KeyPrefix string
+	// MaxAge es la antigüedad a partir de la cual una partición completa se
+	// archiva y se elimina (default DefaultRetentionMaxAge).
+	MaxAge time.Duration
+	// PremakeMonths es cuántos meses futuros se crean por adelantado (default DefaultPremakeMonths).
+	PremakeMonths int
+	// HashChain registra el último eslabón de cada cadena antes de eliminar la
+	// partición, para que VerifyChain pueda anclar los eventos restantes.
+	HashChain bool
+}
+
+// RetentionManager administra las particiones mensuales de audit.events:
+// las crea por adelantado y archiva en object storage las que superan MaxAge,
+// registrándolas en audit.archive_manifest antes de eliminarlas.
+// Requiere la migración a tabla particionada descrita en docs/README.md.
+type RetentionManager struct {
+	db     *gorm.DB
+	config RetentionConfig
+	// rows recorre los eventos de una partición; rows y now son
+	// reemplazables en tests.
+	rows func(ctx context.Context, p partition, fn func(internal.AuditEventDB) error) error
+	now  func() time.Time
+}
+
+// NewRetentionManager crea un RetentionManager. Retorna ErrArchiveStoreRequired
+// si config.Store es nil.
+func NewRetentionManager(db *gorm.DB, config RetentionConfig) (*RetentionManager, error) {
+	if config.Store == nil {
+		return nil, ErrArchiveStoreRequired
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = DefaultArchivePrefix
+	}
+	config.KeyPrefix = strings.TrimSuffix(config.KeyPrefix, "/")
+	if config.MaxAge <= 0 {
+		config.MaxAge = DefaultRetentionMaxAge
+	}
+	if config.PremakeMonths < 0 {
+		config.PremakeMonths = 0
+	} else if config.PremakeMonths == 0 {
+		config.PremakeMonths = DefaultPremakeMonths
+	}
+	m := &RetentionManager{db: db, config: config, now: time.Now}
+	m.rows = m.partitionRows
+	return m, nil
+}
+
+// Run crea las particiones pendientes y archiva las vencidas. Pensado para
+// ejecutarse periódicamente (p. ej. un job diario).
+func (m *RetentionManager) Run(ctx context.Context) ([]audit.ColdPeriod, error) {
+	if err := m.EnsurePartitions(ctx); err != nil {
+		return nil, err
+	}
+	return m.ArchiveExpired(ctx)
+}
+
+// EnsurePartitions crea la partición del mes actual y las PremakeMonths siguientes.
+func (m *RetentionManager) EnsurePartitions(ctx context.Context) error {
+	current := monthStart(m.now())
+	for i := 0; i <= m.config.PremakeMonths; i++ {
+		p := partitionFor(current.AddDate(0, i, 0))
+		if err := m.db.WithContext(ctx).Exec(p.createSQL()).Error; err != nil {
+			return fmt.Errorf("audit: creando partición %s: %w", p.name, err)
+		}
+	}
+	return nil
+}
+
+// ArchiveExpired archiva, de la más antigua a la más reciente, las particiones
+// cuyo mes terminó antes de now-MaxAge. Cada una se separa primero de
+// audit.events (DETACH), para que ninguna escritura posterior quede fuera del
+// archivo; luego se exporta a NDJSON, se comprime con gzip, se cifra si hay
+// Sealer y se sube a Store en streaming. Por último, en una transacción, se
+// registra en el manifiesto y se elimina la tabla.
+//
+// Si una partición falla se detiene y retorna las ya archivadas. Una partición
+// separada pero no eliminada se retoma en la siguiente ejecución.
+func (m *RetentionManager) ArchiveExpired(ctx context.Context) ([]audit.ColdPeriod, error) {
+	var tables []partitionTable
+	if err := m.db.WithContext(ctx).Raw(listPartitionsSQL).Scan(&tables).Error; err != nil {
+		return nil, fmt.Errorf("audit: listando particiones: %w", err)
+	}
+
+	archived := make([]audit.ColdPeriod, 0)
+	for _, p := range expiredPartitions(tables, m.now().Add(-m.config.MaxAge)) {
+		period, err := m.archivePartition(ctx, p)
+		if err != nil {
+			return archived, err
+		}
+		if period != nil {
+			archived = append(archived, *period)
+		}
+	}
+	return archived, nil
+}
+
+// OpenArchive descarga un archivo registrado en el manifiesto (location es
+// ColdPeriod.Location), verifica su checksum, lo descifra si corresponde y
+// retorna el NDJSON descomprimido. El caller debe cerrar el ReadCloser.
+func (m *RetentionManager) OpenArchive(ctx context.Context, location string) (io.ReadCloser, error) {
+	var manifest internal.ArchiveManifestDB
+	result := m.db.WithContext(ctx).Where("object_key = ?", location).Limit(1).Find(&manifest)
+	if result.Error != nil {
+		return nil, fmt.Errorf("audit: consultando manifiesto: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrArchiveNotFound
+	}
+
+	rc, err := m.config.Store.Download(ctx, location)
+	if err != nil {
+		return nil, fmt.Errorf("audit: descargando %s: %w", location, err)
+	}
+	defer rc.Close() //nolint:errcheck
+	blob, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("audit: leyendo %s: %w", location, err)
+	}
+	return m.decodeArchive(blob, manifest.SHA256, manifest.Encrypted)
+}
+
+// VerifyArchive verifica la cadena de hashes de un mes archivado, anclando
+// cada cadena en el eslabón anterior (en audit.events o en
+// audit.archive_chain_anchors). Los eventos sin hash se ignoran.
+func (m *RetentionManager) VerifyArchive(ctx context.Context, location string) (*ChainReport, error) {
+	rc, err := m.OpenArchive(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	db := m.db.WithContext(ctx)
+	report := &ChainReport{}
+	verifier := &internal.ChainVerifier{}
+	lastKey := ""
+	dec := json.NewDecoder(rc)
+	for {
+		var rec internal.ArchiveRecord
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("audit: leyendo %s: %w", location, err)
+		}
+		if rec.Hash == "" {
+			continue
+		}
+		if rec.ChainKey != lastKey && rec.ChainSeq > 1 {
+			if brk, err := anchorChain(db, verifier, rec.ChainKey, rec.ChainSeq-1); err != nil || brk != nil {
+				report.Break = brk
+				fillReport(report, verifier)
+				return report, err
+			}
+		}
+		lastKey = rec.ChainKey
+		brk, err := verifier.Next(rec.ToDBModel())
+		if err != nil {
+			return nil, fmt.Errorf("audit: verificando evento %s: %w", rec.ID, err)
+		}
+		if brk != nil {
+			report.Break = toChainBreak(brk)
+			break
+		}
+	}
+	fillReport(report, verifier)
+	return report, nil
+}
+
+func (m *RetentionManager) archivePartition(ctx context.Context, p partition) (*audit.ColdPeriod, error) {
+	db := m.db.WithContext(ctx)
+	if p.attached {
+		if err := db.Exec(p.detachSQL()).Error; err != nil {
+			return nil, fmt.Errorf("audit: separando partición %s: %w", p.name, err)
+		}
+	}
+
+	var count int64
+	if err := db.Table("audit." + p.name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("audit: contando eventos de %s: %w", p.name, err)
+	}
+	if count == 0 {
+		if err := db.Exec(p.dropSQL()).Error; err != nil {
+			return nil, fmt.Errorf("audit: eliminando partición vacía %s: %w", p.name, err)
+		}
+		return nil, nil
+	}
+
+	key := m.objectKey(p)
+	stats, err := m.uploadArchive(ctx, key, p)
+	if err != nil {
+		return nil, err
+	}
+	if stats.events != count {
+		return nil, fmt.Errorf("audit: la partición %s tiene %d eventos y se archivaron %d", p.name, count, stats.events)
+	}
+
+	manifest := internal.ArchiveManifestDB{
+		PeriodStart: p.from,
+		PeriodEnd:   p.to,
+		ObjectKey:   key,
+		EventCount:  stats.events,
+		SizeBytes:   stats.size,
+		SHA256:      stats.sha256,
+		Encrypted:   m.config.Sealer != nil,
+		ArchivedAt:  m.now().UTC(),
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if m.config.HashChain {
+			if err := tx.Exec(p.anchorSQL(), p.from).Error; err != nil {
+				return fmt.Errorf("registrando anclas de cadena: %w", err)
+			}
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&manifest).Error; err != nil {
+			return fmt.Errorf("registrando manifiesto: %w", err)
+		}
+		return tx.Exec(p.dropSQL()).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("audit: archivando partición %s: %w", p.name, err)
+	}
+
+	period := internal.ToColdPeriod(manifest)
+	return &period, nil
+}
+
+// archiveStats describe un archivo ya subido.
+type archiveStats struct {
+	events int64
+	size   int64
+	sha256 string
+}
+
+// uploadArchive sube el archivo de la partición a Store a medida que se
+// genera, sin armarlo en memoria.
+func (m *RetentionManager) uploadArchive(ctx context.Context, key string, p partition) (archiveStats, error) {
+	type result struct {
+		stats archiveStats
+		err   error
+	}
+	pr, pw := io.Pipe()
+	done := make(chan result, 1)
+	go func() {
+		stats, err := m.writeArchive(ctx, p, pw)
+		_ = pw.CloseWithError(err) //nolint:errcheck // siempre retorna nil
+		done <- result{stats, err}
+	}()
+
+	uploadErr := m.config.Store.Upload(ctx, key, pr)
+	// Si Upload terminó sin leer todo, desbloquea al writer.
+	_ = pr.CloseWithError(io.ErrClosedPipe) //nolint:errcheck // siempre retorna nil
+	res := <-done
+
+	if res.err != nil && !errors.Is(res.err, io.ErrClosedPipe) {
+		return archiveStats{}, fmt.Errorf("audit: exportando partición %s: %w", p.name, res.err)
+	}
+	if uploadErr != nil {
+		return archiveStats{}, fmt.Errorf("audit: subiendo %s: %w", key, uploadErr)
+	}
+	if res.err != nil {
+		return archiveStats{}, fmt.Errorf("audit: subiendo %s: %w", key, res.err)
+	}
+	return res.stats, nil
+}
+
+// writeArchive escribe en w el mes de la partición como NDJSON comprimido y,
+// si hay Sealer, cifrado por bloques.
+func (m *RetentionManager) writeArchive(ctx context.Context, p partition, w io.Writer) (archiveStats, error) {
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(w, hash)}
+
+	var sealed *sealWriter
+	var out io.Writer = counter
+	if m.config.Sealer != nil {
+		sealed = &sealWriter{w: counter, sealer: m.config.Sealer}
+		out = sealed
+	}
+	zw := gzip.NewWriter(out)
+	enc := json.NewEncoder(zw)
+
+	var events int64
+	err := m.rows(ctx, p, func(row internal.AuditEventDB) error {
+		events++
+		return enc.Encode(internal.ToArchiveRecord(row))
+	})
+	if err != nil {
+		return archiveStats{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return archiveStats{}, err
+	}
+	if sealed != nil {
+		if err := sealed.Flush(); err != nil {
+			return archiveStats{}, err
+		}
+	}
+	return archiveStats{events: events, size: counter.n, sha256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// partitionRows recorre los eventos de una partición separada en el orden de
+// sus cadenas.
+func (m *RetentionManager) partitionRows(ctx context.Context, p partition, fn func(internal.AuditEventDB) error) error {
+	tx := m.db.WithContext(ctx).Table("audit." + p.name).Order("chain_key, chain_seq, created_at, id")
+	rows, err := tx.Rows()
+	if err != nil {
+		return fmt.Errorf("audit: abriendo stream de %s: %w", p.name, err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		var row internal.AuditEventDB
+		if err := tx.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("audit: leyendo evento: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (m *RetentionManager) decodeArchive(blob []byte, checksum string, encrypted bool) (io.ReadCloser, error) {
+	sum := sha256.Sum256(blob)
+	if hex.EncodeToString(sum[:]) != checksum {
+		return nil, ErrArchiveChecksum
+	}
+	if encrypted {
+		if m.config.Sealer == nil {
+			return nil, ErrArchiveSealerRequired
+		}
+		var err error
+		if blob, err = openFrames(m.config.Sealer, blob); err != nil {
+			return nil, fmt.Errorf("audit: descifrando archivo: %w", err)
+		}
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, fmt.Errorf("audit: descomprimiendo archivo: %w", err)
+	}
+	return zr, nil
+}
+
+func (m *RetentionManager) objectKey(p partition) string {
+	key := fmt.Sprintf("%s/%s/events-%s.ndjson.gz", m.config.KeyPrefix, p.from.Format("2006/01"), p.from.Format("2006-01"))
+	if m.config.Sealer != nil {
+		key += ".enc"
+	}
+	return key
+}
+
+// listPartitionsSQL lista las tablas events_p* del schema audit e indica si
+// siguen adjuntas a audit.events. Las separadas son archivos interrumpidos.
+const listPartitionsSQL = `SELECT c.relname AS name,
+	EXISTS (SELECT 1 FROM pg_inherits i WHERE i.inhrelid = c.oid) AS attached
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = 'audit' AND c.relkind IN ('r', 'p') AND c.relname LIKE 'events\_p%'`
+
+// partitionTable es una fila de listPartitionsSQL.
+type partitionTable struct {
+	Name     string
+	Attached bool
+}
+
+// partition es una partición mensual [from, to) de audit.events.
+type partition struct {
+	name     string
+	from     time.Time
+	to       time.Time
+	attached bool
+}
+
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func partitionFor(t time.Time) partition {
+	from := monthStart(t)
+	return partition{name: partitionPrefix + from.Format("200601"), from: from, to: from.AddDate(0, 1, 0)}
+}
+
+// parsePartition reconoce los nombres events_pYYYYMM; otras particiones
+// (p. ej. una DEFAULT) se ignoran.
+func parsePartition(name string) (partition, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok || len(suffix) != len("200601") {
+		return partition{}, false
+	}
+	month, err := time.ParseInLocation("200601", suffix, time.UTC)
+	if err != nil {
+		return partition{}, false
+	}
+	return partitionFor(month), true
+}
+
+// expiredPartitions retorna, ordenadas por mes, las particiones que terminan
+// antes o en cutoff.
+func expiredPartitions(tables []partitionTable, cutoff time.Time) []partition {
+	out := make([]partition, 0)
+	for _, t := range tables {
+		if p, ok := parsePartition(t.Name); ok && !p.to.After(cutoff) {
+			p.attached = t.Attached
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].from.Before(out[j].from) })
+	return out
+}
+
+// Los nombres de partición se derivan de fechas, por lo que es seguro
+// interpolarlos en DDL.
+func (p partition) createSQL() string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS audit.%s PARTITION OF audit.events FOR VALUES FROM ('%s') TO ('%s')",
+		p.name, p.from.Format(time.DateTime), p.to.Format(time.DateTime))
+}
+
+func (p partition) anchorSQL() string {
+	return fmt.Sprintf(`INSERT INTO audit.archive_chain_anchors (chain_key, chain_seq, hash, period_start)
+SELECT DISTINCT ON (chain_key) chain_key, chain_seq, hash, ?
+FROM audit.%s
+WHERE chain_key IS NOT NULL
+ORDER BY chain_key, chain_seq DESC
+ON CONFLICT DO NOTHING`, p.name)
+}
+
+func (p partition) detachSQL() string {
+	return fmt.Sprintf("ALTER TABLE audit.events DETACH PARTITION audit.%s", p.name)
+}
+
+func (p partition) dropSQL() string {
+	return fmt.Sprintf("DROP TABLE audit.%s", p.name)
+}
+
+// archiveFrameSize es el tamaño de cada bloque cifrado del archivo.
+const archiveFrameSize = 1 << 20
+
+// sealWriter cifra lo escrito en bloques de archiveFrameSize. Cada bloque se
+// escribe como su longitud (4 bytes big-endian) seguida del bloque cifrado.
+type sealWriter struct {
+	w      io.Writer
+	sealer ArchiveSealer
+	buf    []byte
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := min(len(p), archiveFrameSize-len(s.buf))
+		s.buf = append(s.buf, p[:k]...)
+		p = p[k:]
+		if len(s.buf) == archiveFrameSize {
+			if err := s.Flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush cifra y escribe el bloque pendiente.
+func (s *sealWriter) Flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	frame, err := s.sealer.Seal(s.buf)
+	if err != nil {
+		return fmt.Errorf("cifrando archivo: %w", err)
+	}
+	if uint64(len(frame)) > math.MaxUint32 {
+		return fmt.Errorf("cifrando archivo: bloque de %d bytes", len(frame))
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(frame)))
+	if _, err := s.w.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(frame); err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	return nil
+}
+
+// openFrames descifra los bloques escritos por sealWriter.
+func openFrames(sealer ArchiveSealer, blob []byte) ([]byte, error) {
+	var out []byte
+	for len(blob) > 0 {
+		if len(blob) < 4 {
+			return nil, errors.New("bloque truncado")
+		}
+		size := binary.BigEndian.Uint32(blob)
+		blob = blob[4:]
+		if uint64(size) > uint64(len(blob)) {
+			return nil, errors.New("bloque truncado")
+		}
+		plain, err := sealer.Open(blob[:size])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, plain...)
+		blob = blob[size:]
+	}
+	return out, nil
+}
+
+// countingWriter cuenta los bytes escritos.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
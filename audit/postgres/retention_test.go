@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit/postgres/internal"
+)
+
+type memoryStore struct {
+	objects map[string][]byte
+}
+
+func (s *memoryStore) Upload(_ context.Context, key string, content io.Reader) error {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	if s.objects == nil {
+		s.objects = make(map[string][]byte)
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStore) Download(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// xorSealer es un cifrado de juguete: basta para verificar que el archivo pasa por el Sealer.
+type xorSealer struct{}
+
+func (xorSealer) Seal(p []byte) ([]byte, error) { return xorBytes(p), nil }
+func (xorSealer) Open(b []byte) ([]byte, error) { return xorBytes(b), nil }
+
+func xorBytes(in []byte) []byte {
+	out := make([]byte, len(in))
+	for i, b := range in {
+		out[i] = b ^ 0x5a
+	}
+	return out
+}
+
+// staticRows reemplaza la lectura de la partición separada.
+func staticRows(rows []internal.AuditEventDB, visited *partition) func(context.Context, partition, func(internal.AuditEventDB) error) error {
+	return func(_ context.Context, p partition, fn func(internal.AuditEventDB) error) error {
+		*visited = p
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestNewRetentionManagerRequiresStore(t *testing.T) {
+	if _, err := NewRetentionManager(nil, RetentionConfig{}); !errors.Is(err, ErrArchiveStoreRequired) {
+		t.Fatalf("expected ErrArchiveStoreRequired, got %v", err)
+	}
+
+	m, err := NewRetentionManager(nil, RetentionConfig{Store: &memoryStore{}, KeyPrefix: "archive/"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.config.MaxAge != DefaultRetentionMaxAge || m.config.PremakeMonths != DefaultPremakeMonths {
+		t.Errorf("defaults not applied: %+v", m.config)
+	}
+	if m.config.KeyPrefix != "archive" {
+		t.Errorf("expected trailing slash trimmed, got %q", m.config.KeyPrefix)
+	}
+}
+
+func TestPartitionNaming(t *testing.T) {
+	p := partitionFor(time.Date(2026, 12, 17, 23, 0, 0, 0, time.UTC))
+	if p.name != "events_p202612" {
+		t.Errorf("unexpected name %q", p.name)
+	}
+	if !p.from.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) || !p.to.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected bounds %v - %v", p.from, p.to)
+	}
+
+	sql := p.createSQL()
+	want := "CREATE TABLE IF NOT EXISTS audit.events_p202612 PARTITION OF audit.events FOR VALUES FROM ('2026-12-01 00:00:00') TO ('2027-01-01 00:00:00')"
+	if sql != want {
+		t.Errorf("unexpected DDL:\n got %s\nwant %s", sql, want)
+	}
+
+	for _, name := range []string{"events_default", "events_p2026", "events_p202613", "other_p202601"} {
+		if _, ok := parsePartition(name); ok {
+			t.Errorf("%q should not parse as a monthly partition", name)
+		}
+	}
+}
+
+func TestExpiredPartitions(t *testing.T) {
+	cutoff := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	tables := []partitionTable{
+		{Name: "events_p202510", Attached: true},
+		{Name: "events_p202509", Attached: true},
+		{Name: "events_default", Attached: true},
+		{Name: "events_p202507"},
+		{Name: "events_p202511", Attached: true},
+	}
+
+	got := expiredPartitions(tables, cutoff)
+	if len(got) != 2 || got[0].name != "events_p202507" || got[1].name != "events_p202509" {
+		t.Fatalf("unexpected expired partitions: %+v", got)
+	}
+	if got[0].attached || !got[1].attached {
+		t.Errorf("a detached table should be resumed without DETACH: %+v", got)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	key, seq, hash := "svc|", int64(7), "abc"
+	rows := []internal.AuditEventDB{
+		{ID: "evt-1", Action: "create", ResourceType: "user", Changes: map[string]any{}, ChainKey: &key, ChainSeq: &seq, Hash: &hash},
+		{ID: "evt-2", Action: "delete", ResourceType: "user"},
+	}
+	for _, sealer := range []ArchiveSealer{nil, xorSealer{}} {
+		store := &memoryStore{}
+		m, err := NewRetentionManager(nil, RetentionConfig{Store: store, Sealer: sealer})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var visited partition
+		m.rows = staticRows(rows, &visited)
+		p := partitionFor(time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC))
+
+		stats, err := m.uploadArchive(context.Background(), m.objectKey(p), p)
+		if err != nil {
+			t.Fatalf("uploadArchive: %v", err)
+		}
+		if stats.events != 2 {
+			t.Errorf("expected 2 events, got %d", stats.events)
+		}
+		if visited.name != p.name {
+			t.Errorf("archive should read the partition table, got %q", visited.name)
+		}
+		blob := store.objects[m.objectKey(p)]
+		if stats.size != int64(len(blob)) || stats.sha256 != sha256Hex(blob) {
+			t.Errorf("stats %+v do not describe the uploaded blob", stats)
+		}
+		if sealer != nil && !bytes.HasPrefix(xorBytes(blob[4:]), []byte{0x1f, 0x8b}) {
+			t.Errorf("sealed archive should wrap a gzip stream")
+		}
+
+		rc, err := m.decodeArchive(blob, stats.sha256, sealer != nil)
+		if err != nil {
+			t.Fatalf("decodeArchive: %v", err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("read archive: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 NDJSON lines, got %d: %s", len(lines), data)
+		}
+		var first internal.ArchiveRecord
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first.ID != "evt-1" {
+			t.Errorf("unexpected first line %s (%v)", lines[0], err)
+		}
+		if first.ChainKey != key || first.ChainSeq != seq || first.Hash != hash {
+			t.Errorf("archived line should keep the chain columns: %s", lines[0])
+		}
+		if first.Changes == nil {
+			t.Errorf("empty changes should survive the archive, they are part of the hash")
+		}
+	}
+}
+
+func TestSealedArchiveFrames(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), archiveFrameSize/4)
+	var out bytes.Buffer
+	w := &sealWriter{w: &out, sealer: xorSealer{}}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	got, err := openFrames(xorSealer{}, out.Bytes())
+	if err != nil {
+		t.Fatalf("openFrames: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("frames do not round-trip")
+	}
+	if _, err := openFrames(xorSealer{}, out.Bytes()[:out.Len()-1]); err == nil {
+		t.Error("a truncated archive should fail")
+	}
+}
+
+func TestUploadArchiveErrors(t *testing.T) {
+	p := partitionFor(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	failing := &failingStore{}
+	m, _ := NewRetentionManager(nil, RetentionConfig{Store: failing})
+	var visited partition
+	m.rows = staticRows([]internal.AuditEventDB{{ID: "evt-1"}}, &visited)
+	if _, err := m.uploadArchive(context.Background(), "k", p); err == nil || !strings.Contains(err.Error(), "subiendo") {
+		t.Errorf("expected the upload error, got %v", err)
+	}
+
+	boom := errors.New("read failed")
+	m, _ = NewRetentionManager(nil, RetentionConfig{Store: &memoryStore{}})
+	m.rows = func(context.Context, partition, func(internal.AuditEventDB) error) error { return boom }
+	if _, err := m.uploadArchive(context.Background(), "k", p); !errors.Is(err, boom) {
+		t.Errorf("expected the export error, got %v", err)
+	}
+}
+
+// failingStore falla sin leer el contenido.
+type failingStore struct{ memoryStore }
+
+func (*failingStore) Upload(context.Context, string, io.Reader) error {
+	return errors.New("store down")
+}
+
+func TestDecodeArchiveErrors(t *testing.T) {
+	m, _ := NewRetentionManager(nil, RetentionConfig{Store: &memoryStore{}})
+	blob := []byte("sealed")
+
+	if _, err := m.decodeArchive(blob, "bad", false); !errors.Is(err, ErrArchiveChecksum) {
+		t.Errorf("expected ErrArchiveChecksum, got %v", err)
+	}
+	if _, err := m.decodeArchive(blob, sha256Hex(blob), true); !errors.Is(err, ErrArchiveSealerRequired) {
+		t.Errorf("expected ErrArchiveSealerRequired, got %v", err)
+	}
+}
+
+func TestArchiveObjectKey(t *testing.T) {
+	p := partitionFor(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	plain, _ := NewRetentionManager(nil, RetentionConfig{Store: &memoryStore{}})
+	if got := plain.objectKey(p); got != "audit/events/2025/03/events-2025-03.ndjson.gz" {
+		t.Errorf("unexpected key %q", got)
+	}
+	sealed, _ := NewRetentionManager(nil, RetentionConfig{Store: &memoryStore{}, Sealer: xorSealer{}})
+	if got := sealed.objectKey(p); !strings.HasSuffix(got, ".ndjson.gz.enc") {
+		t.Errorf("sealed archives should use the .enc suffix, got %q", got)
+	}
+}
+
+func TestAnchorSQLTargetsPartition(t *testing.T) {
+	sql := partitionFor(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)).anchorSQL()
+	for _, fragment := range []string{"audit.archive_chain_anchors", "FROM audit.events_p202503", "DISTINCT ON (chain_key)", "ON CONFLICT DO NOTHING"} {
+		if !strings.Contains(sql, fragment) {
+			t.Errorf("expected %q in SQL: %s", fragment, sql)
+		}
+	}
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestArchiveRecordKeepsHash(t *testing.T) {
+	school, status := "school-1", 201
+	row := internal.AuditEventDB{
+		ID: "evt-1", ActorID: "u-1", ActorEmail: "a@edugo.test", ActorRole: "admin",
+		ServiceName: "svc", Action: "create", ResourceType: "user", SchoolID: &school, StatusCode: &status,
+		Changes: map[string]any{}, Metadata: map[string]any{"n": 1.5},
+		CreatedAt: time.Date(2025, 3, 5, 10, 0, 0, 123456000, time.UTC),
+		Severity:  "info", Category: "data",
+	}
+	if err := internal.SealRecord(&row, internal.ChainKeyFor(row.ServiceName, row.SchoolID), 0, ""); err != nil {
+		t.Fatalf("SealRecord: %v", err)
+	}
+
+	line, err := json.Marshal(internal.ToArchiveRecord(row))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var rec internal.ArchiveRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	got, err := internal.ComputeHash(rec.ToDBModel())
+	if err != nil {
+		t.Fatalf("ComputeHash: %v", err)
+	}
+	if got != *row.Hash {
+		t.Errorf("archived record hashes to %s, want %s", got, *row.Hash)
+	}
+}
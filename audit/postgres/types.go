@@ -9,23 +9,70 @@ import (
 	"gorm.io/gorm"
 )
 
+// insertBatchSize limita las filas por INSERT para no exceder el máximo de
+// parámetros por sentencia de PostgreSQL (65535).
+const insertBatchSize = 500
+
 // PostgresAuditLogger implementa audit.AuditLogger usando PostgreSQL mediante GORM.
 // Persiste los eventos en la tabla audit.events.
 type PostgresAuditLogger struct { //nolint:revive
 	db          *gorm.DB
 	serviceName string
+	hashChain   bool
+}
+
+// Option configura un PostgresAuditLogger.
+type Option func(*PostgresAuditLogger)
+
+// WithHashChain habilita la cadena de hashes a prueba de manipulación: cada
+// evento persiste el SHA-256 de su forma canónica encadenado al hash del
+// evento anterior de su partición (ServiceName + SchoolID).
+// Requiere las columnas chain_key, chain_seq, prev_hash y hash (ver docs).
+func WithHashChain() Option {
+	return func(l *PostgresAuditLogger) {
+		l.hashChain = true
+	}
 }
 
 // NewPostgresAuditLogger crea un nuevo PostgresAuditLogger para el servicio indicado.
 // El parámetro serviceName identifica el servicio que registra el evento
 // (por ejemplo: "iam-platform", "admin-api", "mobile-api").
-func NewPostgresAuditLogger(db *gorm.DB, serviceName string) *PostgresAuditLogger {
-	return &PostgresAuditLogger{db: db, serviceName: serviceName}
+func NewPostgresAuditLogger(db *gorm.DB, serviceName string, opts ...Option) *PostgresAuditLogger {
+	l := &PostgresAuditLogger{db: db, serviceName: serviceName}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 // Log persiste un AuditEvent en la base de datos.
 // Aplica valores por defecto de Severity y Category si no están definidos.
 func (l *PostgresAuditLogger) Log(ctx context.Context, event audit.AuditEvent) error {
+	record := internal.ToDBModel(l.normalize(event))
+	if l.hashChain {
+		return l.insertChained(ctx, []internal.AuditEventDB{record})
+	}
+	return l.db.WithContext(ctx).Omit(internal.ChainColumns...).Create(&record).Error
+}
+
+// LogBatch persiste varios AuditEvent con inserciones agrupadas (CreateInBatches).
+// Aplica los mismos defaults que Log a cada evento.
+func (l *PostgresAuditLogger) LogBatch(ctx context.Context, events []audit.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	records := make([]internal.AuditEventDB, len(events))
+	for i, event := range events {
+		records[i] = internal.ToDBModel(l.normalize(event))
+	}
+	if l.hashChain {
+		return l.insertChained(ctx, records)
+	}
+	return l.db.WithContext(ctx).Omit(internal.ChainColumns...).CreateInBatches(&records, insertBatchSize).Error
+}
+
+// normalize aplica los defaults de severidad, categoría, servicio y actor.
+func (l *PostgresAuditLogger) normalize(event audit.AuditEvent) audit.AuditEvent {
 	if event.Severity == "" {
 		event.Severity = audit.SeverityInfo
 	}
@@ -43,9 +90,7 @@ func (l *PostgresAuditLogger) Log(ctx context.Context, event audit.AuditEvent) e
 	if event.ActorRole == "" {
 		event.ActorRole = internal.DefaultActorRole
 	}
-
-	record := internal.ToDBModel(event)
-	return l.db.WithContext(ctx).Create(&record).Error
+	return event
 }
 
 // LogFromGin es un método de conveniencia que extrae los datos del contexto Gin
@@ -0,0 +1,18 @@
+// Package rabbit proporciona una implementación de audit.AuditLogger que
+// publica los eventos en RabbitMQ, pensada para alimentar un SIEM.
+//
+// Publica a través de un Publisher, satisfecho por rabbit.Publisher de
+// edugo-shared/messaging/rabbit. Se combina con audit.FanOutAuditLogger para
+// enviar solo ciertos eventos al bus:
+//
+//	bus, _ := auditrabbit.NewRabbitAuditLogger(msgrabbit.NewPublisher(conn), auditrabbit.Config{
+//		Exchange: "audit.events",
+//	})
+//	logger := audit.NewFanOutAuditLogger(appLogger,
+//		audit.Sink{Name: "postgres", Logger: pgLogger, Required: true},
+//		audit.Sink{Name: "siem", Logger: bus, Filter: audit.AllOf(
+//			audit.MatchSeverity(audit.SeverityCritical),
+//			audit.MatchCategory(audit.CategoryAuth),
+//		)},
+//	)
+package rabbit
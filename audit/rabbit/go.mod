@@ -0,0 +1,7 @@
+module github.com/EduGoGroup/edugo-shared/audit/rabbit
+
+go 1.25.0
+
+require github.com/EduGoGroup/edugo-shared/audit v0.900.1
+
+replace github.com/EduGoGroup/edugo-shared/audit => ../
@@ -0,0 +1,164 @@
+package rabbit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+)
+
+// MessageSchemaVersion es la versión del esquema de Message.
+const MessageSchemaVersion = "1"
+
+// ErrExchangeRequired indica que Config.Exchange está vacío.
+var ErrExchangeRequired = errors.New("audit/rabbit: Config.Exchange es requerido")
+
+// Publisher es el subconjunto de rabbit.Publisher (edugo-shared/messaging/rabbit)
+// que usa RabbitAuditLogger. El body se serializa a JSON.
+type Publisher interface {
+	Publish(ctx context.Context, exchange, routingKey string, body any) error
+}
+
+// Config configura RabbitAuditLogger.
+type Config struct {
+	// Exchange destino de los eventos. Requerido.
+	Exchange string
+	// RoutingKey calcula la routing key de cada evento (default DefaultRoutingKey).
+	RoutingKey func(audit.AuditEvent) string
+	// ServiceName se usa cuando el evento no trae ServiceName.
+	ServiceName string
+}
+
+// DefaultRoutingKey retorna "audit.<category>.<severity>", con "data" e
+// "info" cuando el evento no los informa.
+func DefaultRoutingKey(e audit.AuditEvent) string {
+	category, severity := e.Category, e.Severity
+	if category == "" {
+		category = audit.CategoryData
+	}
+	if severity == "" {
+		severity = audit.SeverityInfo
+	}
+	return "audit." + category + "." + severity
+}
+
+// Message es el cuerpo JSON publicado por cada evento. Los nombres de campo
+// son estables; los cambios incompatibles suben MessageSchemaVersion.
+type Message struct {
+	SchemaVersion  string         `json:"schema_version"`
+	MessageID      string         `json:"message_id"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+	ServiceName    string         `json:"service_name"`
+	ActorID        string         `json:"actor_id"`
+	ActorEmail     string         `json:"actor_email"`
+	ActorRole      string         `json:"actor_role"`
+	ActorIP        string         `json:"actor_ip,omitempty"`
+	ActorUserAgent string         `json:"actor_user_agent,omitempty"`
+	SchoolID       string         `json:"school_id,omitempty"`
+	UnitID         string         `json:"unit_id,omitempty"`
+	Action         string         `json:"action"`
+	ResourceType   string         `json:"resource_type"`
+	ResourceID     string         `json:"resource_id,omitempty"`
+	PermissionUsed string         `json:"permission_used,omitempty"`
+	RequestMethod  string         `json:"request_method,omitempty"`
+	RequestPath    string         `json:"request_path,omitempty"`
+	RequestID      string         `json:"request_id,omitempty"`
+	StatusCode     int            `json:"status_code,omitempty"`
+	Severity       string         `json:"severity"`
+	Category       string         `json:"category"`
+	ErrorMessage   string         `json:"error_message,omitempty"`
+	Changes        map[string]any `json:"changes,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+}
+
+// RabbitAuditLogger implementa audit.AuditLogger publicando cada evento como Message.
+type RabbitAuditLogger struct { //nolint:revive
+	publisher Publisher
+	config    Config
+	now       func() time.Time // reemplazable en tests
+}
+
+// NewRabbitAuditLogger crea el logger. Retorna ErrExchangeRequired si
+// config.Exchange está vacío.
+func NewRabbitAuditLogger(publisher Publisher, config Config) (*RabbitAuditLogger, error) {
+	if config.Exchange == "" {
+		return nil, ErrExchangeRequired
+	}
+	if config.RoutingKey == nil {
+		config.RoutingKey = DefaultRoutingKey
+	}
+	return &RabbitAuditLogger{publisher: publisher, config: config, now: time.Now}, nil
+}
+
+// Log publica el evento en el exchange configurado.
+func (l *RabbitAuditLogger) Log(ctx context.Context, event audit.AuditEvent) error {
+	msg, err := l.toMessage(event)
+	if err != nil {
+		return err
+	}
+	if err := l.publisher.Publish(ctx, l.config.Exchange, l.config.RoutingKey(event), msg); err != nil {
+		return fmt.Errorf("audit/rabbit: publicando evento %s: %w", msg.MessageID, err)
+	}
+	return nil
+}
+
+func (l *RabbitAuditLogger) toMessage(e audit.AuditEvent) (Message, error) {
+	id, err := newMessageID()
+	if err != nil {
+		return Message{}, err
+	}
+	occurredAt := e.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = l.now()
+	}
+	service := e.ServiceName
+	if service == "" {
+		service = l.config.ServiceName
+	}
+	severity, category := e.Severity, e.Category
+	if severity == "" {
+		severity = audit.SeverityInfo
+	}
+	if category == "" {
+		category = audit.CategoryData
+	}
+	return Message{
+		SchemaVersion:  MessageSchemaVersion,
+		MessageID:      id,
+		OccurredAt:     occurredAt.UTC(),
+		ServiceName:    service,
+		ActorID:        e.ActorID,
+		ActorEmail:     e.ActorEmail,
+		ActorRole:      e.ActorRole,
+		ActorIP:        e.ActorIP,
+		ActorUserAgent: e.ActorUserAgent,
+		SchoolID:       e.SchoolID,
+		UnitID:         e.UnitID,
+		Action:         e.Action,
+		ResourceType:   e.ResourceType,
+		ResourceID:     e.ResourceID,
+		PermissionUsed: e.PermissionUsed,
+		RequestMethod:  e.RequestMethod,
+		RequestPath:    e.RequestPath,
+		RequestID:      e.RequestID,
+		StatusCode:     e.StatusCode,
+		Severity:       severity,
+		Category:       category,
+		ErrorMessage:   e.ErrorMessage,
+		Changes:        e.Changes,
+		Metadata:       e.Metadata,
+	}, nil
+}
+
+// newMessageID genera un identificador aleatorio de 128 bits para deduplicar en el consumidor.
+func newMessageID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("audit/rabbit: generando message_id: %w", err)
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,126 @@
+package rabbit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+)
+
+type published struct {
+	exchange   string
+	routingKey string
+	body       any
+}
+
+type fakePublisher struct {
+	calls []published
+	err   error
+}
+
+func (f *fakePublisher) Publish(_ context.Context, exchange, routingKey string, body any) error {
+	f.calls = append(f.calls, published{exchange: exchange, routingKey: routingKey, body: body})
+	return f.err
+}
+
+func TestNewRabbitAuditLoggerRequiresExchange(t *testing.T) {
+	if _, err := NewRabbitAuditLogger(&fakePublisher{}, Config{}); !errors.Is(err, ErrExchangeRequired) {
+		t.Fatalf("expected ErrExchangeRequired, got %v", err)
+	}
+}
+
+func TestLogPublishesMessage(t *testing.T) {
+	pub := &fakePublisher{}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	logger, err := NewRabbitAuditLogger(pub, Config{
+		Exchange:    "audit.events",
+		ServiceName: "iam",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.now = func() time.Time { return now }
+
+	err = logger.Log(context.Background(), audit.AuditEvent{
+		ActorID:      "user-1",
+		Action:       "user.login_failed",
+		ResourceType: "session",
+		Severity:     audit.SeverityCritical,
+		Category:     audit.CategoryAuth,
+		Metadata:     map[string]any{"attempts": 5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pub.calls) != 1 {
+		t.Fatalf("expected one publish, got %d", len(pub.calls))
+	}
+	call := pub.calls[0]
+	if call.exchange != "audit.events" || call.routingKey != "audit.auth.critical" {
+		t.Errorf("unexpected destination %s/%s", call.exchange, call.routingKey)
+	}
+
+	raw, err := json.Marshal(call.body)
+	if err != nil {
+		t.Fatalf("marshal body: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal body: %v", err)
+	}
+	if decoded["schema_version"] != MessageSchemaVersion || decoded["service_name"] != "iam" ||
+		decoded["occurred_at"] != "2026-05-01T12:00:00Z" || decoded["action"] != "user.login_failed" {
+		t.Errorf("unexpected message: %s", raw)
+	}
+	if id, _ := decoded["message_id"].(string); len(id) != 32 {
+		t.Errorf("expected 128-bit hex message_id, got %q", id)
+	}
+	if _, ok := decoded["actor_ip"]; ok {
+		t.Errorf("empty optional fields should be omitted: %s", raw)
+	}
+}
+
+func TestLogAppliesDefaults(t *testing.T) {
+	pub := &fakePublisher{}
+	logger, _ := NewRabbitAuditLogger(pub, Config{Exchange: "audit.events"})
+
+	occurred := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("COT", -5*3600))
+	if err := logger.Log(context.Background(), audit.AuditEvent{Action: "update", ServiceName: "academic", OccurredAt: occurred}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := pub.calls[0].body.(Message)
+	if pub.calls[0].routingKey != "audit.data.info" {
+		t.Errorf("unexpected routing key %q", pub.calls[0].routingKey)
+	}
+	if msg.Severity != audit.SeverityInfo || msg.Category != audit.CategoryData || msg.ServiceName != "academic" {
+		t.Errorf("defaults not applied: %+v", msg)
+	}
+	if !msg.OccurredAt.Equal(occurred) || msg.OccurredAt.Location() != time.UTC {
+		t.Errorf("expected OccurredAt preserved in UTC, got %v", msg.OccurredAt)
+	}
+}
+
+func TestLogCustomRoutingKeyAndError(t *testing.T) {
+	pub := &fakePublisher{err: errors.New("channel closed")}
+	logger, _ := NewRabbitAuditLogger(pub, Config{
+		Exchange:   "siem",
+		RoutingKey: func(e audit.AuditEvent) string { return "siem." + e.Action },
+	})
+
+	err := logger.Log(context.Background(), audit.AuditEvent{Action: "role.grant"})
+	if err == nil || !errors.Is(err, pub.err) {
+		t.Fatalf("expected wrapped publish error, got %v", err)
+	}
+	if pub.calls[0].routingKey != "siem.role.grant" {
+		t.Errorf("unexpected routing key %q", pub.calls[0].routingKey)
+	}
+}
+
+func TestRabbitAuditLoggerImplementsAuditLogger(t *testing.T) {
+	var _ audit.AuditLogger = &RabbitAuditLogger{}
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Límites de paginación de Reader.Query.
+const (
+	DefaultQueryLimit = 50
+	MaxQueryLimit     = 500
+)
+
+// ErrInvalidCursor indica que el cursor de paginación no pudo decodificarse.
+var ErrInvalidCursor = errors.New("audit: cursor inválido")
+
+// AuditQuery filtra eventos de auditoría. Los campos vacíos no filtran.
+// Los slices se combinan con OR dentro del campo y AND entre campos.
+type AuditQuery struct { //nolint:revive
+	ActorID      string
+	SchoolID     string
+	UnitID       string
+	ResourceType string
+	ResourceID   string
+	Actions      []string
+	Severities   []string
+	Categories   []string
+	From         time.Time // Inclusivo; cero = sin límite inferior
+	To           time.Time // Exclusivo; cero = sin límite superior
+
+	// Limit es el tamaño de página de Query (default DefaultQueryLimit, máximo
+	// MaxQueryLimit). En Stream, 0 significa sin límite.
+	Limit int
+	// Cursor es el NextCursor de la página anterior; vacío = primera página.
+	Cursor string
+}
+
+// AuditRecord es un evento persistido, con su identificador.
+// OccurredAt refleja la hora almacenada del evento.
+type AuditRecord struct { //nolint:revive
+	ID string
+	AuditEvent
+}
+
+// AuditPage es una página de resultados ordenada del más reciente al más antiguo.
+type AuditPage struct { //nolint:revive
+	Records    []AuditRecord
+	NextCursor string // Vacío cuando no hay más páginas
+}
+
+// ColdPeriod es un rango de tiempo cuyos eventos se archivaron fuera de la
+// base de datos en caliente. Query y Stream no retornan esos eventos.
+type ColdPeriod struct {
+	From       time.Time // Inclusivo
+	To         time.Time // Exclusivo
+	Location   string    // Ubicación del archivo (p. ej. clave en object storage)
+	Events     int64
+	ArchivedAt time.Time
+}
+
+// Reader es el contrato de lectura de eventos de auditoría.
+type Reader interface {
+	// Query retorna una página de eventos (keyset por OccurredAt DESC, ID DESC).
+	Query(ctx context.Context, q AuditQuery) (AuditPage, error)
+	// Stream recorre todos los eventos que cumplen el filtro en el mismo orden,
+	// sin cargarlos en memoria. Si fn retorna error, el recorrido se detiene.
+	Stream(ctx context.Context, q AuditQuery, fn func(AuditRecord) error) error
+	// ColdPeriods retorna los periodos archivados que se solapan con [from, to),
+	// ordenados por From. Un límite en cero no acota.
+	ColdPeriods(ctx context.Context, from, to time.Time) ([]ColdPeriod, error)
+}
+
+// NormalizeLimit aplica el default y el máximo de Query al límite solicitado.
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		return MaxQueryLimit
+	}
+	return limit
+}
+
+type cursorPayload struct {
+	OccurredAt time.Time `json:"t"`
+	ID         string    `json:"id"`
+}
+
+// EncodeCursor construye un cursor opaco a partir del último registro de una página.
+func EncodeCursor(occurredAt time.Time, id string) string {
+	raw, _ := json.Marshal(cursorPayload{OccurredAt: occurredAt.UTC(), ID: id}) //nolint:errcheck // struct serializable
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor extrae la posición codificada por EncodeCursor.
+func DecodeCursor(cursor string) (occurredAt time.Time, id string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	var p cursorPayload
+	if err := json.Unmarshal(raw, &p); err != nil || p.ID == "" || p.OccurredAt.IsZero() {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return p.OccurredAt, p.ID, nil
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReader struct {
+	records []AuditRecord
+}
+
+func (f *fakeReader) ColdPeriods(context.Context, time.Time, time.Time) ([]ColdPeriod, error) {
+	return nil, nil
+}
+
+func (f *fakeReader) Query(_ context.Context, q AuditQuery) (AuditPage, error) {
+	return AuditPage{Records: f.records}, nil
+}
+
+func (f *fakeReader) Stream(_ context.Context, _ AuditQuery, fn func(AuditRecord) error) error {
+	for _, r := range f.records {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sampleRecords() []AuditRecord {
+	at := time.Date(2026, 4, 1, 8, 30, 0, 0, time.UTC)
+	return []AuditRecord{
+		{ID: "evt-1", AuditEvent: AuditEvent{
+			ActorID: "actor-1", ActorEmail: "a@example.com", Action: "update", ResourceType: "user",
+			StatusCode: 200, Severity: SeverityInfo, Category: CategoryData, OccurredAt: at,
+			Changes: map[string]any{"name": "Ana"},
+		}},
+		{ID: "evt-2", AuditEvent: AuditEvent{
+			ActorID: "actor-2", ActorEmail: "=HYPERLINK(\"x\")", Action: "delete", ResourceType: "role",
+			Severity: SeverityCritical, Category: CategoryAdmin, OccurredAt: at.Add(-time.Minute),
+		}},
+	}
+}
+
+func TestCursor_RoundTrip(t *testing.T) {
+	at := time.Date(2026, 4, 1, 8, 30, 0, 123000, time.UTC)
+	cursor := EncodeCursor(at, "evt-1")
+
+	gotAt, gotID, err := DecodeCursor(cursor)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(gotAt))
+	assert.Equal(t, "evt-1", gotID)
+}
+
+func TestCursor_Invalid(t *testing.T) {
+	for _, cursor := range []string{"%%%", "bm90LWpzb24", EncodeCursor(time.Time{}, "")} {
+		_, _, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	assert.Equal(t, DefaultQueryLimit, NormalizeLimit(0))
+	assert.Equal(t, 10, NormalizeLimit(10))
+	assert.Equal(t, MaxQueryLimit, NormalizeLimit(MaxQueryLimit+1))
+}
+
+func TestExport_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Export(context.Background(), &fakeReader{records: sampleRecords()}, AuditQuery{}, ExportNDJSON, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "evt-1", first["id"])
+	assert.Equal(t, "2026-04-01T08:30:00Z", first["occurred_at"])
+	assert.Equal(t, map[string]any{"name": "Ana"}, first["changes"])
+}
+
+func TestExport_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Export(context.Background(), &fakeReader{records: sampleRecords()}, AuditQuery{}, ExportCSV, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, csvHeader, rows[0])
+	assert.Equal(t, "evt-1", rows[1][0])
+	assert.Equal(t, "200", rows[1][17])
+	assert.Equal(t, `{"name":"Ana"}`, rows[1][21])
+	assert.Equal(t, `'=HYPERLINK("x")`, rows[2][4], "las fórmulas deben neutralizarse")
+}
+
+func TestExport_UnsupportedFormat(t *testing.T) {
+	_, err := Export(context.Background(), &fakeReader{}, AuditQuery{}, ExportFormat("xml"), &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func TestExport_PropagatesWriterError(t *testing.T) {
+	_, err := Export(context.Background(), &fakeReader{records: sampleRecords()}, AuditQuery{}, ExportNDJSON, failingWriter{})
+	assert.Error(t, err)
+}
+
+func TestExportFormat_ContentType(t *testing.T) {
+	assert.Equal(t, "text/csv; charset=utf-8", ExportCSV.ContentType())
+	assert.Equal(t, "application/x-ndjson", ExportNDJSON.ContentType())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
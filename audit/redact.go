@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedactedValue reemplaza los valores de rutas sensibles en un diff.
+const RedactedValue = "[REDACTED]"
+
+// defaultSensitivePaths cubre credenciales en cualquier recurso y profundidad.
+var defaultSensitivePaths = []string{
+	"**.password",
+	"**.password_hash",
+	"**.*token",
+	"**.*token_hash",
+	"**.secret",
+	"**.*_secret",
+	"**.otp_secret",
+	"**.recovery_codes",
+}
+
+// RedactionPolicy define qué rutas de un diff se ocultan, globalmente y por
+// tipo de recurso. Los patrones usan segmentos separados por puntos: "*"
+// acepta un segmento (con glob de path.Match dentro del segmento) y "**"
+// acepta cero o más segmentos. Un patrón cubre también todo lo anidado bajo
+// la ruta: "credentials" oculta "credentials.token". Ejemplos:
+// "guardian.phone", "guardians.*.document_number", "**.email".
+//
+// Es segura para uso concurrente.
+type RedactionPolicy struct {
+	mu         sync.RWMutex
+	global     [][]string
+	byResource map[string][][]string
+}
+
+// NewRedactionPolicy crea una política con los patrones globales indicados
+// (sin los defaults). Ver DefaultRedactionPolicy.
+func NewRedactionPolicy(global ...string) *RedactionPolicy {
+	return &RedactionPolicy{
+		global:     splitPatterns(global),
+		byResource: make(map[string][][]string),
+	}
+}
+
+// DefaultRedactionPolicy retorna una política nueva que oculta contraseñas,
+// tokens y secretos en cualquier recurso. Se extiende con ForResource.
+func DefaultRedactionPolicy() *RedactionPolicy {
+	return NewRedactionPolicy(defaultSensitivePaths...)
+}
+
+// ForResource agrega patrones sensibles para un tipo de recurso
+// (el AuditEvent.ResourceType, p. ej. "guardian" o "user").
+func (p *RedactionPolicy) ForResource(resourceType string, patterns ...string) *RedactionPolicy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.byResource[resourceType] = append(p.byResource[resourceType], splitPatterns(patterns)...)
+	return p
+}
+
+// IsSensitive indica si la ruta, o uno de sus ancestros, debe ocultarse para
+// el tipo de recurso.
+func (p *RedactionPolicy) IsSensitive(resourceType, changePath string) bool {
+	if changePath == "" {
+		return false
+	}
+	segments := strings.Split(changePath, ".")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, pattern := range p.global {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	for _, pattern := range p.byResource[resourceType] {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact retorna una copia de changes con los valores sensibles reemplazados
+// por RedactedValue. Si un cambio agrega o quita un objeto completo, también
+// se ocultan las rutas sensibles anidadas dentro de él.
+func (p *RedactionPolicy) Redact(resourceType string, changes []Change) []Change {
+	out := make([]Change, len(changes))
+	for i, c := range changes {
+		if p.IsSensitive(resourceType, c.Path) {
+			c.Old = redactedIfSet(c.Old)
+			c.New = redactedIfSet(c.New)
+		} else {
+			c.Old = p.redactNested(resourceType, c.Path, c.Old)
+			c.New = p.redactNested(resourceType, c.Path, c.New)
+		}
+		out[i] = c
+	}
+	return out
+}
+
+func (p *RedactionPolicy) redactNested(resourceType, base string, v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(t))
+		for key, child := range t {
+			childPath := joinPath(base, key)
+			if p.IsSensitive(resourceType, childPath) {
+				copied[key] = redactedIfSet(child)
+				continue
+			}
+			copied[key] = p.redactNested(resourceType, childPath, child)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(t))
+		for i, child := range t {
+			childPath := joinPath(base, strconv.Itoa(i))
+			if p.IsSensitive(resourceType, childPath) {
+				copied[i] = redactedIfSet(child)
+				continue
+			}
+			copied[i] = p.redactNested(resourceType, childPath, child)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+func redactedIfSet(v any) any {
+	if v == nil {
+		return nil
+	}
+	return RedactedValue
+}
+
+func splitPatterns(patterns []string) [][]string {
+	out := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, strings.Split(p, "."))
+		}
+	}
+	return out
+}
+
+// matchSegments indica si un patrón segmentado cubre la ruta segmentada o
+// uno de sus ancestros.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -14,12 +14,73 @@ type TokenBlacklist interface {
 	IsRevoked(jti string) bool
 }
 
-// InMemoryBlacklist implements TokenBlacklist using sync.Map with TTL cleanup.
+// UserTokenRevoker is implemented by blacklists that support bulk revocation:
+// every token of a user issued before a cutoff is considered revoked (logout
+// from all devices, password change). The `iat` claim has second precision, so
+// tokens issued within the same second as the cutoff are not covered.
+type UserTokenRevoker interface {
+	// RevokeAllBefore revokes the user's tokens issued before `before`. The
+	// cutoff is kept until expiresAt, which should be `before` plus the
+	// longest token lifetime. A later cutoff replaces an earlier one.
+	RevokeAllBefore(userID string, before, expiresAt time.Time)
+	// IsRevokedForUser returns true if a token of the user issued at issuedAt
+	// falls under a bulk revocation.
+	IsRevokedForUser(userID string, issuedAt time.Time) bool
+}
+
+// ContextTokenRevoker is implemented by blacklists whose Revoke can fail,
+// such as the Redis one, so callers can stop when a revocation was not
+// stored.
+type ContextTokenRevoker interface {
+	// RevokeContext adds a token's JTI to the blacklist until expiresAt and
+	// returns the storage error, if any.
+	RevokeContext(ctx context.Context, jti string, expiresAt time.Time) error
+}
+
+// revokeToken revokes jti with RevokeContext when the blacklist supports it,
+// or with Revoke otherwise.
+func revokeToken(ctx context.Context, blacklist TokenBlacklist, jti string, expiresAt time.Time) error {
+	if revoker, ok := blacklist.(ContextTokenRevoker); ok {
+		return revoker.RevokeContext(ctx, jti, expiresAt)
+	}
+	blacklist.Revoke(jti, expiresAt)
+	return nil
+}
+
+// IsTokenRevoked checks the claims against the blacklist: the JTI and, if the
+// blacklist implements UserTokenRevoker, the user's bulk revocation cutoff.
+func IsTokenRevoked(blacklist TokenBlacklist, claims *Claims) bool {
+	if blacklist == nil || claims == nil {
+		return false
+	}
+	if blacklist.IsRevoked(claims.ID) {
+		return true
+	}
+	if revoker, ok := blacklist.(UserTokenRevoker); ok && claims.IssuedAt != nil {
+		return revoker.IsRevokedForUser(claims.UserID, claims.IssuedAt.Time)
+	}
+	return false
+}
+
+// issuedBefore compares at second precision, the resolution of `iat`.
+func issuedBefore(issuedAt, cutoff time.Time) bool {
+	return issuedAt.Unix() < cutoff.Unix()
+}
+
+// InMemoryBlacklist implements TokenBlacklist and UserTokenRevoker using
+// sync.Map with TTL cleanup.
 type InMemoryBlacklist struct {
 	store sync.Map // jti -> expiresAt (time.Time)
+	users sync.Map // userID -> userCutoff
+	mu    sync.Mutex
 	done  chan struct{}
 }
 
+type userCutoff struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
 // NewInMemoryBlacklist creates a blacklist with a background cleanup goroutine.
 // Cancel the context to stop the cleanup goroutine.
 func NewInMemoryBlacklist(ctx context.Context) *InMemoryBlacklist {
@@ -52,6 +113,41 @@ func (b *InMemoryBlacklist) IsRevoked(jti string) bool {
 	return true
 }
 
+// RevokeAllBefore revokes every token of the user issued before `before`.
+func (b *InMemoryBlacklist) RevokeAllBefore(userID string, before, expiresAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	next := userCutoff{before: before, expiresAt: expiresAt}
+	if val, ok := b.users.Load(userID); ok {
+		if cur, valid := val.(userCutoff); valid {
+			if cur.before.After(next.before) {
+				next.before = cur.before
+			}
+			if cur.expiresAt.After(next.expiresAt) {
+				next.expiresAt = cur.expiresAt
+			}
+		}
+	}
+	b.users.Store(userID, next)
+}
+
+// IsRevokedForUser returns true if the token falls under a bulk revocation.
+func (b *InMemoryBlacklist) IsRevokedForUser(userID string, issuedAt time.Time) bool {
+	val, ok := b.users.Load(userID)
+	if !ok {
+		return false
+	}
+	cutoff, valid := val.(userCutoff)
+	if !valid {
+		return false
+	}
+	if time.Now().After(cutoff.expiresAt) {
+		b.users.CompareAndDelete(userID, cutoff)
+		return false
+	}
+	return issuedBefore(issuedAt, cutoff.before)
+}
+
 func (b *InMemoryBlacklist) cleanupLoop(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
@@ -69,6 +165,12 @@ func (b *InMemoryBlacklist) cleanupLoop(ctx context.Context) {
 				}
 				return true
 			})
+			b.users.Range(func(key, value any) bool {
+				if cutoff, ok := value.(userCutoff); ok && now.After(cutoff.expiresAt) {
+					b.users.CompareAndDelete(key, cutoff)
+				}
+				return true
+			})
 		}
 	}
 }
@@ -82,3 +184,9 @@ func (n *NoOpBlacklist) Revoke(_ string, _ time.Time) {}
 
 // IsRevoked always returns false.
 func (n *NoOpBlacklist) IsRevoked(_ string) bool { return false }
+
+// RevokeAllBefore is a no-op.
+func (n *NoOpBlacklist) RevokeAllBefore(_ string, _, _ time.Time) {}
+
+// IsRevokedForUser always returns false.
+func (n *NoOpBlacklist) IsRevokedForUser(_ string, _ time.Time) bool { return false }
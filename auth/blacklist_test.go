@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,3 +47,46 @@ func TestInMemoryBlacklist_CancelStopsCleanup(t *testing.T) {
 	// Wait for cleanup goroutine to finish
 	<-bl.done
 }
+
+func TestInMemoryBlacklist_RevokeAllBefore(t *testing.T) {
+	bl := NewInMemoryBlacklist(t.Context())
+	now := time.Now()
+
+	bl.RevokeAllBefore("user-1", now, now.Add(time.Hour))
+
+	assert.True(t, bl.IsRevokedForUser("user-1", now.Add(-time.Minute)))
+	assert.False(t, bl.IsRevokedForUser("user-1", now.Add(time.Second)))
+	assert.False(t, bl.IsRevokedForUser("user-2", now.Add(-time.Minute)))
+
+	// An earlier cutoff does not move the current one back.
+	bl.RevokeAllBefore("user-1", now.Add(-time.Hour), now.Add(time.Hour))
+	assert.True(t, bl.IsRevokedForUser("user-1", now.Add(-time.Minute)))
+
+	// Once the cutoff expires it no longer applies.
+	bl.RevokeAllBefore("user-3", now, now.Add(-time.Second))
+	assert.False(t, bl.IsRevokedForUser("user-3", now.Add(-time.Minute)))
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	bl := NewInMemoryBlacklist(t.Context())
+	now := time.Now()
+	old := &Claims{UserID: "user-1", RegisteredClaims: jwt.RegisteredClaims{ID: "jti-old", IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute))}}
+	fresh := &Claims{UserID: "user-1", RegisteredClaims: jwt.RegisteredClaims{ID: "jti-new", IssuedAt: jwt.NewNumericDate(now.Add(time.Second))}}
+
+	assert.False(t, IsTokenRevoked(bl, old))
+	assert.False(t, IsTokenRevoked(nil, old))
+
+	bl.Revoke("jti-new", now.Add(time.Hour))
+	assert.True(t, IsTokenRevoked(bl, fresh))
+
+	bl.RevokeAllBefore("user-1", now, now.Add(time.Hour))
+	assert.True(t, IsTokenRevoked(bl, old))
+
+	// A blacklist without UserTokenRevoker only checks the JTI.
+	assert.False(t, IsTokenRevoked(jtiOnlyBlacklist{}, old))
+}
+
+type jtiOnlyBlacklist struct{}
+
+func (jtiOnlyBlacklist) Revoke(string, time.Time) {}
+func (jtiOnlyBlacklist) IsRevoked(string) bool    { return false }
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+)
+
+// GrantTypeClientCredentials es el grant_type OAuth2 (RFC 6749 §4.4) con el
+// que un cliente M2M obtiene un service JWT.
+const GrantTypeClientCredentials = "client_credentials"
+
+// DefaultServiceTokenTTL es la vigencia de los service JWT emitidos por
+// ClientCredentialsIssuer si no se configura otra.
+const DefaultServiceTokenTTL = 15 * time.Minute
+
+var (
+	// ErrServiceClientNotFound lo retorna un ServiceClientStore cuando el
+	// client_id no existe.
+	ErrServiceClientNotFound = stdErrors.New("auth: cliente de servicio no encontrado")
+	// ErrInvalidClient indica credenciales de cliente inválidas (client_id
+	// inexistente, deshabilitado o secret incorrecto); OAuth2 invalid_client.
+	ErrInvalidClient = stdErrors.New("auth: credenciales de cliente inválidas")
+	// ErrInvalidScope indica que ninguno de los scopes pedidos está permitido
+	// para el cliente; OAuth2 invalid_scope.
+	ErrInvalidScope = stdErrors.New("auth: scope inválido")
+)
+
+// ServiceClient es un cliente M2M registrado (auth.service_clients). El
+// secret se guarda solo como hash PHC de un PasswordHasher (Argon2id), nunca
+// en claro.
+type ServiceClient struct {
+	ClientID   string
+	SecretHash string
+	Scopes     []enum.Scope
+	Disabled   bool
+}
+
+// ServiceClientStore resuelve clientes M2M por client_id. Retorna
+// ErrServiceClientNotFound si no existe.
+type ServiceClientStore interface {
+	FindServiceClient(ctx context.Context, clientID string) (*ServiceClient, error)
+}
+
+// InMemoryServiceClientStore implementa ServiceClientStore en memoria, para
+// tests y servicios con clientes fijos por configuración. Es seguro para uso
+// concurrente.
+type InMemoryServiceClientStore struct {
+	mu      sync.RWMutex
+	clients map[string]ServiceClient
+}
+
+// NewInMemoryServiceClientStore crea un store con los clientes dados.
+func NewInMemoryServiceClientStore(clients ...ServiceClient) *InMemoryServiceClientStore {
+	s := &InMemoryServiceClientStore{clients: make(map[string]ServiceClient, len(clients))}
+	for _, c := range clients {
+		s.Put(c)
+	}
+	return s
+}
+
+// Put agrega o reemplaza un cliente.
+func (s *InMemoryServiceClientStore) Put(c ServiceClient) {
+	c.Scopes = slices.Clone(c.Scopes)
+	s.mu.Lock()
+	s.clients[c.ClientID] = c
+	s.mu.Unlock()
+}
+
+// FindServiceClient implementa ServiceClientStore.
+func (s *InMemoryServiceClientStore) FindServiceClient(_ context.Context, clientID string) (*ServiceClient, error) {
+	s.mu.RLock()
+	c, ok := s.clients[clientID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrServiceClientNotFound
+	}
+	c.Scopes = slices.Clone(c.Scopes)
+	return &c, nil
+}
+
+// ServiceToken es la respuesta de un token endpoint client_credentials.
+type ServiceToken struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	Scopes      []string
+}
+
+// ClientCredentialsConfig configura un ClientCredentialsIssuer.
+type ClientCredentialsConfig struct {
+	// TTL de los tokens emitidos (default DefaultServiceTokenTTL).
+	TTL time.Duration
+	// Hasher verifica los secrets (default DefaultPasswordHasher; también
+	// acepta hashes bcrypt).
+	Hasher PasswordHasher
+}
+
+// ClientCredentialsIssuer implementa el grant client_credentials: autentica
+// al cliente contra un ServiceClientStore, recorta los scopes pedidos a los
+// permitidos y emite el service JWT con un ServiceJWTManager.
+type ClientCredentialsIssuer struct {
+	store   ServiceClientStore
+	manager *ServiceJWTManager
+	hasher  PasswordHasher
+	ttl     time.Duration
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+// NewClientCredentialsIssuer crea un ClientCredentialsIssuer.
+func NewClientCredentialsIssuer(store ServiceClientStore, manager *ServiceJWTManager, cfg ClientCredentialsConfig) *ClientCredentialsIssuer {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultServiceTokenTTL
+	}
+	if cfg.Hasher == nil {
+		cfg.Hasher = DefaultPasswordHasher()
+	}
+	return &ClientCredentialsIssuer{store: store, manager: manager, hasher: cfg.Hasher, ttl: cfg.TTL}
+}
+
+// Issue autentica clientID/clientSecret y emite un token con los scopes
+// pedidos que el cliente tiene permitidos y existen en enum.AllScopes; sin
+// scopes pedidos concede todos los permitidos (RFC 6749 §3.3). Retorna
+// ErrInvalidClient si las credenciales no son válidas y ErrInvalidScope si
+// no queda ningún scope.
+func (i *ClientCredentialsIssuer) Issue(ctx context.Context, clientID, clientSecret string, requested []string) (*ServiceToken, error) {
+	client, err := i.store.FindServiceClient(ctx, clientID)
+	switch {
+	case stdErrors.Is(err, ErrServiceClientNotFound):
+		// Verifica contra un hash descartable para no revelar por tiempo de
+		// respuesta qué client_id existen.
+		_, _ = i.hasher.Verify(i.dummySecretHash(), clientSecret) //nolint:errcheck
+		return nil, ErrInvalidClient
+	case err != nil:
+		return nil, fmt.Errorf("auth: buscar cliente de servicio: %w", err)
+	}
+
+	if _, err := i.hasher.Verify(client.SecretHash, clientSecret); err != nil {
+		if stdErrors.Is(err, ErrPasswordMismatch) {
+			return nil, ErrInvalidClient
+		}
+		return nil, fmt.Errorf("auth: verificar secret de cliente: %w", err)
+	}
+	if client.Disabled {
+		return nil, ErrInvalidClient
+	}
+
+	scopes := grantedScopes(client.Scopes, requested)
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+
+	token, expiresAt, err := i.manager.GenerateServiceToken(client.ClientID, scopes, i.ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceToken{AccessToken: token, ExpiresAt: expiresAt, Scopes: scopes}, nil
+}
+
+func (i *ClientCredentialsIssuer) dummySecretHash() string {
+	i.dummyOnce.Do(func() {
+		i.dummyHash, _ = i.hasher.Hash("edugo-dummy-client-secret")
+	})
+	return i.dummyHash
+}
+
+// grantedScopes intersecta los scopes pedidos (o todos, si no se pidió
+// ninguno) con los permitidos y válidos, sin repetir y en el orden pedido.
+func grantedScopes(allowed []enum.Scope, requested []string) []string {
+	if len(requested) == 0 {
+		requested = make([]string, len(allowed))
+		for i, s := range allowed {
+			requested[i] = s.String()
+		}
+	}
+	granted := make([]string, 0, len(requested))
+	for _, r := range requested {
+		s := enum.Scope(r)
+		if s.IsValid() && slices.Contains(allowed, s) && !slices.Contains(granted, r) {
+			granted = append(granted, r)
+		}
+	}
+	return granted
+}
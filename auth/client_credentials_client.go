@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults de ClientCredentialsClientConfig.
+const (
+	DefaultServiceTokenRefreshBefore = time.Minute
+	DefaultServiceTokenFetchTimeout  = 5 * time.Second
+)
+
+// maxTokenResponseBytes acota la respuesta del token endpoint.
+const maxTokenResponseBytes = 64 << 10
+
+// ErrServiceTokenUnavailable indica que no se pudo obtener un service token
+// del token endpoint.
+var ErrServiceTokenUnavailable = stdErrors.New("auth: service token no disponible")
+
+// TokenResponse es el cuerpo de respuesta exitosa de un token endpoint
+// OAuth2 (RFC 6749 §5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// TokenErrorResponse es el cuerpo de error de un token endpoint OAuth2
+// (RFC 6749 §5.2).
+type TokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// NewTokenResponse arma la respuesta OAuth2 de un ServiceToken.
+func NewTokenResponse(t *ServiceToken, now time.Time) TokenResponse {
+	return TokenResponse{
+		AccessToken: t.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(t.ExpiresAt.Sub(now).Round(time.Second) / time.Second),
+		Scope:       strings.Join(t.Scopes, " "),
+	}
+}
+
+// ClientCredentialsClientConfig configura un ClientCredentialsClient.
+type ClientCredentialsClientConfig struct {
+	// TokenURL es la URL del token endpoint del emisor.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes a pedir; vacío pide todos los permitidos al cliente.
+	Scopes []string
+	// RefreshBefore es cuánto antes del vencimiento se pide un token nuevo
+	// (default DefaultServiceTokenRefreshBefore), para no usar uno que venza
+	// en vuelo. Se acota a la mitad de la vigencia del token, para que un
+	// expires_in corto no anule la caché.
+	RefreshBefore time.Duration
+	// HTTPClient para las llamadas. Si es nil se usa uno con FetchTimeout.
+	HTTPClient *http.Client
+	// FetchTimeout limita cada llamada (default DefaultServiceTokenFetchTimeout).
+	FetchTimeout time.Duration
+}
+
+// ClientCredentialsClient obtiene service JWTs con el grant
+// client_credentials y los cachea hasta RefreshBefore (como mucho la mitad
+// de su vigencia) antes de que venzan.
+// Las llamadas concurrentes con la caché vencida comparten una sola
+// petición al emisor. Es seguro para uso concurrente.
+type ClientCredentialsClient struct {
+	cfg    ClientCredentialsClientConfig
+	client *http.Client
+	now    func() time.Time // reemplazable en tests
+
+	fetchMu sync.Mutex // serializa las peticiones al emisor
+
+	mu        sync.RWMutex
+	token     string
+	refreshAt time.Time
+}
+
+// NewClientCredentialsClient crea un ClientCredentialsClient. No realiza
+// ninguna petición.
+func NewClientCredentialsClient(cfg ClientCredentialsClientConfig) *ClientCredentialsClient {
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = DefaultServiceTokenRefreshBefore
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = DefaultServiceTokenFetchTimeout
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cfg.FetchTimeout}
+	}
+	return &ClientCredentialsClient{cfg: cfg, client: client, now: time.Now}
+}
+
+// Token retorna el service JWT en caché o pide uno nuevo si no hay o está
+// por vencer.
+func (c *ClientCredentialsClient) Token(ctx context.Context) (string, error) {
+	if token, ok := c.cached(); ok {
+		return token, nil
+	}
+
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+	// Otra goroutine pudo renovarlo mientras esperábamos.
+	if token, ok := c.cached(); ok {
+		return token, nil
+	}
+	return c.fetch(ctx)
+}
+
+// Invalidate descarta el token en caché; llamarlo si el destino respondió
+// 401 con un token que el cliente creía vigente.
+func (c *ClientCredentialsClient) Invalidate() {
+	c.mu.Lock()
+	c.token = ""
+	c.refreshAt = time.Time{}
+	c.mu.Unlock()
+}
+
+func (c *ClientCredentialsClient) cached() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.token == "" || !c.now().Before(c.refreshAt) {
+		return "", false
+	}
+	return c.token, true
+}
+
+// fetch pide un token al emisor. Debe llamarse con fetchMu tomado.
+func (c *ClientCredentialsClient) fetch(ctx context.Context) (string, error) {
+	form := url.Values{"grant_type": {GrantTypeClientCredentials}}
+	if len(c.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.FetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrServiceTokenUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+
+	requestedAt := c.now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrServiceTokenUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrServiceTokenUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr TokenErrorResponse
+		if json.Unmarshal(body, &tokenErr) == nil && tokenErr.Error != "" {
+			return "", fmt.Errorf("%w: status %d: %s", ErrServiceTokenUnavailable, resp.StatusCode, tokenErr.Error)
+		}
+		return "", fmt.Errorf("%w: status %d", ErrServiceTokenUnavailable, resp.StatusCode)
+	}
+
+	var tr TokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return "", fmt.Errorf("%w: respuesta inválida: %v", ErrServiceTokenUnavailable, err)
+	}
+	if tr.AccessToken == "" || tr.ExpiresIn <= 0 {
+		return "", fmt.Errorf("%w: respuesta sin access_token o expires_in", ErrServiceTokenUnavailable)
+	}
+
+	// expires_in se cuenta desde que se envió la petición, del lado seguro.
+	lifetime := time.Duration(tr.ExpiresIn) * time.Second
+	c.mu.Lock()
+	c.token = tr.AccessToken
+	c.refreshAt = requestedAt.Add(lifetime - min(c.cfg.RefreshBefore, lifetime/2))
+	c.mu.Unlock()
+	return tr.AccessToken, nil
+}
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClientCredentialsIssuer(t *testing.T) *ClientCredentialsIssuer {
+	t.Helper()
+	hasher := newTestArgon2idHasher(t, testArgon2idParams)
+	hash, err := hasher.Hash("worker-secret")
+	require.NoError(t, err)
+
+	store := NewInMemoryServiceClientStore(
+		ServiceClient{ClientID: "edugo-worker", SecretHash: hash, Scopes: []enum.Scope{enum.ScopeNotificationsDispatch}},
+		ServiceClient{ClientID: "edugo-old", SecretHash: hash, Scopes: []enum.Scope{enum.ScopeNotificationsDispatch}, Disabled: true},
+	)
+	return NewClientCredentialsIssuer(store, newTestServiceManager(), ClientCredentialsConfig{
+		TTL:    10 * time.Minute,
+		Hasher: hasher,
+	})
+}
+
+func TestClientCredentialsIssuer_Issue(t *testing.T) {
+	issuer := newTestClientCredentialsIssuer(t)
+	ctx := context.Background()
+
+	t.Run("sin scopes pedidos concede todos los permitidos", func(t *testing.T) {
+		tok, err := issuer.Issue(ctx, "edugo-worker", "worker-secret", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"notifications.dispatch"}, tok.Scopes)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), tok.ExpiresAt, time.Minute)
+
+		claims, err := newTestServiceManager().ValidateServiceToken(tok.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "edugo-worker", claims.ClientID)
+		assert.Equal(t, []string{"notifications.dispatch"}, claims.Scopes)
+	})
+
+	t.Run("recorta los scopes no permitidos", func(t *testing.T) {
+		tok, err := issuer.Issue(ctx, "edugo-worker", "worker-secret",
+			[]string{"admin.everything", "notifications.dispatch", "notifications.dispatch"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"notifications.dispatch"}, tok.Scopes)
+	})
+
+	tests := []struct {
+		name      string
+		clientID  string
+		secret    string
+		requested []string
+		wantErr   error
+	}{
+		{"secret incorrecto", "edugo-worker", "otro", nil, ErrInvalidClient},
+		{"cliente inexistente", "edugo-ghost", "worker-secret", nil, ErrInvalidClient},
+		{"cliente deshabilitado", "edugo-old", "worker-secret", nil, ErrInvalidClient},
+		{"ningún scope permitido", "edugo-worker", "worker-secret", []string{"admin.everything"}, ErrInvalidScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := issuer.Issue(ctx, tt.clientID, tt.secret, tt.requested)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewTokenResponse(t *testing.T) {
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	resp := NewTokenResponse(&ServiceToken{
+		AccessToken: "tok",
+		ExpiresAt:   now.Add(15 * time.Minute),
+		Scopes:      []string{"a.b", "c.d"},
+	}, now)
+	assert.Equal(t, TokenResponse{AccessToken: "tok", TokenType: "Bearer", ExpiresIn: 900, Scope: "a.b c.d"}, resp)
+}
+
+func TestClientCredentialsClient_CachesUntilRefreshWindow(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		// RFC 6749 §2.3.1: las credenciales van form-urlencoded en Basic.
+		id, secret, ok := r.BasicAuth()
+		secret, _ = url.QueryUnescape(secret)
+		if !ok || id != "edugo-worker" || secret != "s3cr3t+/=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(TokenErrorResponse{Error: "invalid_client"})
+			return
+		}
+		assert.Equal(t, GrantTypeClientCredentials, r.PostFormValue("grant_type"))
+		assert.Equal(t, "notifications.dispatch", r.PostFormValue("scope"))
+		_ = json.NewEncoder(w).Encode(TokenResponse{
+			AccessToken: "tok-" + string(rune('0'+calls.Load())),
+			TokenType:   "Bearer",
+			ExpiresIn:   600,
+		})
+	}))
+	defer srv.Close()
+
+	clock := &fakeClock{now: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	client := NewClientCredentialsClient(ClientCredentialsClientConfig{
+		TokenURL:      srv.URL,
+		ClientID:      "edugo-worker",
+		ClientSecret:  "s3cr3t+/=",
+		Scopes:        []string{"notifications.dispatch"},
+		RefreshBefore: time.Minute,
+	})
+	client.now = clock.Now
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := client.Token(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, "tok-1", tok)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load(), "las llamadas concurrentes comparten una petición")
+
+	// Dentro de la vigencia menos RefreshBefore sigue usando la caché.
+	clock.Advance(8 * time.Minute)
+	tok, err := client.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tok-1", tok)
+
+	// Dentro de la ventana de refresh pide uno nuevo.
+	clock.Advance(time.Minute)
+	tok, err = client.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tok-2", tok)
+
+	client.Invalidate()
+	tok, err = client.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tok-3", tok)
+}
+
+func TestClientCredentialsClient_ShortLivedToken(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(TokenResponse{AccessToken: "tok", TokenType: "Bearer", ExpiresIn: 60})
+	}))
+	defer srv.Close()
+
+	clock := &fakeClock{now: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	client := NewClientCredentialsClient(ClientCredentialsClientConfig{TokenURL: srv.URL, ClientID: "x", ClientSecret: "y"})
+	client.now = clock.Now
+	ctx := context.Background()
+
+	// Con expires_in igual a RefreshBefore (1 min) el margen se acota a 30s.
+	for range 3 {
+		_, err := client.Token(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), calls.Load(), "un token corto también se cachea")
+
+	clock.Advance(29 * time.Second)
+	_, err := client.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	clock.Advance(time.Second)
+	_, err = client.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClientCredentialsClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(TokenErrorResponse{Error: "invalid_client"})
+	}))
+	defer srv.Close()
+
+	client := NewClientCredentialsClient(ClientCredentialsClientConfig{TokenURL: srv.URL, ClientID: "x", ClientSecret: "y"})
+	_, err := client.Token(context.Background())
+	assert.ErrorIs(t, err, ErrServiceTokenUnavailable)
+	assert.ErrorContains(t, err, "invalid_client")
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/common/errors"
+)
+
+// DefaultAccessTokenTTL es la vigencia por defecto de los access tokens
+// emitidos por ContextSwitcher.
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+var (
+	// ErrContextTargetInvalid indica un destino de cambio de contexto sin rol.
+	ErrContextTargetInvalid = stdErrors.New("auth: el contexto destino requiere role_id")
+	// ErrContextNotAllowed lo retorna un ContextResolver cuando el usuario no
+	// tiene la membresía del contexto destino (escuela, unidad y rol).
+	ErrContextNotAllowed = stdErrors.New("auth: el usuario no puede activar el contexto destino")
+)
+
+// ContextTarget es el contexto al que el usuario quiere cambiar. Con
+// SubjectStudentID el destino es modo representante (ActorModeWard) sobre
+// ese acudido.
+type ContextTarget struct {
+	SchoolID         string `json:"school_id,omitempty"`
+	AcademicUnitID   string `json:"academic_unit_id,omitempty"`
+	RoleID           string `json:"role_id"`
+	SubjectStudentID string `json:"subject_student_id,omitempty"`
+}
+
+// ContextMembership son los datos de display del contexto destino que el
+// ContextResolver obtiene al validar la membresía.
+type ContextMembership struct {
+	RoleName           string
+	SchoolName         string
+	AcademicUnitName   string
+	Landing            string
+	SubjectStudentName string
+}
+
+// ContextResolver es la fuente de membresías y grants de IAM que usa
+// ContextSwitcher. Lo implementa cada servicio de identidad sobre su BD.
+type ContextResolver interface {
+	// ResolveMembership verifica que el usuario tenga el rol target.RoleID en
+	// la escuela y unidad del destino. Retorna ErrContextNotAllowed si no.
+	ResolveMembership(ctx context.Context, userID string, target ContextTarget) (*ContextMembership, error)
+	// ParentRole retorna el padre de un rol, con ok=false si es canónico
+	// (misma semántica que el parentOf de ResolveRoleChain).
+	ParentRole(ctx context.Context, roleID string) (parent string, ok bool, err error)
+	// RoleGrants retorna los grants propios de un rol, sin los heredados.
+	RoleGrants(ctx context.Context, roleID string) (Grants, error)
+}
+
+// ContextSwitchConfig configura un ContextSwitcher.
+type ContextSwitchConfig struct {
+	// AccessTTL es la vigencia del access token nuevo (default DefaultAccessTokenTTL).
+	AccessTTL time.Duration
+	// RefreshTTL es la vigencia del refresh token nuevo (default DefaultRefreshTokenTTL).
+	RefreshTTL time.Duration
+	// Wards valida el vínculo en los destinos con SubjectStudentID. Sin él
+	// se rechaza el modo representante con ErrWardNotLinked.
+	Wards WardResolver
+	// Blacklist, si no es nil, rechaza access tokens revocados (IsTokenRevoked).
+	Blacklist TokenBlacklist
+}
+
+// ContextSwitchResult es el par de tokens emitido para el contexto nuevo.
+type ContextSwitchResult struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	ActiveContext    *UserContext
+}
+
+// ContextSwitcher canjea un access token válido por uno del contexto
+// destino (cambio de escuela, unidad académica o acudido): valida la
+// membresía, resuelve la cadena de roles con ResolveRoleChain, funde sus
+// grants con MergeGrantChain y emite el access token con
+// GenerateTokenWithContext y el refresh con GenerateMinimalToken (mismo
+// snapshot que usa el refresh use case para rotar).
+type ContextSwitcher struct {
+	manager  *JWTManager
+	resolver ContextResolver
+	cfg      ContextSwitchConfig
+}
+
+// NewContextSwitcher crea un ContextSwitcher.
+func NewContextSwitcher(manager *JWTManager, resolver ContextResolver, cfg ContextSwitchConfig) *ContextSwitcher {
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = DefaultAccessTokenTTL
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = DefaultRefreshTokenTTL
+	}
+	return &ContextSwitcher{manager: manager, resolver: resolver, cfg: cfg}
+}
+
+// Exchange valida accessToken y emite el par de tokens del contexto target.
+// Preserva amr, auth_time y mfa_at del token presentado: cambiar de
+// contexto no es un login nuevo.
+//
+// Retorna un error de no autorizado si el token es inválido o está
+// revocado, ErrContextTargetInvalid, ErrContextNotAllowed, ErrWardNotLinked,
+// o el error del resolver.
+func (s *ContextSwitcher) Exchange(ctx context.Context, accessToken string, target ContextTarget) (*ContextSwitchResult, error) {
+	claims, err := s.manager.ValidateToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.Blacklist != nil && IsTokenRevoked(s.cfg.Blacklist, claims) {
+		return nil, errors.NewUnauthorizedError("token revoked")
+	}
+	return s.ExchangeClaims(ctx, claims, target)
+}
+
+// ExchangeClaims es Exchange para claims ya validados (p. ej. por
+// JWTAuthMiddleware).
+func (s *ContextSwitcher) ExchangeClaims(ctx context.Context, claims *Claims, target ContextTarget) (*ContextSwitchResult, error) {
+	if target.RoleID == "" {
+		return nil, ErrContextTargetInvalid
+	}
+
+	membership, err := s.resolver.ResolveMembership(ctx, claims.UserID, target)
+	if err != nil {
+		return nil, err
+	}
+
+	actorMode := ""
+	if target.SubjectStudentID != "" {
+		if s.cfg.Wards == nil {
+			return nil, ErrWardNotLinked
+		}
+		ok, err := s.cfg.Wards.IsGuardianOf(ctx, claims.UserID, target.SubjectStudentID)
+		if err != nil {
+			return nil, fmt.Errorf("auth: validando vínculo de representante: %w", err)
+		}
+		if !ok {
+			return nil, ErrWardNotLinked
+		}
+		actorMode = ActorModeWard
+	}
+
+	grants, err := s.resolveGrants(ctx, target.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeContext := &UserContext{
+		RoleID:           target.RoleID,
+		RoleName:         membership.RoleName,
+		SchoolID:         target.SchoolID,
+		SchoolName:       membership.SchoolName,
+		AcademicUnitID:   target.AcademicUnitID,
+		AcademicUnitName: membership.AcademicUnitName,
+		Landing:          membership.Landing,
+		Grants:           grants,
+		SubjectStudentID: target.SubjectStudentID,
+		ActorMode:        actorMode,
+	}
+	if actorMode == ActorModeWard {
+		activeContext.SubjectStudentName = membership.SubjectStudentName
+	}
+
+	opts := claims.AuthOptions()
+	access, accessExp, err := s.manager.GenerateTokenWithContext(claims.UserID, claims.Email, activeContext, s.cfg.AccessTTL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	refresh, refreshExp, err := s.manager.GenerateMinimalToken(
+		claims.UserID, claims.Email,
+		target.SchoolID, target.AcademicUnitID, target.RoleID,
+		target.SubjectStudentID, actorMode,
+		s.cfg.RefreshTTL, opts...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContextSwitchResult{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExp,
+		ActiveContext:    activeContext,
+	}, nil
+}
+
+// resolveGrants funde los grants de la cadena del rol, ancestro primero.
+func (s *ContextSwitcher) resolveGrants(ctx context.Context, roleID string) (Grants, error) {
+	chain, err := ResolveRoleChain(roleID, func(id string) (string, bool, error) {
+		return s.resolver.ParentRole(ctx, id)
+	})
+	if err != nil {
+		return Grants{}, fmt.Errorf("auth: resolviendo cadena de roles: %w", err)
+	}
+	slices.Reverse(chain)
+
+	levels := make([]Grants, 0, len(chain))
+	for _, id := range chain {
+		g, err := s.resolver.RoleGrants(ctx, id)
+		if err != nil {
+			return Grants{}, fmt.Errorf("auth: resolviendo grants del rol %s: %w", id, err)
+		}
+		levels = append(levels, g)
+	}
+	return MergeGrantChain(levels), nil
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContextResolver tiene memberships "user|school|unit|role" y una
+// jerarquía role-teacher-lead → role-teacher.
+type fakeContextResolver struct {
+	memberships map[string]ContextMembership
+	parents     map[string]string
+	grants      map[string]Grants
+}
+
+func newFakeContextResolver() *fakeContextResolver {
+	return &fakeContextResolver{
+		memberships: map[string]ContextMembership{
+			"user-1|school-2|unit-9|role-teacher-lead": {RoleName: "Jefe de área", SchoolName: "Colegio 2", AcademicUnitName: "5A", Landing: "teacher-home"},
+			"user-1|school-1||role-guardian":           {RoleName: "Representante", SchoolName: "Colegio 1", SubjectStudentName: "Ana"},
+		},
+		parents: map[string]string{"role-teacher-lead": "role-teacher"},
+		grants: map[string]Grants{
+			"role-teacher":      {Allow: []string{"academic.*", "content.*.read"}},
+			"role-teacher-lead": {Allow: []string{"academic.*", "reports.*"}, Deny: []string{"academic.units.delete"}},
+			"role-guardian":     {Allow: []string{"assessments.read:own"}},
+		},
+	}
+}
+
+func (f *fakeContextResolver) ResolveMembership(_ context.Context, userID string, t ContextTarget) (*ContextMembership, error) {
+	m, ok := f.memberships[userID+"|"+t.SchoolID+"|"+t.AcademicUnitID+"|"+t.RoleID]
+	if !ok {
+		return nil, ErrContextNotAllowed
+	}
+	return &m, nil
+}
+
+func (f *fakeContextResolver) ParentRole(_ context.Context, roleID string) (string, bool, error) {
+	p, ok := f.parents[roleID]
+	return p, ok, nil
+}
+
+func (f *fakeContextResolver) RoleGrants(_ context.Context, roleID string) (Grants, error) {
+	return f.grants[roleID], nil
+}
+
+func newTestContextSwitcher(t *testing.T, cfg ContextSwitchConfig) (*ContextSwitcher, *JWTManager, string) {
+	t.Helper()
+	m := NewJWTManager(testSecretKey, testIssuer)
+	authTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	token, _, err := m.GenerateTokenWithContext("user-1", "user@edugo.com",
+		&UserContext{RoleID: "role-teacher", SchoolID: "school-1", Grants: Grants{Allow: []string{"academic.*"}}},
+		time.Hour, WithAMR(AMRPassword), WithAuthTime(authTime))
+	require.NoError(t, err)
+	return NewContextSwitcher(m, newFakeContextResolver(), cfg), m, token
+}
+
+func TestContextSwitcher_SwitchSchoolAndUnit(t *testing.T) {
+	s, m, token := newTestContextSwitcher(t, ContextSwitchConfig{})
+
+	res, err := s.Exchange(context.Background(), token, ContextTarget{
+		SchoolID: "school-2", AcademicUnitID: "unit-9", RoleID: "role-teacher-lead",
+	})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(DefaultAccessTokenTTL), res.AccessExpiresAt, time.Minute)
+
+	claims, err := m.ValidateToken(res.AccessToken)
+	require.NoError(t, err)
+	ac := claims.ActiveContext
+	assert.Equal(t, "school-2", ac.SchoolID)
+	assert.Equal(t, "5A", ac.AcademicUnitName)
+	assert.Equal(t, "teacher-home", ac.Landing)
+	assert.Empty(t, ac.ActorMode)
+	// Ancestro primero, deduplicado; el deny del hijo viaja aparte.
+	assert.Equal(t, []string{"academic.*", "content.*.read", "reports.*"}, ac.Grants.Allow)
+	assert.Equal(t, []string{"academic.units.delete"}, ac.Grants.Deny)
+	// Cambiar de contexto no es un login: se preservan amr y auth_time.
+	assert.Equal(t, []string{AMRPassword}, claims.AMR)
+	require.NotNil(t, claims.AuthTime)
+	assert.True(t, claims.AuthFresh(2*time.Hour, time.Now()))
+
+	refresh, err := m.ValidateMinimalToken(res.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "school-2", refresh.SchoolID)
+	assert.Equal(t, "unit-9", refresh.AcademicUnitID)
+	assert.Equal(t, "role-teacher-lead", refresh.RoleID)
+	assert.Empty(t, refresh.SubjectStudentID)
+	assert.Empty(t, refresh.ActorMode)
+	assert.Equal(t, []string{AMRPassword}, refresh.AMR)
+}
+
+func TestContextSwitcher_SwitchToWard(t *testing.T) {
+	wards := WardResolverFunc(func(_ context.Context, guardianID, studentID string) (bool, error) {
+		return guardianID == "user-1" && studentID == "student-1", nil
+	})
+	s, m, token := newTestContextSwitcher(t, ContextSwitchConfig{Wards: wards})
+	target := ContextTarget{SchoolID: "school-1", RoleID: "role-guardian", SubjectStudentID: "student-1"}
+
+	res, err := s.Exchange(context.Background(), token, target)
+	require.NoError(t, err)
+	assert.Equal(t, ActorModeWard, res.ActiveContext.ActorMode)
+	assert.Equal(t, "Ana", res.ActiveContext.SubjectStudentName)
+
+	refresh, err := m.ValidateMinimalToken(res.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "student-1", refresh.SubjectStudentID)
+	assert.Equal(t, ActorModeWard, refresh.ActorMode)
+
+	target.SubjectStudentID = "student-2"
+	_, err = s.Exchange(context.Background(), token, target)
+	assert.ErrorIs(t, err, ErrWardNotLinked)
+
+	// Sin WardResolver no se permite el modo representante.
+	noWards, _, _ := newTestContextSwitcher(t, ContextSwitchConfig{})
+	target.SubjectStudentID = "student-1"
+	_, err = noWards.Exchange(context.Background(), token, target)
+	assert.ErrorIs(t, err, ErrWardNotLinked)
+}
+
+func TestContextSwitcher_Rejections(t *testing.T) {
+	ctx := context.Background()
+	bl := NewInMemoryBlacklist(t.Context())
+	s, m, token := newTestContextSwitcher(t, ContextSwitchConfig{Blacklist: bl})
+
+	_, err := s.Exchange(ctx, token, ContextTarget{SchoolID: "school-2"})
+	assert.ErrorIs(t, err, ErrContextTargetInvalid)
+
+	_, err = s.Exchange(ctx, token, ContextTarget{SchoolID: "school-3", RoleID: "role-teacher"})
+	assert.ErrorIs(t, err, ErrContextNotAllowed)
+
+	_, err = s.Exchange(ctx, "no-es-un-jwt", ContextTarget{RoleID: "role-teacher"})
+	assert.Error(t, err)
+
+	claims, err := m.ValidateToken(token)
+	require.NoError(t, err)
+	bl.Revoke(claims.ID, time.Now().Add(time.Hour))
+	_, err = s.Exchange(ctx, token, ContextTarget{SchoolID: "school-2", AcademicUnitID: "unit-9", RoleID: "role-teacher-lead"})
+	assert.Error(t, err, "un token revocado no se canjea")
+}
@@ -3,7 +3,8 @@ module github.com/EduGoGroup/edugo-shared/auth
 go 1.25.0
 
 require (
-	github.com/EduGoGroup/edugo-shared/common v0.1.0
+	github.com/EduGoGroup/edugo-shared/audit v0.900.1
+	github.com/EduGoGroup/edugo-shared/common v0.900.5
 	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/google/uuid v1.6.0
 	github.com/stretchr/testify v1.11.1
@@ -14,5 +15,8 @@ require (
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	golang.org/x/sys v0.44.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/EduGoGroup/edugo-shared/audit => ../audit
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+)
+
+// GrantIssueKind identifica el tipo de hallazgo de AnalyzeGrants. Los
+// valores son estables: el editor de roles del admin los usa como códigos.
+type GrantIssueKind string
+
+const (
+	// GrantIssueInvalidPattern: el pattern no cumple enum.PathPermissionRegex.
+	// No se le aplica ningún otro análisis.
+	GrantIssueInvalidPattern GrantIssueKind = "invalid_pattern"
+	// GrantIssueMatchesNothing: el pattern no cubre ningún permiso de
+	// enum.AllPermissions.
+	GrantIssueMatchesNothing GrantIssueKind = "matches_nothing"
+	// GrantIssueRedundantAllow: otro allow ya cubre todo lo que cubre este.
+	GrantIssueRedundantAllow GrantIssueKind = "redundant_allow"
+	// GrantIssueShadowedAllow: un deny cubre todo lo que cubre este allow,
+	// así que nunca concede nada.
+	GrantIssueShadowedAllow GrantIssueKind = "shadowed_allow"
+	// GrantIssueRedundantDeny: otro deny ya cubre todo lo que cubre este.
+	GrantIssueRedundantDeny GrantIssueKind = "redundant_deny"
+	// GrantIssueDeadDeny: ningún allow cubre algo que este deny cubra; el
+	// default deny ya produce el mismo resultado.
+	GrantIssueDeadDeny GrantIssueKind = "dead_deny"
+)
+
+// GrantRef identifica una aparición de un pattern en los grants; RoleID
+// solo se informa con AnalyzeGrantChain.
+type GrantRef struct {
+	Pattern string      `json:"pattern"`
+	Effect  GrantEffect `json:"effect"`
+	RoleID  string      `json:"role_id,omitempty"`
+}
+
+// GrantIssue es un hallazgo sobre un pattern. CoveredBy lista los patterns
+// que lo vuelven redundante o lo anulan.
+type GrantIssue struct {
+	Kind GrantIssueKind `json:"kind"`
+	GrantRef
+	CoveredBy []GrantRef `json:"covered_by,omitempty"`
+}
+
+// GrantsReport es el resultado serializable de AnalyzeGrants, en el orden
+// en que aparecen los patterns (deny antes que allow por cada rol).
+type GrantsReport struct {
+	Issues []GrantIssue `json:"issues"`
+}
+
+// HasIssues reporta si el análisis encontró algún hallazgo.
+func (r GrantsReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// AnalyzeGrants revisa estáticamente g con la semántica de PermissionMatches:
+// patterns inválidos o que no cubren ningún permiso del catálogo, allows
+// redundantes o anulados por un deny, y denies redundantes o que no
+// restringen ningún allow.
+//
+// La cobertura se decide entre pares de patterns (un allow cubierto por la
+// unión de varios denies no se reporta), no contra el catálogo: un
+// `prefix.*` sigue siendo útil aunque hoy se listen todos sus permisos, porque
+// también cubre los que se agreguen después. Si un pattern aparece repetido,
+// se reporta la segunda aparición.
+func AnalyzeGrants(g Grants) GrantsReport {
+	return analyzeGrantEntries(grantEntries(nil, g, ""))
+}
+
+// AnalyzeGrantChain es AnalyzeGrants sobre la cadena de roles, ancestro
+// primero (el orden que espera MergeGrantChain): analiza la unión de todos
+// los niveles, de modo que un rol hijo que repite un pattern del padre o
+// que lo anula con un deny se reporta, con el rol de cada pattern.
+func AnalyzeGrantChain(chain []RoleGrants) GrantsReport {
+	var entries []grantEntry
+	for _, rg := range chain {
+		entries = grantEntries(entries, rg.Grants, rg.RoleID)
+	}
+	return analyzeGrantEntries(entries)
+}
+
+type grantEntry struct {
+	ref   GrantRef
+	shape grantShape
+	valid bool
+}
+
+func grantEntries(entries []grantEntry, g Grants, roleID string) []grantEntry {
+	add := func(patterns []string, effect GrantEffect) {
+		for _, p := range patterns {
+			entries = append(entries, grantEntry{
+				ref:   GrantRef{Pattern: p, Effect: effect, RoleID: roleID},
+				shape: shapeOf(p),
+				valid: enum.IsPathFormat(p),
+			})
+		}
+	}
+	add(g.Deny, GrantEffectDeny)
+	add(g.Allow, GrantEffectAllow)
+	return entries
+}
+
+func analyzeGrantEntries(entries []grantEntry) GrantsReport {
+	report := GrantsReport{Issues: []GrantIssue{}}
+	for i, e := range entries {
+		if !e.valid {
+			report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueInvalidPattern, GrantRef: e.ref})
+			continue
+		}
+		if !matchesCatalog(e.ref.Pattern) {
+			report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueMatchesNothing, GrantRef: e.ref})
+		}
+
+		if e.ref.Effect == GrantEffectAllow {
+			if denies := coveringEntries(entries, i, GrantEffectDeny); len(denies) > 0 {
+				report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueShadowedAllow, GrantRef: e.ref, CoveredBy: denies})
+				continue
+			}
+			if allows := coveringEntries(entries, i, GrantEffectAllow); len(allows) > 0 {
+				report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueRedundantAllow, GrantRef: e.ref, CoveredBy: allows})
+			}
+			continue
+		}
+
+		if denies := coveringEntries(entries, i, GrantEffectDeny); len(denies) > 0 {
+			report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueRedundantDeny, GrantRef: e.ref, CoveredBy: denies})
+			continue
+		}
+		dead := true
+		for _, o := range entries {
+			if o.valid && o.ref.Effect == GrantEffectAllow && o.shape.overlaps(e.shape) {
+				dead = false
+				break
+			}
+		}
+		if dead {
+			report.Issues = append(report.Issues, GrantIssue{Kind: GrantIssueDeadDeny, GrantRef: e.ref})
+		}
+	}
+	return report
+}
+
+// coveringEntries retorna las entradas válidas con el efecto dado que cubren
+// a entries[i]. Un pattern idéntico del mismo efecto solo cuenta si aparece
+// antes, para reportar una sola de las repeticiones.
+func coveringEntries(entries []grantEntry, i int, effect GrantEffect) []GrantRef {
+	var refs []GrantRef
+	target := entries[i]
+	for j, o := range entries {
+		if j == i || !o.valid || o.ref.Effect != effect {
+			continue
+		}
+		if o.ref.Pattern == target.ref.Pattern && effect == target.ref.Effect && j > i {
+			continue
+		}
+		if o.shape.covers(target.shape) {
+			refs = append(refs, o.ref)
+		}
+	}
+	return refs
+}
+
+func matchesCatalog(pattern string) bool {
+	for p := range enum.AllPermissions {
+		if PermissionMatches(pattern, p.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantShape es la forma de un pattern según las reglas de
+// PermissionMatches, en su mismo orden de precedencia.
+type grantShape struct {
+	kind grantShapeKind
+	head string // exact: el pattern; subtree: `prefix`; middle: `prefix.`
+	tail string // suffix y middle: `.suffix`
+}
+
+type grantShapeKind int
+
+const (
+	shapeExact grantShapeKind = iota
+	shapeAny
+	shapeSubtree
+	shapeSuffix
+	shapeMiddle
+)
+
+func shapeOf(p string) grantShape {
+	switch {
+	case p == "*":
+		return grantShape{kind: shapeAny}
+	case strings.HasSuffix(p, ".*"):
+		return grantShape{kind: shapeSubtree, head: p[:len(p)-2]}
+	case strings.HasPrefix(p, "*."):
+		return grantShape{kind: shapeSuffix, tail: p[1:]}
+	}
+	if i := strings.Index(p, ".*."); i > 0 {
+		head, tail := p[:i+1], p[i+2:]
+		if !strings.Contains(head, "*") && !strings.Contains(tail, "*") {
+			return grantShape{kind: shapeMiddle, head: head, tail: tail}
+		}
+	}
+	// Resto: PermissionMatches solo lo compara por igualdad.
+	return grantShape{kind: shapeExact, head: p}
+}
+
+// covers reporta si todo request que matchea o también matchea s. Asume
+// patterns que cumplen enum.PathPermissionRegex.
+func (s grantShape) covers(o grantShape) bool {
+	if s.kind == shapeAny {
+		return true
+	}
+	switch o.kind {
+	case shapeAny:
+		return false
+	case shapeExact:
+		return s.matches(o.head)
+	case shapeSubtree:
+		return s.kind == shapeSubtree && s.matches(o.head)
+	case shapeSuffix:
+		return s.kind == shapeSuffix && strings.HasSuffix(o.tail, s.tail)
+	case shapeMiddle:
+		switch s.kind {
+		case shapeSubtree:
+			return strings.HasPrefix(o.head, s.head+".")
+		case shapeSuffix:
+			return strings.HasSuffix(o.tail, s.tail)
+		case shapeMiddle:
+			return strings.HasPrefix(o.head, s.head) && strings.HasSuffix(o.tail, s.tail)
+		}
+	}
+	return false
+}
+
+// overlaps reporta si existe algún request que matchee ambos patterns.
+// Asume patterns que cumplen enum.PathPermissionRegex.
+func (s grantShape) overlaps(o grantShape) bool {
+	if s.kind == shapeAny || o.kind == shapeAny {
+		return true
+	}
+	if s.kind == shapeExact {
+		return o.matches(s.head)
+	}
+	if o.kind == shapeExact {
+		return s.matches(o.head)
+	}
+	if s.kind > o.kind {
+		s, o = o, s
+	}
+	// Acá s.kind <= o.kind, ambos entre shapeSubtree y shapeMiddle.
+	switch s.kind {
+	case shapeSubtree:
+		switch o.kind {
+		case shapeSubtree:
+			return prefixCompatible(s.head+".", o.head+".")
+		case shapeSuffix:
+			return true // `prefix.suffix`
+		case shapeMiddle:
+			return prefixCompatible(s.head+".", o.head)
+		}
+	case shapeSuffix:
+		switch o.kind {
+		case shapeSuffix, shapeMiddle:
+			return suffixCompatible(s.tail, o.tail)
+		}
+	case shapeMiddle:
+		return prefixCompatible(s.head, o.head) && suffixCompatible(s.tail, o.tail)
+	}
+	return false
+}
+
+func (s grantShape) matches(request string) bool {
+	switch s.kind {
+	case shapeAny:
+		return true
+	case shapeSubtree:
+		return PermissionMatches(s.head+".*", request)
+	case shapeSuffix:
+		return PermissionMatches("*"+s.tail, request)
+	case shapeMiddle:
+		return PermissionMatches(s.head+"*"+s.tail, request)
+	}
+	return s.head == request
+}
+
+func prefixCompatible(a, b string) bool {
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+func suffixCompatible(a, b string) bool {
+	return strings.HasSuffix(a, b) || strings.HasSuffix(b, a)
+}
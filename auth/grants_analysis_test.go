@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func issueKinds(r GrantsReport) map[string]GrantIssueKind {
+	out := make(map[string]GrantIssueKind, len(r.Issues))
+	for _, is := range r.Issues {
+		out[string(is.Effect)+":"+is.Pattern] = is.Kind
+	}
+	return out
+}
+
+func TestAnalyzeGrants(t *testing.T) {
+	g := Grants{
+		Allow: []string{
+			"admin.users.*",
+			"admin.users.read", // cubierto por admin.users.*
+			"content.materials.read",
+			"reports.read", // anulado por reports.*
+			"academic.*.read",
+			"academic.units.read", // cubierto por academic.*.read
+			"Admin.Users",         // fuera de la gramática
+			"nothing.here.at_all", // no matchea el catálogo
+			"admin.users.*",       // repetido
+		},
+		Deny: []string{
+			"reports.*",
+			"reports.read", // cubierto por reports.*
+			"admin.users.delete",
+			"screens.*", // ningún allow lo toca
+		},
+	}
+
+	got := issueKinds(AnalyzeGrants(g))
+	want := map[string]GrantIssueKind{
+		"allow:admin.users.read":    GrantIssueRedundantAllow,
+		"allow:reports.read":        GrantIssueShadowedAllow,
+		"allow:academic.units.read": GrantIssueRedundantAllow,
+		"allow:Admin.Users":         GrantIssueInvalidPattern,
+		"allow:admin.users.*":       GrantIssueRedundantAllow,
+		"deny:reports.read":         GrantIssueRedundantDeny,
+		"deny:screens.*":            GrantIssueDeadDeny,
+	}
+	// nothing.here.at_all (matches_nothing) se verifica aparte.
+	delete(got, "allow:nothing.here.at_all")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("issues:\n got=%v\nwant=%v", got, want)
+	}
+
+	report := AnalyzeGrants(Grants{Allow: []string{"nothing.here.at_all"}})
+	if len(report.Issues) != 1 || report.Issues[0].Kind != GrantIssueMatchesNothing {
+		t.Fatalf("issues = %+v, want solo matches_nothing", report.Issues)
+	}
+}
+
+func TestAnalyzeGrants_RepeatedPatternReportedOnce(t *testing.T) {
+	report := AnalyzeGrants(Grants{Allow: []string{"admin.users.read", "admin.users.read"}})
+	if len(report.Issues) != 1 {
+		t.Fatalf("issues = %+v, want una sola repetición reportada", report.Issues)
+	}
+}
+
+func TestAnalyzeGrants_Clean(t *testing.T) {
+	g := Grants{
+		Allow: []string{"academic.*", "*.read", "admin.users.update:own"},
+		Deny:  []string{"academic.units.delete"},
+	}
+	if report := AnalyzeGrants(g); report.HasIssues() {
+		t.Fatalf("issues = %+v, want ninguno", report.Issues)
+	}
+}
+
+func TestAnalyzeGrantChain(t *testing.T) {
+	chain := []RoleGrants{
+		{RoleID: "teacher", Grants: Grants{Allow: []string{"content.materials.*", "reports.read"}}},
+		{RoleID: "teacher-substitute", Grants: Grants{
+			Allow: []string{"content.materials.read"},
+			Deny:  []string{"reports.read"},
+		}},
+	}
+
+	report := AnalyzeGrantChain(chain)
+	want := []GrantIssue{
+		{
+			Kind:      GrantIssueShadowedAllow,
+			GrantRef:  GrantRef{Pattern: "reports.read", Effect: GrantEffectAllow, RoleID: "teacher"},
+			CoveredBy: []GrantRef{{Pattern: "reports.read", Effect: GrantEffectDeny, RoleID: "teacher-substitute"}},
+		},
+		{
+			Kind:      GrantIssueRedundantAllow,
+			GrantRef:  GrantRef{Pattern: "content.materials.read", Effect: GrantEffectAllow, RoleID: "teacher-substitute"},
+			CoveredBy: []GrantRef{{Pattern: "content.materials.*", Effect: GrantEffectAllow, RoleID: "teacher"}},
+		},
+	}
+	if !reflect.DeepEqual(report.Issues, want) {
+		t.Fatalf("issues:\n got=%+v\nwant=%+v", report.Issues, want)
+	}
+
+	raw, err := json.Marshal(report.Issues[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantJSON = `{"kind":"shadowed_allow","pattern":"reports.read","effect":"allow","role_id":"teacher",` +
+		`"covered_by":[{"pattern":"reports.read","effect":"deny","role_id":"teacher-substitute"}]}`
+	if string(raw) != wantJSON {
+		t.Errorf("json = %s, want %s", raw, wantJSON)
+	}
+}
+
+// TestGrantShape_Differential verifica covers y overlaps contra
+// PermissionMatches sobre patterns y requests generados en un alfabeto chico.
+func TestGrantShape_Differential(t *testing.T) {
+	rng := rand.New(rand.NewPCG(20260504, 17))
+	segments := []string{"a", "b", "ab", "x"}
+	randomPath := func(n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = segments[rng.IntN(len(segments))]
+		}
+		return strings.Join(parts, ".")
+	}
+	randomPattern := func() string {
+		var p string
+		switch rng.IntN(5) {
+		case 0:
+			p = "*"
+		case 1:
+			p = randomPath(1+rng.IntN(3)) + ".*"
+		case 2:
+			p = "*." + randomPath(1)
+		case 3:
+			p = randomPath(1) + ".*." + randomPath(1)
+		default:
+			p = randomPath(1 + rng.IntN(3))
+		}
+		if rng.IntN(4) == 0 {
+			p += ":own"
+		}
+		return p
+	}
+
+	requests := make([]string, 0, 4000)
+	for range cap(requests) {
+		r := randomPath(1 + rng.IntN(5))
+		if rng.IntN(4) == 0 {
+			r += ":own"
+		}
+		requests = append(requests, r)
+	}
+
+	for range 3000 {
+		a, b := randomPattern(), randomPattern()
+		sa, sb := shapeOf(a), shapeOf(b)
+		covers, overlaps := sa.covers(sb), sa.overlaps(sb)
+		sawOverlap := false
+		for _, r := range requests {
+			ma, mb := PermissionMatches(a, r), PermissionMatches(b, r)
+			if covers && mb && !ma {
+				t.Fatalf("covers(%q, %q) pero %q matchea solo el segundo", a, b, r)
+			}
+			if ma && mb {
+				sawOverlap = true
+			}
+		}
+		if sawOverlap && !overlaps {
+			t.Fatalf("overlaps(%q, %q) = false pero hay requests comunes", a, b)
+		}
+		if sa.overlaps(sb) != sb.overlaps(sa) {
+			t.Fatalf("overlaps(%q, %q) no es simétrico", a, b)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package auth
+
+import "strings"
+
+// GrantEffect indica si un pattern viene de la lista allow o deny.
+type GrantEffect string
+
+const (
+	GrantEffectAllow GrantEffect = "allow"
+	GrantEffectDeny  GrantEffect = "deny"
+)
+
+// Razones de la decisión en GrantsExplanation.Reason.
+const (
+	GrantsReasonDenied      = "denied"       // algún deny matcheó (deny precedence)
+	GrantsReasonAllowed     = "allowed"      // ningún deny y algún allow matcheó
+	GrantsReasonDefaultDeny = "default_deny" // nada matcheó
+)
+
+// GrantMatch es un pattern que cubre el request. Roles lista, en el orden de
+// la cadena recibida, los roles que aportan el pattern; vacío con
+// ExplainGrants, que no conoce la procedencia.
+type GrantMatch struct {
+	Pattern string      `json:"pattern"`
+	Effect  GrantEffect `json:"effect"`
+	Roles   []string    `json:"roles,omitempty"`
+}
+
+// GrantsExplanation responde "por qué" EvaluateGrants decidió lo que
+// decidió: todos los patterns que matchearon (deny primero, luego allow, en
+// el orden de los grants) y el que ganó. Winner es nil con default deny.
+type GrantsExplanation struct {
+	Request string       `json:"request"`
+	Allowed bool         `json:"allowed"`
+	Reason  string       `json:"reason"`
+	Winner  *GrantMatch  `json:"winner,omitempty"`
+	Matches []GrantMatch `json:"matches"`
+}
+
+// String resume la explicación en una línea apta para logs y headers, p. ej.
+// `denied by deny:admin.users.delete; matched allow:admin.users.*`.
+func (e GrantsExplanation) String() string {
+	var b strings.Builder
+	b.WriteString(e.Reason)
+	if e.Winner != nil {
+		b.WriteString(" by ")
+		writeGrantMatch(&b, *e.Winner)
+	}
+	first := true
+	for _, m := range e.Matches {
+		if e.Winner != nil && m.Pattern == e.Winner.Pattern && m.Effect == e.Winner.Effect {
+			continue
+		}
+		if first {
+			b.WriteString("; matched ")
+			first = false
+		} else {
+			b.WriteString(", ")
+		}
+		writeGrantMatch(&b, m)
+	}
+	return b.String()
+}
+
+func writeGrantMatch(b *strings.Builder, m GrantMatch) {
+	b.WriteString(string(m.Effect))
+	b.WriteByte(':')
+	b.WriteString(m.Pattern)
+	if len(m.Roles) > 0 {
+		b.WriteString(" (")
+		b.WriteString(strings.Join(m.Roles, ","))
+		b.WriteByte(')')
+	}
+}
+
+// ExplainGrants evalúa request contra g igual que EvaluateGrants y además
+// reporta qué patterns matchearon y cuál decidió: el primer deny que
+// matchea o, si no hay ninguno, el primer allow. Pensado para soporte
+// ("¿por qué este docente no ve X?"), no para el camino caliente.
+func ExplainGrants(g Grants, request string) GrantsExplanation {
+	return explainGrants(request, g, nil)
+}
+
+// RoleGrants son los grants propios de un rol de la cadena de herencia.
+type RoleGrants struct {
+	RoleID string
+	Grants Grants
+}
+
+// ExplainGrantChain es ExplainGrants sobre la cadena de roles que
+// MergeGrantChain aplanaría (p. ej. la de ResolveRoleChain con los grants
+// de cada rol): la decisión es la de EvaluateGrants(MergeGrantChain(...)) y
+// cada match indica de qué roles proviene el pattern.
+func ExplainGrantChain(chain []RoleGrants, request string) GrantsExplanation {
+	grants := make([]Grants, len(chain))
+	origin := make(map[GrantEffect]map[string][]string, 2)
+	origin[GrantEffectAllow] = make(map[string][]string)
+	origin[GrantEffectDeny] = make(map[string][]string)
+	for i, rg := range chain {
+		grants[i] = rg.Grants
+		addGrantOrigin(origin[GrantEffectAllow], rg.Grants.Allow, rg.RoleID)
+		addGrantOrigin(origin[GrantEffectDeny], rg.Grants.Deny, rg.RoleID)
+	}
+	return explainGrants(request, MergeGrantChain(grants), origin)
+}
+
+// addGrantOrigin registra roleID como fuente de cada pattern, sin repetirlo
+// si el rol declara el mismo pattern dos veces.
+func addGrantOrigin(origin map[string][]string, patterns []string, roleID string) {
+	for _, p := range patterns {
+		roles := origin[p]
+		if len(roles) > 0 && roles[len(roles)-1] == roleID {
+			continue
+		}
+		origin[p] = append(roles, roleID)
+	}
+}
+
+func explainGrants(request string, g Grants, origin map[GrantEffect]map[string][]string) GrantsExplanation {
+	e := GrantsExplanation{Request: request, Reason: GrantsReasonDefaultDeny, Matches: []GrantMatch{}}
+	collect := func(patterns []string, effect GrantEffect) int {
+		first := -1
+		for _, p := range patterns {
+			if !PermissionMatches(p, request) {
+				continue
+			}
+			if first < 0 {
+				first = len(e.Matches)
+			}
+			e.Matches = append(e.Matches, GrantMatch{Pattern: p, Effect: effect, Roles: origin[effect][p]})
+		}
+		return first
+	}
+
+	deny := collect(g.Deny, GrantEffectDeny)
+	allow := collect(g.Allow, GrantEffectAllow)
+	switch {
+	case deny >= 0:
+		e.Reason = GrantsReasonDenied
+		e.Winner = &e.Matches[deny]
+	case allow >= 0:
+		e.Allowed = true
+		e.Reason = GrantsReasonAllowed
+		e.Winner = &e.Matches[allow]
+	}
+	return e
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplainGrants(t *testing.T) {
+	g := Grants{
+		Allow: []string{"admin.users.*", "*.read", "content.materials.update:own"},
+		Deny:  []string{"*.delete", "admin.users.delete"},
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		allowed bool
+		reason  string
+		winner  *GrantMatch
+		matches []GrantMatch
+	}{
+		{
+			name:    "allow por subárbol y sufijo",
+			request: "admin.users.read",
+			allowed: true,
+			reason:  GrantsReasonAllowed,
+			winner:  &GrantMatch{Pattern: "admin.users.*", Effect: GrantEffectAllow},
+			matches: []GrantMatch{
+				{Pattern: "admin.users.*", Effect: GrantEffectAllow},
+				{Pattern: "*.read", Effect: GrantEffectAllow},
+			},
+		},
+		{
+			name:    "gana el primer deny",
+			request: "admin.users.delete",
+			reason:  GrantsReasonDenied,
+			winner:  &GrantMatch{Pattern: "*.delete", Effect: GrantEffectDeny},
+			matches: []GrantMatch{
+				{Pattern: "*.delete", Effect: GrantEffectDeny},
+				{Pattern: "admin.users.delete", Effect: GrantEffectDeny},
+				{Pattern: "admin.users.*", Effect: GrantEffectAllow},
+			},
+		},
+		{
+			name:    ":own no cubre el permiso completo",
+			request: "content.materials.update",
+			reason:  GrantsReasonDefaultDeny,
+			matches: []GrantMatch{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExplainGrants(g, tt.request)
+			if got.Allowed != tt.allowed || got.Reason != tt.reason {
+				t.Fatalf("decisión = (%v, %q), want (%v, %q)", got.Allowed, got.Reason, tt.allowed, tt.reason)
+			}
+			if got.Allowed != EvaluateGrants(g, tt.request) {
+				t.Fatalf("la decisión difiere de EvaluateGrants")
+			}
+			if !reflect.DeepEqual(got.Winner, tt.winner) {
+				t.Errorf("winner = %+v, want %+v", got.Winner, tt.winner)
+			}
+			if !reflect.DeepEqual(got.Matches, tt.matches) {
+				t.Errorf("matches = %+v, want %+v", got.Matches, tt.matches)
+			}
+		})
+	}
+}
+
+func TestExplainGrants_String(t *testing.T) {
+	g := Grants{Allow: []string{"admin.users.*"}, Deny: []string{"*.delete"}}
+	if got, want := ExplainGrants(g, "admin.users.delete").String(),
+		"denied by deny:*.delete; matched allow:admin.users.*"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := ExplainGrants(g, "reports.view").String(), "default_deny"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// TestExplainGrantChain verifica que cada match informa los roles de la
+// cadena que aportan el pattern y que la decisión coincide con la del
+// Grants aplanado por MergeGrantChain.
+func TestExplainGrantChain(t *testing.T) {
+	chain := []RoleGrants{
+		{RoleID: "teacher", Grants: Grants{Allow: []string{"content.materials.*", "reports.read"}}},
+		{RoleID: "teacher-substitute", Grants: Grants{
+			Allow: []string{"reports.read"},
+			Deny:  []string{"content.materials.delete"},
+		}},
+	}
+
+	got := ExplainGrantChain(chain, "content.materials.delete")
+	if got.Allowed || got.Reason != GrantsReasonDenied {
+		t.Fatalf("decisión = (%v, %q), want denied", got.Allowed, got.Reason)
+	}
+	wantWinner := &GrantMatch{Pattern: "content.materials.delete", Effect: GrantEffectDeny, Roles: []string{"teacher-substitute"}}
+	if !reflect.DeepEqual(got.Winner, wantWinner) {
+		t.Errorf("winner = %+v, want %+v", got.Winner, wantWinner)
+	}
+	if len(got.Matches) != 2 || !reflect.DeepEqual(got.Matches[1].Roles, []string{"teacher"}) {
+		t.Errorf("matches = %+v, want el allow content.materials.* del rol teacher", got.Matches)
+	}
+
+	got = ExplainGrantChain(chain, "reports.read")
+	if !got.Allowed {
+		t.Fatal("reports.read debe estar permitido")
+	}
+	if want := []string{"teacher", "teacher-substitute"}; !reflect.DeepEqual(got.Winner.Roles, want) {
+		t.Errorf("roles = %v, want %v", got.Winner.Roles, want)
+	}
+
+	merged := MergeGrantChain([]Grants{chain[0].Grants, chain[1].Grants})
+	for _, r := range []string{"content.materials.read", "content.materials.delete", "reports.read", "admin.users.read"} {
+		if ExplainGrantChain(chain, r).Allowed != EvaluateGrants(merged, r) {
+			t.Errorf("%q: la decisión difiere de EvaluateGrants(MergeGrantChain)", r)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// JWKSPath es la ruta estándar donde se publica el JWKS.
+const JWKSPath = "/.well-known/jwks.json"
+
+// Valores por defecto de JWKSClientConfig y del handler.
+const (
+	DefaultJWKSRefreshInterval    = time.Hour
+	DefaultJWKSMinRefreshInterval = time.Minute
+	DefaultJWKSFetchTimeout       = 5 * time.Second
+	DefaultJWKSMaxAge             = 5 * time.Minute
+)
+
+// maxJWKSBytes limita el tamaño de un JWKS remoto.
+const maxJWKSBytes = 1 << 20
+
+var (
+	// ErrInvalidJWK indica un JWK mal formado o con parámetros no soportados.
+	ErrInvalidJWK = stdErrors.New("auth: JWK inválido")
+	// ErrJWKSUnavailable indica que el JWKS remoto no pudo obtenerse y no hay claves en caché.
+	ErrJWKSUnavailable = stdErrors.New("auth: JWKS no disponible")
+)
+
+// JWK es una clave pública en formato JSON Web Key (RFC 7517). Los campos
+// binarios van en base64url sin padding.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC (crv, x, y) y OKP/Ed25519 (crv, x)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS es un conjunto de JWK tal como se publica en JWKSPath.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// JWKS retorna las claves públicas del KeySet en formato JWKS. Las claves
+// HMAC nunca se incluyen.
+func (s *KeySet) JWKS() (JWKS, error) {
+	keys := s.PublicKeys()
+	out := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, k := range keys {
+		jwk, err := k.JWK()
+		if err != nil {
+			return JWKS{}, err
+		}
+		out.Keys = append(out.Keys, jwk)
+	}
+	return out, nil
+}
+
+// JWK codifica la clave pública. Retorna ErrUnsupportedKey para claves HMAC.
+func (k Key) JWK() (JWK, error) {
+	jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64.EncodeToString(pub.N.Bytes())
+		jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		raw, err := pub.Bytes()
+		if err != nil {
+			return JWK{}, fmt.Errorf("%w: %v", ErrUnsupportedKey, err)
+		}
+		// raw = 0x04 || X || Y, con X e Y del tamaño de la curva.
+		size := (len(raw) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64.EncodeToString(raw[1 : 1+size])
+		jwk.Y = b64.EncodeToString(raw[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64.EncodeToString(pub)
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, k.public)
+	}
+	return jwk, nil
+}
+
+// Key decodifica el JWK en una clave de verificación. Si el JWK declara `alg`
+// debe coincidir con el inferido del tipo de clave.
+func (j JWK) Key() (Key, error) {
+	if j.Use != "" && j.Use != "sig" {
+		return Key{}, fmt.Errorf("%w: use %q", ErrInvalidJWK, j.Use)
+	}
+	var (
+		key Key
+		err error
+	)
+	switch j.Kty {
+	case "RSA":
+		key, err = j.rsaKey()
+	case "EC":
+		key, err = j.ecKey()
+	case "OKP":
+		key, err = j.okpKey()
+	default:
+		return Key{}, fmt.Errorf("%w: kty %q", ErrInvalidJWK, j.Kty)
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	if j.Alg != "" && j.Alg != key.Algorithm {
+		return Key{}, fmt.Errorf("%w: alg %q no corresponde a la clave (%s)", ErrInvalidJWK, j.Alg, key.Algorithm)
+	}
+	return key, nil
+}
+
+func (j JWK) rsaKey() (Key, error) {
+	n, err := b64.DecodeString(j.N)
+	if err != nil || len(n) == 0 {
+		return Key{}, fmt.Errorf("%w: n", ErrInvalidJWK)
+	}
+	e, err := b64.DecodeString(j.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return Key{}, fmt.Errorf("%w: e", ErrInvalidJWK)
+	}
+	exp := new(big.Int).SetBytes(e)
+	return NewVerificationKey(j.Kid, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())})
+}
+
+func (j JWK) ecKey() (Key, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return Key{}, fmt.Errorf("%w: crv %q", ErrInvalidJWK, j.Crv)
+	}
+	size := (curve.Params().BitSize + 7) / 8
+	x, errX := b64.DecodeString(j.X)
+	y, errY := b64.DecodeString(j.Y)
+	if errX != nil || errY != nil || len(x) != size || len(y) != size {
+		return Key{}, fmt.Errorf("%w: coordenadas EC", ErrInvalidJWK)
+	}
+	raw := make([]byte, 0, 1+2*size)
+	raw = append(raw, 0x04)
+	raw = append(raw, x...)
+	raw = append(raw, y...)
+	pub, err := ecdsa.ParseUncompressedPublicKey(curve, raw)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+	}
+	return NewVerificationKey(j.Kid, pub)
+}
+
+func (j JWK) okpKey() (Key, error) {
+	if j.Crv != "Ed25519" {
+		return Key{}, fmt.Errorf("%w: crv %q", ErrInvalidJWK, j.Crv)
+	}
+	x, err := b64.DecodeString(j.X)
+	if err != nil || len(x) != ed25519.PublicKeySize {
+		return Key{}, fmt.Errorf("%w: x", ErrInvalidJWK)
+	}
+	return NewVerificationKey(j.Kid, ed25519.PublicKey(x))
+}
+
+// ParseJWKS decodifica un documento JWKS en un KeySet solo de verificación.
+// Las claves con `use` distinto de "sig" o de tipo desconocido se ignoran,
+// como recomienda RFC 7517. Un JWK soportado pero mal formado se omite y su
+// error se retorna en skipped, para que una clave rota no invalide las demás;
+// solo es un error que no quede ninguna clave usable.
+func ParseJWKS(data []byte) (set *KeySet, skipped []error, err error) {
+	var doc JWKS
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidJWK, err)
+	}
+	set, _ = NewKeySet()
+	usable := 0
+	for _, jwk := range doc.Keys {
+		if (jwk.Use != "" && jwk.Use != "sig") || !supportedKty(jwk.Kty) {
+			continue
+		}
+		key, err := jwk.Key()
+		if err == nil {
+			err = set.Add(key)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("kid %q: %w", jwk.Kid, err))
+			continue
+		}
+		usable++
+	}
+	if usable == 0 {
+		return nil, skipped, fmt.Errorf("%w: el JWKS no tiene claves de verificación usables", ErrInvalidJWK)
+	}
+	return set, skipped, nil
+}
+
+func supportedKty(kty string) bool {
+	return kty == "RSA" || kty == "EC" || kty == "OKP"
+}
+
+// NewJWKSHandler retorna un http.Handler que publica las claves públicas del
+// KeySet en formato JWKS, para montarlo en JWKSPath (en Gin:
+// `r.GET(auth.JWKSPath, gin.WrapH(auth.NewJWKSHandler(keys, 0)))`). El JWKS
+// se genera en cada request, por lo que refleja las rotaciones. maxAge fija el
+// Cache-Control; si es 0 se usa DefaultJWKSMaxAge.
+func NewJWKSHandler(keys *KeySet, maxAge time.Duration) http.Handler {
+	if maxAge <= 0 {
+		maxAge = DefaultJWKSMaxAge
+	}
+	cacheControl := "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		doc, err := keys.JWKS()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		body, err := json.Marshal(doc)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", cacheControl)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(body)
+		}
+	})
+}
+
+// JWKSClientConfig configura un JWKSClient.
+type JWKSClientConfig struct {
+	// HTTPClient para las descargas. Si es nil se usa uno con FetchTimeout.
+	HTTPClient *http.Client
+	// RefreshInterval es la vigencia del JWKS en caché (default 1h).
+	RefreshInterval time.Duration
+	// MinRefreshInterval limita las descargas forzadas por un kid desconocido
+	// (default 1m), para que tokens con kids inventados no golpeen al emisor.
+	MinRefreshInterval time.Duration
+	// FetchTimeout limita cada descarga (default 5s).
+	FetchTimeout time.Duration
+	// OnError recibe los JWK que ParseJWKS omitió por estar mal formados
+	// (opcional).
+	OnError func(err error)
+}
+
+// JWKSClient obtiene y cachea el JWKS publicado por el emisor de tokens.
+// Implementa KeyResolver para usarse con NewJWTManagerWithResolver.
+//
+// El JWKS se descarga de forma perezosa y se renueva al vencer
+// RefreshInterval o al recibir un kid desconocido (como mucho una vez por
+// MinRefreshInterval), lo que cubre la rotación de claves del emisor. Si una
+// descarga falla se siguen usando las claves en caché.
+//
+// Es seguro para uso concurrente.
+type JWKSClient struct {
+	url    string
+	client *http.Client
+	cfg    JWKSClientConfig
+	now    func() time.Time // reemplazable en tests
+
+	fetchMu sync.Mutex // serializa las descargas
+
+	mu          sync.RWMutex
+	keys        *KeySet
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewJWKSClient crea un JWKSClient para la URL del JWKS (p. ej.
+// "https://auth.edugo.com"+JWKSPath). No realiza ninguna descarga.
+func NewJWKSClient(url string, cfg JWKSClientConfig) *JWKSClient {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultJWKSRefreshInterval
+	}
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = DefaultJWKSMinRefreshInterval
+	}
+	if cfg.FetchTimeout <= 0 {
+		cfg.FetchTimeout = DefaultJWKSFetchTimeout
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: cfg.FetchTimeout}
+	}
+	return &JWKSClient{url: url, client: client, cfg: cfg, now: time.Now}
+}
+
+// ResolveKey implementa KeyResolver: retorna la clave del kid, renovando el
+// JWKS si está vencido o si el kid no está en caché.
+func (c *JWKSClient) ResolveKey(kid string) (Key, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.FetchTimeout)
+	defer cancel()
+
+	keys, stale := c.cached()
+	if keys == nil || stale {
+		if err := c.refreshIfDue(ctx); err != nil && keys == nil {
+			return Key{}, err
+		}
+		keys, _ = c.cached()
+	}
+	if key, ok := keys.Key(kid); ok {
+		return key, nil
+	}
+
+	// kid desconocido: posible rotación en el emisor.
+	if err := c.refreshIfDue(ctx); err == nil {
+		keys, _ = c.cached()
+		if key, ok := keys.Key(kid); ok {
+			return key, nil
+		}
+	}
+	return Key{}, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+}
+
+// Refresh descarga el JWKS inmediatamente y reemplaza la caché. Útil para
+// precargar las claves al iniciar el servicio.
+func (c *JWKSClient) Refresh(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+	return c.fetch(ctx)
+}
+
+// cached retorna el KeySet en caché (nil si nunca se descargó) e indica si venció.
+func (c *JWKSClient) cached() (*KeySet, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.keys == nil {
+		return nil, true
+	}
+	return c.keys, c.now().Sub(c.fetchedAt) >= c.cfg.RefreshInterval
+}
+
+// refreshIfDue descarga el JWKS salvo que el último intento (exitoso o no)
+// sea más reciente que MinRefreshInterval.
+func (c *JWKSClient) refreshIfDue(ctx context.Context) error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	c.mu.RLock()
+	recent := !c.attemptedAt.IsZero() && c.now().Sub(c.attemptedAt) < c.cfg.MinRefreshInterval
+	empty := c.keys == nil
+	c.mu.RUnlock()
+	if recent {
+		if empty {
+			return ErrJWKSUnavailable
+		}
+		return nil
+	}
+	return c.fetch(ctx)
+}
+
+// fetch descarga y parsea el JWKS. Debe llamarse con fetchMu tomado.
+func (c *JWKSClient) fetch(ctx context.Context) error {
+	c.mu.Lock()
+	c.attemptedAt = c.now()
+	c.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrJWKSUnavailable, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJWKSUnavailable, err)
+	}
+	keys, skipped, err := ParseJWKS(body)
+	for _, skipErr := range skipped {
+		c.cfg.OnError(fmt.Errorf("auth: omitiendo JWK de %s: %w", c.url, skipErr))
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = c.now()
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newECKey(t *testing.T, kid string, curve elliptic.Curve) Key {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	key, err := NewSigningKey(kid, priv)
+	require.NoError(t, err)
+	return key
+}
+
+// jwksServer publica el KeySet con NewJWKSHandler y cuenta las descargas.
+func jwksServer(t *testing.T, keys *KeySet) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	handler := NewJWKSHandler(keys, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// fakeClock es un reloj manual para controlar la vigencia de la caché.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	keys := []Key{
+		newRSAKey(t, "rsa"),
+		newEd25519Key(t, "ed"),
+		newECKey(t, "p256", elliptic.P256()),
+		newECKey(t, "p384", elliptic.P384()),
+		newECKey(t, "p521", elliptic.P521()),
+	}
+	for _, key := range keys {
+		t.Run(key.ID, func(t *testing.T) {
+			jwk, err := key.JWK()
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, jwk.Kid)
+			assert.Equal(t, key.Algorithm, jwk.Alg)
+			assert.Equal(t, "sig", jwk.Use)
+
+			decoded, err := jwk.Key()
+			require.NoError(t, err)
+			assert.Equal(t, key.Algorithm, decoded.Algorithm)
+			assert.False(t, decoded.CanSign())
+			assert.True(t, decoded.PublicKey().(interface{ Equal(x crypto.PublicKey) bool }).Equal(key.PublicKey()))
+		})
+	}
+}
+
+func TestJWK_HMACNotEncodable(t *testing.T) {
+	key, err := NewHMACKey("", []byte("secret"))
+	require.NoError(t, err)
+	_, err = key.JWK()
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
+
+func TestJWK_Invalid(t *testing.T) {
+	rsaJWK, err := newRSAKey(t, "rsa").JWK()
+	require.NoError(t, err)
+
+	tests := map[string]JWK{
+		"kty desconocido":      {Kty: "oct"},
+		"use enc":              {Kty: "RSA", Use: "enc", N: rsaJWK.N, E: rsaJWK.E},
+		"alg inconsistente":    {Kty: "RSA", Alg: "ES256", N: rsaJWK.N, E: rsaJWK.E},
+		"curva no soportada":   {Kty: "EC", Crv: "secp256k1", X: "AA", Y: "AA"},
+		"coordenadas cortas":   {Kty: "EC", Crv: "P-256", X: "AA", Y: "AA"},
+		"ed25519 mal formado":  {Kty: "OKP", Crv: "Ed25519", X: "AA"},
+		"rsa sin exponente":    {Kty: "RSA", N: rsaJWK.N},
+		"base64 inválido en n": {Kty: "RSA", N: "***", E: rsaJWK.E},
+	}
+	for name, jwk := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := jwk.Key()
+			assert.ErrorIs(t, err, ErrInvalidJWK)
+		})
+	}
+}
+
+func TestParseJWKS_SkipsForeignKeys(t *testing.T) {
+	ed := newEd25519Key(t, "ed")
+	jwk, err := ed.JWK()
+	require.NoError(t, err)
+	doc, err := json.Marshal(JWKS{Keys: []JWK{
+		jwk,
+		{Kty: "oct", Kid: "hmac"},
+		{Kty: "RSA", Kid: "enc", Use: "enc"},
+	}})
+	require.NoError(t, err)
+
+	set, skipped, err := ParseJWKS(doc)
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+	_, ok := set.Key("ed")
+	assert.True(t, ok)
+	assert.Len(t, set.PublicKeys(), 1)
+	_, ok = set.SigningKey()
+	assert.False(t, ok)
+}
+
+func TestParseJWKS_SkipsMalformedKeys(t *testing.T) {
+	ed := newEd25519Key(t, "ed")
+	jwk, err := ed.JWK()
+	require.NoError(t, err)
+	broken := JWK{Kty: "EC", Kid: "roto", Crv: "P-256", X: "AA", Y: "AA"}
+
+	doc, err := json.Marshal(JWKS{Keys: []JWK{broken, jwk}})
+	require.NoError(t, err)
+	set, skipped, err := ParseJWKS(doc)
+	require.NoError(t, err)
+	_, ok := set.Key("ed")
+	assert.True(t, ok)
+	require.Len(t, skipped, 1)
+	assert.ErrorIs(t, skipped[0], ErrInvalidJWK)
+	assert.Contains(t, skipped[0].Error(), `"roto"`)
+
+	// Sin ninguna clave usable el documento es inválido.
+	doc, err = json.Marshal(JWKS{Keys: []JWK{broken, {Kty: "oct", Kid: "hmac"}}})
+	require.NoError(t, err)
+	_, skipped, err = ParseJWKS(doc)
+	assert.ErrorIs(t, err, ErrInvalidJWK)
+	assert.Len(t, skipped, 1)
+}
+
+func TestJWKSHandler(t *testing.T) {
+	hmac, err := NewHMACKey("", []byte("legacy"))
+	require.NoError(t, err)
+	keys, err := NewKeySet(newEd25519Key(t, "ed"), newRSAKey(t, "rsa"), hmac)
+	require.NoError(t, err)
+
+	handler := NewJWKSHandler(keys, 10*time.Minute)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, JWKSPath, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=600", rec.Header().Get("Cache-Control"))
+
+	var doc JWKS
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.Len(t, doc.Keys, 2, "la clave HMAC no se publica")
+	assert.Equal(t, "ed", doc.Keys[0].Kid)
+	assert.Equal(t, "rsa", doc.Keys[1].Kid)
+	assert.NotContains(t, rec.Body.String(), `"d"`, "sin material privado")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, JWKSPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestJWKSClient_ValidatesTokensFromIssuer(t *testing.T) {
+	issuerKeys, err := NewKeySet(newEd25519Key(t, "ed-1"))
+	require.NoError(t, err)
+	srv, hits := jwksServer(t, issuerKeys)
+
+	issuer := NewJWTManagerWithKeySet(issuerKeys, "edugo-central")
+	validator := NewJWTManagerWithResolver(NewJWKSClient(srv.URL+JWKSPath, JWKSClientConfig{}), "edugo-central")
+
+	token, _, err := issuer.GenerateTokenWithContext("user-1", "u@edugo.com", testActiveContext(), time.Hour)
+	require.NoError(t, err)
+
+	for range 3 {
+		claims, err := validator.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims.UserID)
+	}
+	assert.Equal(t, int32(1), hits.Load(), "el JWKS se cachea")
+
+	_, _, err = validator.GenerateTokenWithContext("user-1", "u@edugo.com", testActiveContext(), time.Hour)
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestJWKSClient_RefreshesOnUnknownKid(t *testing.T) {
+	issuerKeys, err := NewKeySet(newEd25519Key(t, "old"))
+	require.NoError(t, err)
+	srv, hits := jwksServer(t, issuerKeys)
+
+	clock := &fakeClock{now: time.Now()}
+	client := NewJWKSClient(srv.URL, JWKSClientConfig{})
+	client.now = clock.Now
+	_, err = client.ResolveKey("old")
+	require.NoError(t, err)
+
+	// Rotación en el emisor.
+	require.NoError(t, issuerKeys.Add(newRSAKey(t, "new")))
+	require.NoError(t, issuerKeys.SetSigningKey("new"))
+
+	// Dentro de MinRefreshInterval no se vuelve a descargar.
+	_, err = client.ResolveKey("new")
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+	assert.Equal(t, int32(1), hits.Load())
+
+	clock.Advance(DefaultJWKSMinRefreshInterval)
+	key, err := client.ResolveKey("new")
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", key.Algorithm)
+	assert.Equal(t, int32(2), hits.Load())
+
+	// Un kid inventado no fuerza descargas adicionales.
+	_, err = client.ResolveKey("forged")
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestJWKSClient_ReportsSkippedKeys(t *testing.T) {
+	jwk, err := newEd25519Key(t, "ed").JWK()
+	require.NoError(t, err)
+	body, err := json.Marshal(JWKS{Keys: []JWK{{Kty: "OKP", Kid: "roto", Crv: "Ed25519", X: "AA"}, jwk}})
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	var reported []error
+	client := NewJWKSClient(srv.URL, JWKSClientConfig{OnError: func(err error) { reported = append(reported, err) }})
+	_, err = client.ResolveKey("ed")
+	require.NoError(t, err)
+	require.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], ErrInvalidJWK)
+}
+
+func TestJWKSClient_RefreshIntervalAndStaleOnError(t *testing.T) {
+	issuerKeys, err := NewKeySet(newEd25519Key(t, "ed"))
+	require.NoError(t, err)
+
+	var failing atomic.Bool
+	var hits atomic.Int32
+	handler := NewJWKSHandler(issuerKeys, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	clock := &fakeClock{now: time.Now()}
+	client := NewJWKSClient(srv.URL, JWKSClientConfig{RefreshInterval: 10 * time.Minute})
+	client.now = clock.Now
+	require.NoError(t, client.Refresh(t.Context()))
+
+	// Vencida la caché se vuelve a descargar; si falla se usan las claves en caché.
+	failing.Store(true)
+	clock.Advance(10 * time.Minute)
+	key, err := client.ResolveKey("ed")
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", key.Algorithm)
+	assert.Equal(t, int32(2), hits.Load())
+
+	assert.ErrorIs(t, client.Refresh(t.Context()), ErrJWKSUnavailable)
+}
+
+func TestJWKSClient_Unavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	clock := &fakeClock{now: time.Now()}
+	client := NewJWKSClient(srv.URL, JWKSClientConfig{})
+	client.now = clock.Now
+	_, err := client.ResolveKey("any")
+	assert.ErrorIs(t, err, ErrJWKSUnavailable)
+
+	// Sin claves en caché, los reintentos también respetan MinRefreshInterval.
+	_, err = client.ResolveKey("any")
+	assert.ErrorIs(t, err, ErrJWKSUnavailable)
+}
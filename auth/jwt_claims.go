@@ -1,6 +1,11 @@
 package auth
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 // UserContext representa el contexto activo del usuario en el JWT.
 // Encapsula el rol actual, la escuela/unidad académica asociadas y los
@@ -75,5 +80,44 @@ type Claims struct {
 	// (sujeto + modo de actor) al rotar el access token (ADR 0026).
 	SubjectStudentID string `json:"subject_student_id,omitempty"`
 	ActorMode        string `json:"actor_mode,omitempty"`
+	// AMR son los métodos de autenticación usados en el login (RFC 8176),
+	// AuthTime el momento del login (OIDC auth_time) y MFAAt el del último
+	// segundo factor. Se fijan con WithAMR/WithAuthTime/WithMFA y viajan
+	// también en el refresh para preservarse al rotar: a diferencia de iat,
+	// AuthTime no avanza con cada refresh.
+	AMR      []string         `json:"amr,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	MFAAt    *jwt.NumericDate `json:"mfa_at,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Valores de AMR (RFC 8176). AMRRecoveryCode no es estándar.
+const (
+	AMRPassword     = "pwd"
+	AMROTP          = "otp"
+	AMRMultiFactor  = "mfa"
+	AMRRecoveryCode = "rcode"
+)
+
+// HasAMR reporta si el token incluye el método de autenticación.
+func (c *Claims) HasAMR(method string) bool {
+	return slices.Contains(c.AMR, method)
+}
+
+// MFAFresh reporta si el token tiene un segundo factor verificado hace a lo
+// sumo maxAge. Con maxAge <= 0 basta con que haya un segundo factor.
+func (c *Claims) MFAFresh(maxAge time.Duration, now time.Time) bool {
+	if c.MFAAt == nil {
+		return false
+	}
+	return maxAge <= 0 || now.Sub(c.MFAAt.Time) <= maxAge
+}
+
+// AuthFresh reporta si el login fue hace a lo sumo maxAge. Un token sin
+// auth_time nunca es fresco; con maxAge <= 0 basta con que lo tenga.
+func (c *Claims) AuthFresh(maxAge time.Duration, now time.Time) bool {
+	if c.AuthTime == nil {
+		return false
+	}
+	return maxAge <= 0 || now.Sub(c.AuthTime.Time) <= maxAge
+}
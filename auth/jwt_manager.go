@@ -3,6 +3,7 @@ package auth
 import (
 	stdErrors "errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +16,7 @@ import (
 type JWTManager struct {
 	issuer    string
 	secretKey []byte
+	keys      KeyResolver
 }
 
 // NewJWTManager crea un nuevo JWTManager
@@ -25,6 +27,77 @@ func NewJWTManager(secretKey, issuer string) *JWTManager {
 	}
 }
 
+// NewJWTManagerWithKeySet crea un JWTManager que firma con la clave de firma
+// del KeySet (RS256/ES256/EdDSA, con header `kid`) y valida con cualquiera de
+// sus claves según el `kid` del token. Un KeySet sin clave de firma sirve para
+// servicios que solo validan. Con un KeySet nil los Generate* retornan
+// ErrNoKeys y todo token se rechaza.
+func NewJWTManagerWithKeySet(keys *KeySet, issuer string) *JWTManager {
+	return &JWTManager{
+		keys:   keySetResolver(keys),
+		issuer: issuer,
+	}
+}
+
+// NewJWTManagerWithResolver crea un JWTManager solo de validación que obtiene
+// las claves de un KeyResolver (p. ej. un JWKSClient). Los métodos Generate*
+// retornan error salvo que el resolver sea un KeySet con clave de firma.
+func NewJWTManagerWithResolver(resolver KeyResolver, issuer string) *JWTManager {
+	return &JWTManager{
+		keys:   orNoKeys(resolver),
+		issuer: issuer,
+	}
+}
+
+// TokenOption agrega claims opcionales al emitir un token.
+type TokenOption func(*Claims)
+
+// WithAMR fija los métodos de autenticación del login (p. ej. AMRPassword).
+func WithAMR(methods ...string) TokenOption {
+	return func(c *Claims) {
+		for _, m := range methods {
+			if !slices.Contains(c.AMR, m) {
+				c.AMR = append(c.AMR, m)
+			}
+		}
+	}
+}
+
+// WithAuthTime fija el momento del login (claim auth_time). Se pasa al
+// emitir los tokens del login; en el refresh se preserva con AuthOptions.
+func WithAuthTime(at time.Time) TokenOption {
+	return func(c *Claims) {
+		c.AuthTime = jwt.NewNumericDate(at)
+	}
+}
+
+// WithMFA marca el token con un segundo factor verificado en `at` y agrega
+// AMRMultiFactor a AMR.
+func WithMFA(at time.Time) TokenOption {
+	return func(c *Claims) {
+		c.MFAAt = jwt.NewNumericDate(at)
+		WithAMR(AMRMultiFactor)(c)
+	}
+}
+
+// AuthOptions retorna las opciones que reproducen los claims de
+// autenticación (AMR, AuthTime, MFAAt) de estos claims. El refresh use case
+// las pasa al emitir el siguiente token para preservarlos al rotar.
+func (c *Claims) AuthOptions() []TokenOption {
+	var opts []TokenOption
+	if len(c.AMR) > 0 {
+		opts = append(opts, WithAMR(c.AMR...))
+	}
+	if c.AuthTime != nil {
+		opts = append(opts, WithAuthTime(c.AuthTime.Time))
+	}
+	if c.MFAAt != nil {
+		at := c.MFAAt.Time
+		opts = append(opts, func(c *Claims) { c.MFAAt = jwt.NewNumericDate(at) })
+	}
+	return opts
+}
+
 // GenerateTokenWithContext genera un JWT con contexto RBAC.
 //
 // Parámetros:
@@ -32,6 +105,7 @@ func NewJWTManager(secretKey, issuer string) *JWTManager {
 //   - email: Email del usuario (requerido, no puede estar vacío)
 //   - activeContext: Contexto activo del usuario con rol, escuela y permisos (requerido)
 //   - expiresIn: Duración hasta la expiración del token (mínimo 1 minuto)
+//   - opts: Claims opcionales (WithAMR, WithMFA)
 //
 // Retorna:
 //   - string: Token JWT firmado
@@ -41,6 +115,7 @@ func (m *JWTManager) GenerateTokenWithContext(
 	userID, email string,
 	activeContext *UserContext,
 	expiresIn time.Duration,
+	opts ...TokenOption,
 ) (string, time.Time, error) {
 	// Validar entradas
 	if userID == "" {
@@ -72,9 +147,11 @@ func (m *JWTManager) GenerateTokenWithContext(
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(m.secretKey)
+	signedToken, err := m.sign(claims)
 	if err != nil {
 		return "", time.Time{}, errors.NewInternalError("no se pudo firmar el token JWT", err)
 	}
@@ -87,7 +164,7 @@ func (m *JWTManager) GenerateTokenWithContext(
 func (m *JWTManager) parseAndValidateToken(tokenString string) (*Claims, error) {
 	parser := jwt.NewParser(
 		jwt.WithIssuer(m.issuer),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithValidMethods(m.validMethods()),
 		// SH-1: exige claim `exp`. Un token sin expiración (aunque esté bien
 		// firmado) se rechaza. Los tres generadores (GenerateTokenWithContext,
 		// GenerateMinimalToken) siempre fijan `exp`, así que no rompe tokens
@@ -98,12 +175,7 @@ func (m *JWTManager) parseAndValidateToken(tokenString string) (*Claims, error)
 		jwt.WithLeeway(30*time.Second),
 	)
 
-	token, err := parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
+	token, err := parser.ParseWithClaims(tokenString, &Claims{}, m.keyFunc)
 
 	if err != nil {
 		if stdErrors.Is(err, jwt.ErrTokenExpired) {
@@ -124,6 +196,31 @@ func (m *JWTManager) parseAndValidateToken(tokenString string) (*Claims, error)
 	return claims, nil
 }
 
+// sign firma los claims con el KeySet o, sin KeySet, con el secret HS256.
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
+	if m.keys != nil {
+		return signWith(m.keys, claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secretKey)
+}
+
+func (m *JWTManager) validMethods() []string {
+	if m.keys != nil {
+		return validMethods(m.keys)
+	}
+	return []string{jwt.SigningMethodHS256.Alg()}
+}
+
+func (m *JWTManager) keyFunc(token *jwt.Token) (any, error) {
+	if m.keys != nil {
+		return resolverKeyFunc(m.keys)(token)
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return m.secretKey, nil
+}
+
 // ValidateToken valida un JWT token y retorna los claims
 func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	claims, err := m.parseAndValidateToken(tokenString)
@@ -154,9 +251,14 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 // Los demás snapshot pueden venir vacíos (ej. super_admin recién logueado que
 // todavía no eligió escuela). En ese caso el siguiente access_token también irá
 // sin esos claims y el cliente deberá completar la cascada con switch-context.
+//
+// opts permite preservar los claims de autenticación (AMR, AuthTime, MFAAt)
+// del login: el refresh use case pasa claims.AuthOptions() del refresh token
+// presentado.
 func (m *JWTManager) GenerateMinimalToken(
 	userID, email, schoolID, academicUnitID, roleID, subjectStudentID, actorMode string,
 	expiresIn time.Duration,
+	opts ...TokenOption,
 ) (string, time.Time, error) {
 	if userID == "" {
 		return "", time.Time{}, errors.NewValidationError("userID no puede estar vacío")
@@ -190,9 +292,11 @@ func (m *JWTManager) GenerateMinimalToken(
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	for _, opt := range opts {
+		opt(&claims)
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(m.secretKey)
+	signedToken, err := m.sign(claims)
 	if err != nil {
 		return "", time.Time{}, errors.NewInternalError("no se pudo firmar el token JWT", err)
 	}
@@ -0,0 +1,394 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	stdErrors "errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits es el tamaño mínimo aceptado para claves RSA.
+const minRSAKeyBits = 2048
+
+var (
+	// ErrUnsupportedKey indica un tipo de clave no soportado (o una RSA menor a 2048 bits).
+	ErrUnsupportedKey = stdErrors.New("auth: tipo de clave no soportado")
+	// ErrNoSigningKey indica que el KeySet no tiene clave de firma designada.
+	ErrNoSigningKey = stdErrors.New("auth: el KeySet no tiene clave de firma")
+	// ErrUnknownKeyID indica que el kid no está registrado en el KeySet.
+	ErrUnknownKeyID = stdErrors.New("auth: kid desconocido")
+	// ErrDuplicateKeyID indica que ya existe una clave con ese kid.
+	ErrDuplicateKeyID = stdErrors.New("auth: kid duplicado")
+	// ErrNoKeys indica un manager creado con un KeySet o KeyResolver nil.
+	ErrNoKeys = stdErrors.New("auth: KeySet o KeyResolver nil")
+)
+
+// Key es una clave de firma o verificación de JWT identificada por su kid.
+// El algoritmo se infiere del tipo de clave: RSA → RS256, ECDSA P-256/P-384/P-521
+// → ES256/ES384/ES512, Ed25519 → EdDSA y secretos HMAC → HS256.
+type Key struct {
+	ID        string
+	Algorithm string
+	private   any // *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey o []byte (HMAC)
+	public    any // *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey o []byte (HMAC)
+}
+
+// NewSigningKey crea una clave de firma a partir de una clave privada RSA,
+// ECDSA o Ed25519. La misma clave sirve para verificar.
+func NewSigningKey(kid string, private crypto.Signer) (Key, error) {
+	if private == nil {
+		return Key{}, ErrUnsupportedKey
+	}
+	key, err := NewVerificationKey(kid, private.Public())
+	if err != nil {
+		return Key{}, err
+	}
+	switch k := private.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey:
+		key.private = k
+	case ed25519.PrivateKey:
+		key.private = k
+	case *ed25519.PrivateKey:
+		key.private = *k
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, private)
+	}
+	return key, nil
+}
+
+// NewVerificationKey crea una clave solo de verificación a partir de una clave
+// pública RSA, ECDSA o Ed25519.
+func NewVerificationKey(kid string, public crypto.PublicKey) (Key, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return Key{}, fmt.Errorf("%w: RSA de %d bits (mínimo %d)", ErrUnsupportedKey, k.N.BitLen(), minRSAKeyBits)
+		}
+		return Key{ID: kid, Algorithm: jwt.SigningMethodRS256.Alg(), public: k}, nil
+	case *ecdsa.PublicKey:
+		alg, err := ecdsaAlgorithm(k.Curve)
+		if err != nil {
+			return Key{}, err
+		}
+		return Key{ID: kid, Algorithm: alg, public: k}, nil
+	case ed25519.PublicKey:
+		return Key{ID: kid, Algorithm: jwt.SigningMethodEdDSA.Alg(), public: k}, nil
+	default:
+		return Key{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, public)
+	}
+}
+
+// NewHMACKey crea una clave simétrica HS256. Pensada para la migración desde
+// NewJWTManager: registrada con kid vacío valida los tokens emitidos sin
+// header `kid`. Nunca se publica (ver PublicKeys).
+func NewHMACKey(kid string, secret []byte) (Key, error) {
+	if len(secret) == 0 {
+		return Key{}, fmt.Errorf("%w: secreto HMAC vacío", ErrUnsupportedKey)
+	}
+	return Key{ID: kid, Algorithm: jwt.SigningMethodHS256.Alg(), private: secret, public: secret}, nil
+}
+
+// CanSign indica si la clave tiene material privado.
+func (k Key) CanSign() bool {
+	return k.private != nil
+}
+
+// Symmetric indica si la clave es HMAC.
+func (k Key) Symmetric() bool {
+	_, ok := k.public.([]byte)
+	return ok
+}
+
+// PublicKey retorna la clave pública (nil para claves HMAC).
+func (k Key) PublicKey() crypto.PublicKey {
+	if k.Symmetric() {
+		return nil
+	}
+	return k.public
+}
+
+func (k Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func ecdsaAlgorithm(curve elliptic.Curve) (string, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256.Alg(), nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384.Alg(), nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512.Alg(), nil
+	default:
+		return "", fmt.Errorf("%w: curva ECDSA no soportada", ErrUnsupportedKey)
+	}
+}
+
+// KeySet agrupa las claves de verificación activas, seleccionadas por el
+// header `kid`, y designa una de ellas como clave de firma.
+//
+// Rotación sin downtime:
+//  1. Add(nueva) en todos los validadores (o publicarla vía JWKS).
+//  2. SetSigningKey(nueva.ID) en el emisor.
+//  3. Remove(vieja) cuando expiren los tokens firmados con ella.
+//
+// Es seguro para uso concurrente.
+type KeySet struct {
+	mu         sync.RWMutex
+	keys       map[string]Key
+	signingKID string
+	hasSigning bool
+}
+
+// NewKeySet crea un KeySet con las claves dadas. Si la primera clave puede
+// firmar, queda designada como clave de firma.
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	s := &KeySet{keys: make(map[string]Key)}
+	for _, k := range keys {
+		if err := s.Add(k); err != nil {
+			return nil, err
+		}
+	}
+	if len(keys) > 0 && keys[0].CanSign() {
+		if err := s.SetSigningKey(keys[0].ID); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add registra una clave. Retorna ErrDuplicateKeyID si el kid ya existe.
+func (s *KeySet) Add(key Key) error {
+	if key.public == nil || key.method() == nil {
+		return ErrUnsupportedKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.keys[key.ID]; exists {
+		return fmt.Errorf("%w: %q", ErrDuplicateKeyID, key.ID)
+	}
+	s.keys[key.ID] = key
+	return nil
+}
+
+// Remove elimina una clave. Si era la de firma, el KeySet queda sin clave de firma.
+func (s *KeySet) Remove(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, kid)
+	if s.hasSigning && s.signingKID == kid {
+		s.hasSigning = false
+		s.signingKID = ""
+	}
+}
+
+// SetSigningKey designa la clave de firma. Debe estar registrada y tener material privado.
+func (s *KeySet) SetSigningKey(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	if !key.CanSign() {
+		return fmt.Errorf("%w: %q es solo de verificación", ErrNoSigningKey, kid)
+	}
+	s.signingKID = kid
+	s.hasSigning = true
+	return nil
+}
+
+// SigningKey retorna la clave de firma designada.
+func (s *KeySet) SigningKey() (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.hasSigning {
+		return Key{}, false
+	}
+	return s.keys[s.signingKID], true
+}
+
+// Key retorna la clave registrada con el kid dado.
+func (s *KeySet) Key(kid string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// PublicKeys retorna las claves asimétricas ordenadas por kid, sin material
+// privado. Es el conjunto publicable (JWKS); las claves HMAC se excluyen.
+func (s *KeySet) PublicKeys() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		if k.Symmetric() {
+			continue
+		}
+		out = append(out, Key{ID: k.ID, Algorithm: k.Algorithm, public: k.public})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// algorithms retorna los algoritmos de las claves registradas.
+func (s *KeySet) algorithms() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := make(map[string]bool, len(s.keys))
+	out := make([]string, 0, len(s.keys))
+	for _, k := range s.keys {
+		if !seen[k.Algorithm] {
+			seen[k.Algorithm] = true
+			out = append(out, k.Algorithm)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// sign firma los claims con la clave de firma e incluye su kid en el header.
+func (s *KeySet) sign(claims jwt.Claims) (string, error) {
+	key, ok := s.SigningKey()
+	if !ok {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.private)
+}
+
+// ResolveKey implementa KeyResolver. Retorna ErrUnknownKeyID si el kid no está registrado.
+func (s *KeySet) ResolveKey(kid string) (Key, error) {
+	key, ok := s.Key(kid)
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+	}
+	return key, nil
+}
+
+// KeyResolver resuelve la clave de verificación de un token a partir de su
+// header `kid` (vacío si no lo trae). Lo implementan KeySet y JWKSClient.
+type KeyResolver interface {
+	ResolveKey(kid string) (Key, error)
+}
+
+// keySetResolver retorna keys como KeyResolver. Un *KeySet nil daría una
+// interfaz no nil que entra en pánico al primer uso; se reemplaza por un
+// resolver que falla con ErrNoKeys.
+func keySetResolver(keys *KeySet) KeyResolver {
+	if keys == nil {
+		return noKeys{}
+	}
+	return keys
+}
+
+// orNoKeys evita que un KeyResolver nil haga caer al manager en HS256 con
+// un secret vacío.
+func orNoKeys(r KeyResolver) KeyResolver {
+	if r == nil {
+		return noKeys{}
+	}
+	return r
+}
+
+// noKeys es el resolver de un manager creado sin claves.
+type noKeys struct{}
+
+func (noKeys) ResolveKey(string) (Key, error) { return Key{}, ErrNoKeys }
+
+// asymmetricAlgorithms son los algoritmos aceptados cuando el resolver no
+// informa los suyos (p. ej. un JWKS remoto).
+var asymmetricAlgorithms = []string{
+	jwt.SigningMethodEdDSA.Alg(),
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodES384.Alg(),
+	jwt.SigningMethodES512.Alg(),
+	jwt.SigningMethodRS256.Alg(),
+}
+
+// validMethods retorna los algoritmos aceptables para el resolver.
+func validMethods(r KeyResolver) []string {
+	if s, ok := r.(*KeySet); ok {
+		return s.algorithms()
+	}
+	return asymmetricAlgorithms
+}
+
+// resolverKeyFunc resuelve la clave de verificación por `kid` y exige que el
+// `alg` del token coincida con el de la clave, para evitar confusión de
+// algoritmos.
+func resolverKeyFunc(r KeyResolver) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := r.ResolveKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	}
+}
+
+// signWith firma con el resolver si es un KeySet con clave de firma.
+func signWith(r KeyResolver, claims jwt.Claims) (string, error) {
+	switch s := r.(type) {
+	case *KeySet:
+		return s.sign(claims)
+	case noKeys:
+		return "", ErrNoKeys
+	default:
+		return "", ErrNoSigningKey
+	}
+}
+
+// ParsePrivateKeyPEM decodifica una clave privada PEM (PKCS#8, PKCS#1 o SEC 1).
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: PEM inválido", ErrUnsupportedKey)
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: PEM %q no reconocido", ErrUnsupportedKey, block.Type)
+}
+
+// ParsePublicKeyPEM decodifica una clave pública PEM (PKIX o PKCS#1).
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: PEM inválido", ErrUnsupportedKey)
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: PEM %q no reconocido", ErrUnsupportedKey, block.Type)
+}
@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testActiveContext() *UserContext {
+	return &UserContext{RoleID: "role-1", RoleName: "teacher", Grants: Grants{Allow: []string{"content.*"}}}
+}
+
+func newRSAKey(t *testing.T, kid string) Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := NewSigningKey(kid, priv)
+	require.NoError(t, err)
+	return key
+}
+
+func newEd25519Key(t *testing.T, kid string) Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := NewSigningKey(kid, priv)
+	require.NoError(t, err)
+	return key
+}
+
+func TestNewSigningKey_InfersAlgorithm(t *testing.T) {
+	ec256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ec384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	cases := map[string]crypto.Signer{"ES256": ec256, "ES384": ec384}
+	for alg, priv := range cases {
+		key, err := NewSigningKey("k", priv)
+		require.NoError(t, err)
+		assert.Equal(t, alg, key.Algorithm)
+		assert.True(t, key.CanSign())
+	}
+
+	assert.Equal(t, "RS256", newRSAKey(t, "r").Algorithm)
+	assert.Equal(t, "EdDSA", newEd25519Key(t, "e").Algorithm)
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = NewSigningKey("small", small)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+
+	_, err = NewHMACKey("h", nil)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
+
+func TestJWTManagerWithKeySet_RoundTrip(t *testing.T) {
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := NewSigningKey("ec-1", ec)
+	require.NoError(t, err)
+
+	for _, key := range []Key{newRSAKey(t, "rsa-1"), ecKey, newEd25519Key(t, "ed-1")} {
+		t.Run(key.Algorithm, func(t *testing.T) {
+			keys, err := NewKeySet(key)
+			require.NoError(t, err)
+			manager := NewJWTManagerWithKeySet(keys, testIssuer)
+
+			token, _, err := manager.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+			assert.Equal(t, key.Algorithm, parsed.Header["alg"])
+
+			claims, err := manager.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.UserID)
+
+			refresh, _, err := manager.GenerateMinimalToken("user-1", testEmail, "", "", "", "", "", time.Hour)
+			require.NoError(t, err)
+			_, err = manager.ValidateMinimalToken(refresh)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeySet_RotationWithoutDowntime(t *testing.T) {
+	oldKey := newRSAKey(t, "2026-01")
+	newKey := newEd25519Key(t, "2026-07")
+
+	issuerKeys, err := NewKeySet(oldKey)
+	require.NoError(t, err)
+	issuer := NewJWTManagerWithKeySet(issuerKeys, testIssuer)
+
+	// Validador con solo claves públicas: no puede emitir tokens.
+	validatorKeys, err := NewKeySet()
+	require.NoError(t, err)
+	for _, k := range issuerKeys.PublicKeys() {
+		require.NoError(t, validatorKeys.Add(k))
+	}
+	validator := NewJWTManagerWithKeySet(validatorKeys, testIssuer)
+	_, _, err = validator.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+	require.Error(t, err, "un validador sin clave privada no debe poder firmar")
+
+	oldToken, _, err := issuer.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+	require.NoError(t, err)
+
+	// 1. Publicar la clave nueva en todos lados.
+	require.NoError(t, issuerKeys.Add(newKey))
+	newPublic, err := NewVerificationKey(newKey.ID, newKey.PublicKey())
+	require.NoError(t, err)
+	require.NoError(t, validatorKeys.Add(newPublic))
+
+	// 2. Cambiar la clave de firma: los tokens viejos siguen validando.
+	require.NoError(t, issuerKeys.SetSigningKey(newKey.ID))
+	newToken, _, err := issuer.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+	require.NoError(t, err)
+
+	_, err = validator.ValidateToken(oldToken)
+	require.NoError(t, err)
+	_, err = validator.ValidateToken(newToken)
+	require.NoError(t, err)
+
+	// 3. Retirar la vieja: sus tokens dejan de ser válidos.
+	validatorKeys.Remove(oldKey.ID)
+	_, err = validator.ValidateToken(oldToken)
+	require.Error(t, err)
+	_, err = validator.ValidateToken(newToken)
+	require.NoError(t, err)
+}
+
+func TestKeySet_SigningKeyManagement(t *testing.T) {
+	signer := newRSAKey(t, "a")
+	public, err := NewVerificationKey("b", newRSAKey(t, "tmp").PublicKey())
+	require.NoError(t, err)
+
+	keys, err := NewKeySet(public, signer)
+	require.NoError(t, err)
+	_, ok := keys.SigningKey()
+	assert.False(t, ok, "una clave pública primero no queda como clave de firma")
+
+	assert.ErrorIs(t, keys.SetSigningKey("b"), ErrNoSigningKey)
+	assert.ErrorIs(t, keys.SetSigningKey("zz"), ErrUnknownKeyID)
+	require.NoError(t, keys.SetSigningKey("a"))
+	assert.ErrorIs(t, keys.Add(signer), ErrDuplicateKeyID)
+
+	keys.Remove("a")
+	_, ok = keys.SigningKey()
+	assert.False(t, ok)
+}
+
+func TestKeySet_PublicKeysExcludeSecrets(t *testing.T) {
+	hmacKey, err := NewHMACKey("", []byte(testSecretKey))
+	require.NoError(t, err)
+	keys, err := NewKeySet(newRSAKey(t, "rsa"), hmacKey)
+	require.NoError(t, err)
+
+	published := keys.PublicKeys()
+	require.Len(t, published, 1)
+	assert.Equal(t, "rsa", published[0].ID)
+	assert.False(t, published[0].CanSign())
+	assert.IsType(t, &rsa.PublicKey{}, published[0].PublicKey())
+}
+
+func TestJWTManagerWithKeySet_MigratesLegacyHS256(t *testing.T) {
+	legacy := NewJWTManager(testSecretKey, testIssuer)
+	legacyToken, _, err := legacy.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+	require.NoError(t, err)
+
+	hmacKey, err := NewHMACKey("", []byte(testSecretKey))
+	require.NoError(t, err)
+	keys, err := NewKeySet(newRSAKey(t, "rsa-1"), hmacKey)
+	require.NoError(t, err)
+	manager := NewJWTManagerWithKeySet(keys, testIssuer)
+
+	_, err = manager.ValidateToken(legacyToken)
+	require.NoError(t, err, "los tokens HS256 sin kid se validan con la clave HMAC de kid vacío")
+
+	keys.Remove("")
+	_, err = manager.ValidateToken(legacyToken)
+	require.Error(t, err)
+}
+
+func TestJWTManagerWithKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	keys, err := NewKeySet(rsaKey)
+	require.NoError(t, err)
+	manager := NewJWTManagerWithKeySet(keys, testIssuer)
+
+	// Token HS256 firmado usando la clave pública RSA (publicada) como secreto.
+	der, err := x509.MarshalPKIXPublicKey(rsaKey.PublicKey())
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	claims := Claims{
+		UserID: "attacker", Email: testEmail, ActiveContext: testActiveContext(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "rsa-1"
+	token, err := forged.SignedString(pubPEM)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateToken(token)
+	require.Error(t, err)
+}
+
+func TestJWTManagerWithKeySet_KeepsExpiryRules(t *testing.T) {
+	key := newEd25519Key(t, "ed-1")
+	keys, err := NewKeySet(key)
+	require.NoError(t, err)
+	manager := NewJWTManagerWithKeySet(keys, testIssuer)
+
+	sign := func(exp *jwt.NumericDate) string {
+		claims := Claims{
+			UserID: "user-1", Email: testEmail, ActiveContext: testActiveContext(),
+			RegisteredClaims: jwt.RegisteredClaims{Issuer: testIssuer, ExpiresAt: exp},
+		}
+		token, err := keys.sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+
+	_, err = manager.ValidateToken(sign(nil))
+	require.Error(t, err, "exp es obligatorio")
+
+	_, err = manager.ValidateToken(sign(jwt.NewNumericDate(time.Now().Add(-10 * time.Second))))
+	require.NoError(t, err, "dentro del leeway de 30s")
+
+	_, err = manager.ValidateToken(sign(jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "token expired")
+}
+
+func TestJWTManagerWithNilKeys(t *testing.T) {
+	var keys *KeySet
+	legacy := NewJWTManager("", testIssuer)
+	token, _, err := legacy.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+	require.NoError(t, err)
+
+	for name, manager := range map[string]*JWTManager{
+		"KeySet nil":   NewJWTManagerWithKeySet(keys, testIssuer),
+		"resolver nil": NewJWTManagerWithResolver(nil, testIssuer),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := manager.GenerateTokenWithContext("user-1", testEmail, testActiveContext(), time.Hour)
+			assert.ErrorIs(t, err, ErrNoKeys)
+			// No cae en HS256 con un secret vacío.
+			_, err = manager.ValidateToken(token)
+			assert.Error(t, err)
+		})
+	}
+
+	service := NewServiceJWTManagerWithKeySet(keys, "edugo-identity", "edugo-api-platform")
+	_, _, err = service.GenerateServiceToken("worker", nil, 15*time.Minute)
+	assert.ErrorIs(t, err, ErrNoKeys)
+	_, err = service.ValidateServiceToken(token)
+	assert.Error(t, err)
+}
+
+func TestServiceJWTManagerWithKeySet(t *testing.T) {
+	key := newRSAKey(t, "svc-1")
+	issuerKeys, err := NewKeySet(key)
+	require.NoError(t, err)
+	validatorKeys, err := NewKeySet(issuerKeys.PublicKeys()...)
+	require.NoError(t, err)
+
+	issuer := NewServiceJWTManagerWithKeySet(issuerKeys, "edugo-identity", "edugo-api-platform")
+	validator := NewServiceJWTManagerWithKeySet(validatorKeys, "edugo-identity", "edugo-api-platform")
+
+	token, _, err := issuer.GenerateServiceToken("worker", []string{"notifications.dispatch"}, 15*time.Minute)
+	require.NoError(t, err)
+
+	claims, err := validator.ValidateServiceToken(token)
+	require.NoError(t, err)
+	assert.True(t, claims.HasScope("notifications.dispatch"))
+
+	_, _, err = validator.GenerateServiceToken("worker", nil, 15*time.Minute)
+	require.Error(t, err)
+
+	// Un HS256 con el secret de servicio no pasa por un validador asimétrico.
+	hs := NewServiceJWTManager(testSecretKey, "edugo-identity", "edugo-api-platform")
+	hsToken, _, err := hs.GenerateServiceToken("worker", nil, 15*time.Minute)
+	require.NoError(t, err)
+	_, err = validator.ValidateServiceToken(hsToken)
+	require.Error(t, err)
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	signer, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}))
+	require.NoError(t, err)
+	assert.True(t, priv.Equal(signer))
+
+	sec1, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+	signer, err = ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+	require.NoError(t, err)
+	assert.True(t, priv.Equal(signer))
+
+	pkix, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	public, err := ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+	require.NoError(t, err)
+	assert.True(t, priv.PublicKey.Equal(public))
+
+	_, err = ParsePrivateKeyPEM([]byte("no es pem"))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+	_, err = ParsePublicKeyPEM([]byte("no es pem"))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
@@ -0,0 +1,411 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+)
+
+// Defaults de LoginThrottleConfig.
+const (
+	DefaultLoginWindow              = 15 * time.Minute
+	DefaultLoginAccountFreeAttempts = 3
+	DefaultLoginIPFreeAttempts      = 10
+	DefaultLoginBaseDelay           = time.Second
+	DefaultLoginMaxDelay            = 30 * time.Second
+	DefaultLoginAccountLockout      = 10
+	DefaultLoginIPLockout           = 50
+	DefaultLoginLockoutDuration     = 15 * time.Minute
+)
+
+// Motivos de rechazo de LoginDecision.
+const (
+	LoginReasonThrottled     = "throttled"
+	LoginReasonAccountLocked = "account_locked"
+	LoginReasonIPLocked      = "ip_locked"
+)
+
+// AuditActionLoginLockout es la acción de los eventos de auditoría que
+// emite LoginThrottle al bloquear una cuenta o una IP.
+const AuditActionLoginLockout = "auth.login.lockout"
+
+// Resource de RateLimitRecorder para los rechazos de LoginThrottle.
+const loginRateLimitResource = "login"
+
+// Prefijos de las claves de LoginAttemptStore.
+const (
+	loginKeyAccount = "account:"
+	loginKeyIP      = "ip:"
+)
+
+// LoginAttemptStore guarda los fallos de login por clave (cuenta o IP) en
+// una ventana deslizante y los bloqueos temporales. Usa solo tipos básicos
+// para que los backends (p. ej. cache/redis.LoginAttemptStore) no dependan
+// de este paquete.
+type LoginAttemptStore interface {
+	// RecordFailure registra un fallo en `at` y retorna cuántos hay en
+	// (at-window, at], incluido este.
+	RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (int, error)
+	// Failures retorna cuántos fallos hay en (at-window, at] y el último.
+	Failures(ctx context.Context, key string, at time.Time, window time.Duration) (count int, last time.Time, err error)
+	// Reset borra los fallos de key.
+	Reset(ctx context.Context, key string) error
+	// Lock bloquea key hasta `until`; un `until` pasado levanta el bloqueo.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil retorna el fin del bloqueo vigente en `at`, o el instante
+	// cero si no hay.
+	LockedUntil(ctx context.Context, key string, at time.Time) (time.Time, error)
+}
+
+// RateLimitRecorder recibe los rechazos de LoginThrottle. Lo implementa
+// metrics.Metrics.
+type RateLimitRecorder interface {
+	RecordRateLimitHit(resource string)
+}
+
+// LoginThrottleConfig configura un LoginThrottle. Los umbrales cuentan fallos
+// dentro de Window.
+type LoginThrottleConfig struct {
+	// Window es la ventana deslizante de fallos (default 15 min).
+	Window time.Duration
+	// AccountFreeAttempts / IPFreeAttempts son los fallos permitidos sin
+	// demora (default 3 por cuenta y 10 por IP). Desde ahí cada fallo exige
+	// esperar BaseDelay, duplicándolo por fallo extra hasta MaxDelay.
+	AccountFreeAttempts int
+	IPFreeAttempts      int
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	// AccountLockout / IPLockout son los fallos que bloquean la cuenta o la
+	// IP por LockoutDuration (default 10, 50 y 15 min).
+	AccountLockout  int
+	IPLockout       int
+	LockoutDuration time.Duration
+	// Audit recibe un audit.AuditEvent (CategoryAuth, SeverityWarning) por
+	// cada bloqueo (opcional).
+	Audit audit.AuditLogger
+	// ServiceName se copia a los eventos de auditoría.
+	ServiceName string
+	// Metrics registra cada rechazo con resource "login" (opcional).
+	Metrics RateLimitRecorder
+	// OnError recibe los errores de auditoría, que no cambian la decisión
+	// (opcional).
+	OnError func(err error)
+}
+
+// LoginDecision es el resultado de LoginThrottle para un intento.
+type LoginDecision struct {
+	Allowed bool
+	// Reason es LoginReason* cuando Allowed es false.
+	Reason string
+	// RetryAfter es cuánto falta para poder reintentar (header Retry-After).
+	RetryAfter time.Duration
+	// LockedUntil es el fin del bloqueo cuando Reason es *_locked.
+	LockedUntil time.Time
+}
+
+// LoginThrottle protege la verificación de credenciales contra fuerza
+// bruta, por cuenta y por IP: tras unos fallos exige una espera creciente
+// entre intentos y, pasado un umbral, bloquea temporalmente. Los bloqueos
+// vencen solos. La espera no se implementa durmiendo: el intento temprano
+// se rechaza con RetryAfter.
+//
+// Uso: Check antes de verificar la password; RecordFailure o RecordSuccess
+// según el resultado.
+type LoginThrottle struct {
+	store LoginAttemptStore
+	cfg   LoginThrottleConfig
+	now   func() time.Time // reemplazable en tests
+}
+
+// NewLoginThrottle crea un LoginThrottle.
+func NewLoginThrottle(store LoginAttemptStore, cfg LoginThrottleConfig) *LoginThrottle {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultLoginWindow
+	}
+	if cfg.AccountFreeAttempts <= 0 {
+		cfg.AccountFreeAttempts = DefaultLoginAccountFreeAttempts
+	}
+	if cfg.IPFreeAttempts <= 0 {
+		cfg.IPFreeAttempts = DefaultLoginIPFreeAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultLoginBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultLoginMaxDelay
+	}
+	if cfg.AccountLockout <= 0 {
+		cfg.AccountLockout = DefaultLoginAccountLockout
+	}
+	if cfg.IPLockout <= 0 {
+		cfg.IPLockout = DefaultLoginIPLockout
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = DefaultLoginLockoutDuration
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return &LoginThrottle{store: store, cfg: cfg, now: time.Now}
+}
+
+// Check decide si se permite un intento de login de account desde ip.
+// account se normaliza (minúsculas, sin espacios); ip puede ir vacía.
+func (t *LoginThrottle) Check(ctx context.Context, account, ip string) (LoginDecision, error) {
+	d, err := t.decide(ctx, account, ip, t.now())
+	if err == nil && !d.Allowed && t.cfg.Metrics != nil {
+		t.cfg.Metrics.RecordRateLimitHit(loginRateLimitResource)
+	}
+	return d, err
+}
+
+// RecordFailure registra credenciales inválidas, bloquea la cuenta o la IP
+// si alcanzan su umbral y retorna la decisión para el próximo intento.
+func (t *LoginThrottle) RecordFailure(ctx context.Context, account, ip string) (LoginDecision, error) {
+	now := t.now()
+	for _, k := range t.keys(account, ip) {
+		count, err := t.store.RecordFailure(ctx, k.key, now, t.cfg.Window)
+		if err != nil {
+			return LoginDecision{}, fmt.Errorf("auth: registrando intento de login: %w", err)
+		}
+		if count < k.lockout {
+			continue
+		}
+		until := now.Add(t.cfg.LockoutDuration)
+		if err := t.store.Lock(ctx, k.key, until); err != nil {
+			return LoginDecision{}, fmt.Errorf("auth: bloqueando login: %w", err)
+		}
+		t.auditLockout(ctx, k, ip, count, now, until)
+	}
+	return t.decide(ctx, account, ip, now)
+}
+
+// RecordSuccess borra los fallos de la cuenta tras un login válido. Los de
+// la IP se conservan: un login válido no legitima los intentos contra otras
+// cuentas desde la misma IP.
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, account string) error {
+	if err := t.store.Reset(ctx, loginKeyAccount+normalizeLoginAccount(account)); err != nil {
+		return fmt.Errorf("auth: reiniciando intentos de login: %w", err)
+	}
+	return nil
+}
+
+// Unlock levanta el bloqueo y los fallos de una cuenta (acción de soporte).
+func (t *LoginThrottle) Unlock(ctx context.Context, account string) error {
+	key := loginKeyAccount + normalizeLoginAccount(account)
+	if err := t.store.Lock(ctx, key, time.Time{}); err != nil {
+		return fmt.Errorf("auth: desbloqueando login: %w", err)
+	}
+	if err := t.store.Reset(ctx, key); err != nil {
+		return fmt.Errorf("auth: desbloqueando login: %w", err)
+	}
+	return nil
+}
+
+type loginKey struct {
+	key     string
+	scope   string // "account" o "ip"
+	id      string
+	free    int
+	lockout int
+	reason  string
+}
+
+func (t *LoginThrottle) keys(account, ip string) []loginKey {
+	account = normalizeLoginAccount(account)
+	keys := []loginKey{{
+		key: loginKeyAccount + account, scope: "account", id: account,
+		free: t.cfg.AccountFreeAttempts, lockout: t.cfg.AccountLockout, reason: LoginReasonAccountLocked,
+	}}
+	if ip != "" {
+		keys = append(keys, loginKey{
+			key: loginKeyIP + ip, scope: "ip", id: ip,
+			free: t.cfg.IPFreeAttempts, lockout: t.cfg.IPLockout, reason: LoginReasonIPLocked,
+		})
+	}
+	return keys
+}
+
+// decide aplica, por cada clave, el bloqueo vigente y luego la espera
+// progresiva desde el último fallo. Gana la restricción más larga.
+func (t *LoginThrottle) decide(ctx context.Context, account, ip string, now time.Time) (LoginDecision, error) {
+	decision := LoginDecision{Allowed: true}
+	for _, k := range t.keys(account, ip) {
+		until, err := t.store.LockedUntil(ctx, k.key, now)
+		if err != nil {
+			return LoginDecision{}, fmt.Errorf("auth: consultando bloqueo de login: %w", err)
+		}
+		if until.After(now) {
+			if wait := until.Sub(now); wait > decision.RetryAfter {
+				decision = LoginDecision{Reason: k.reason, RetryAfter: wait, LockedUntil: until}
+			}
+			continue
+		}
+
+		count, last, err := t.store.Failures(ctx, k.key, now, t.cfg.Window)
+		if err != nil {
+			return LoginDecision{}, fmt.Errorf("auth: consultando intentos de login: %w", err)
+		}
+		wait := last.Add(t.delay(count, k.free)).Sub(now)
+		if wait > 0 && wait > decision.RetryAfter {
+			decision = LoginDecision{Reason: LoginReasonThrottled, RetryAfter: wait}
+		}
+	}
+	return decision, nil
+}
+
+// delay es la espera exigida tras count fallos: cero hasta free, luego
+// BaseDelay duplicado por cada fallo extra, con tope MaxDelay.
+func (t *LoginThrottle) delay(count, free int) time.Duration {
+	if count < free {
+		return 0
+	}
+	d := t.cfg.BaseDelay
+	for i := free; i < count && d < t.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, t.cfg.MaxDelay)
+}
+
+func (t *LoginThrottle) auditLockout(ctx context.Context, k loginKey, ip string, failures int, now, until time.Time) {
+	if t.cfg.Metrics != nil {
+		t.cfg.Metrics.RecordRateLimitHit(loginRateLimitResource)
+	}
+	if t.cfg.Audit == nil {
+		return
+	}
+	event := audit.AuditEvent{
+		ActorIP:      ip,
+		ServiceName:  t.cfg.ServiceName,
+		Action:       AuditActionLoginLockout,
+		ResourceType: k.scope,
+		ResourceID:   k.id,
+		Metadata: map[string]any{
+			"scope":        k.scope,
+			"failures":     failures,
+			"window":       t.cfg.Window.String(),
+			"locked_until": until.UTC().Format(time.RFC3339),
+		},
+		Severity:   audit.SeverityWarning,
+		Category:   audit.CategoryAuth,
+		OccurredAt: now,
+	}
+	if err := t.cfg.Audit.Log(ctx, event); err != nil {
+		t.cfg.OnError(fmt.Errorf("auth: auditando bloqueo de login: %w", err))
+	}
+}
+
+func normalizeLoginAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// maxLoginAttemptKeys dispara la limpieza de claves vencidas de
+// InMemoryLoginAttemptStore.
+const maxLoginAttemptKeys = 10000
+
+// InMemoryLoginAttemptStore implementa LoginAttemptStore en memoria, para
+// tests y despliegues de una sola instancia. Es seguro para uso concurrente.
+type InMemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+	locks    map[string]time.Time
+	window   time.Duration // mayor ventana vista, para la limpieza
+}
+
+// NewInMemoryLoginAttemptStore crea un InMemoryLoginAttemptStore.
+func NewInMemoryLoginAttemptStore() *InMemoryLoginAttemptStore {
+	return &InMemoryLoginAttemptStore{
+		failures: make(map[string][]time.Time),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+// RecordFailure implementa LoginAttemptStore.
+func (s *InMemoryLoginAttemptStore) RecordFailure(_ context.Context, key string, at time.Time, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.window = max(s.window, window)
+	if len(s.failures)+len(s.locks) >= maxLoginAttemptKeys {
+		s.sweep(at)
+	}
+	list := append(pruneLoginFailures(s.failures[key], at, window), at)
+	s.failures[key] = list
+	return len(list), nil
+}
+
+// Failures implementa LoginAttemptStore.
+func (s *InMemoryLoginAttemptStore) Failures(_ context.Context, key string, at time.Time, window time.Duration) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := pruneLoginFailures(s.failures[key], at, window)
+	if len(list) == 0 {
+		delete(s.failures, key)
+		return 0, time.Time{}, nil
+	}
+	s.failures[key] = list
+	return len(list), list[len(list)-1], nil
+}
+
+// Reset implementa LoginAttemptStore.
+func (s *InMemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.failures, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// Lock implementa LoginAttemptStore.
+func (s *InMemoryLoginAttemptStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	s.locks[key] = until
+	s.mu.Unlock()
+	return nil
+}
+
+// LockedUntil implementa LoginAttemptStore.
+func (s *InMemoryLoginAttemptStore) LockedUntil(_ context.Context, key string, at time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.locks[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+	if !until.After(at) {
+		delete(s.locks, key)
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+// sweep borra fallos fuera de ventana y bloqueos vencidos. Requiere s.mu.
+func (s *InMemoryLoginAttemptStore) sweep(at time.Time) {
+	for key, list := range s.failures {
+		if list = pruneLoginFailures(list, at, s.window); len(list) == 0 {
+			delete(s.failures, key)
+		} else {
+			s.failures[key] = list
+		}
+	}
+	for key, until := range s.locks {
+		if !until.After(at) {
+			delete(s.locks, key)
+		}
+	}
+}
+
+// prune descarta los instantes fuera de (at-window, at]. list está ordenada.
+func pruneLoginFailures(list []time.Time, at time.Time, window time.Duration) []time.Time {
+	from := at.Add(-window)
+	i, _ := slices.BinarySearchFunc(list, from, func(t, target time.Time) int {
+		if t.After(target) {
+			return 1
+		}
+		return -1
+	})
+	return slices.Delete(list, 0, i)
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingAuditLogger struct {
+	mu     sync.Mutex
+	events []audit.AuditEvent
+}
+
+func (l *recordingAuditLogger) Log(_ context.Context, e audit.AuditEvent) error {
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+	return nil
+}
+
+type rateLimitCounter struct{ hits map[string]int }
+
+func (c *rateLimitCounter) RecordRateLimitHit(resource string) { c.hits[resource]++ }
+
+func newTestLoginThrottle(clock *fakeClock, cfg LoginThrottleConfig) (*LoginThrottle, *recordingAuditLogger, *rateLimitCounter) {
+	auditLog := &recordingAuditLogger{}
+	metrics := &rateLimitCounter{hits: map[string]int{}}
+	cfg.Audit = auditLog
+	cfg.Metrics = metrics
+	cfg.ServiceName = "edugo-api-identity"
+	th := NewLoginThrottle(NewInMemoryLoginAttemptStore(), cfg)
+	th.now = clock.Now
+	return th, auditLog, metrics
+}
+
+func TestLoginThrottle_ProgressiveDelay(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	th, _, metrics := newTestLoginThrottle(clock, LoginThrottleConfig{
+		AccountFreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second,
+	})
+	ctx := context.Background()
+
+	// Los primeros fallos no exigen espera.
+	for range 1 {
+		d, err := th.RecordFailure(ctx, "Ana@EduGo.com ", "10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	}
+
+	// Desde AccountFreeAttempts: 1s, 2s, 4s, 4s (tope).
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d, err := th.RecordFailure(ctx, "ana@edugo.com", "10.0.0.1")
+		require.NoError(t, err)
+		assert.False(t, d.Allowed)
+		assert.Equal(t, LoginReasonThrottled, d.Reason)
+		assert.Equal(t, want, d.RetryAfter)
+
+		// Antes de la espera se rechaza; pasada, se permite.
+		d, err = th.Check(ctx, "ana@edugo.com", "10.0.0.2")
+		require.NoError(t, err)
+		assert.False(t, d.Allowed)
+		clock.Advance(want)
+		d, err = th.Check(ctx, "ana@edugo.com", "10.0.0.2")
+		require.NoError(t, err)
+		assert.True(t, d.Allowed)
+	}
+	assert.Equal(t, 4, metrics.hits["login"])
+
+	// Un login válido reinicia la cuenta.
+	require.NoError(t, th.RecordSuccess(ctx, "ana@edugo.com"))
+	d, err := th.RecordFailure(ctx, "ana@edugo.com", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+
+	// Los fallos salen de la ventana deslizante.
+	clock.Advance(DefaultLoginWindow)
+	for range 2 {
+		d, err = th.RecordFailure(ctx, "ana@edugo.com", "")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, time.Second, d.RetryAfter, "solo cuentan los 2 fallos dentro de la ventana")
+}
+
+func TestLoginThrottle_AccountLockout(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	th, auditLog, _ := newTestLoginThrottle(clock, LoginThrottleConfig{
+		AccountFreeAttempts: 100, AccountLockout: 3, LockoutDuration: 10 * time.Minute,
+	})
+	ctx := context.Background()
+
+	var d LoginDecision
+	var err error
+	for range 3 {
+		d, err = th.RecordFailure(ctx, "ana@edugo.com", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	assert.False(t, d.Allowed)
+	assert.Equal(t, LoginReasonAccountLocked, d.Reason)
+	assert.Equal(t, clock.Now().Add(10*time.Minute), d.LockedUntil)
+
+	require.Len(t, auditLog.events, 1)
+	e := auditLog.events[0]
+	assert.Equal(t, AuditActionLoginLockout, e.Action)
+	assert.Equal(t, audit.CategoryAuth, e.Category)
+	assert.Equal(t, audit.SeverityWarning, e.Severity)
+	assert.Equal(t, "account", e.ResourceType)
+	assert.Equal(t, "ana@edugo.com", e.ResourceID)
+	assert.Equal(t, "10.0.0.1", e.ActorIP)
+	assert.Equal(t, "edugo-api-identity", e.ServiceName)
+	assert.Equal(t, 3, e.Metadata["failures"])
+
+	// Bloqueada desde cualquier IP, hasta que vence sola.
+	d, err = th.Check(ctx, "ana@edugo.com", "10.0.0.99")
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 10*time.Minute, d.RetryAfter)
+
+	clock.Advance(10 * time.Minute)
+	d, err = th.Check(ctx, "ana@edugo.com", "10.0.0.99")
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+
+	// Unlock (soporte) levanta bloqueo y fallos.
+	for range 3 {
+		_, err = th.RecordFailure(ctx, "luis@edugo.com", "")
+		require.NoError(t, err)
+	}
+	require.NoError(t, th.Unlock(ctx, "luis@edugo.com"))
+	d, err = th.Check(ctx, "luis@edugo.com", "")
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+}
+
+func TestLoginThrottle_IPLockoutAcrossAccounts(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)}
+	th, auditLog, _ := newTestLoginThrottle(clock, LoginThrottleConfig{
+		AccountFreeAttempts: 100, IPFreeAttempts: 100, IPLockout: 5,
+	})
+	ctx := context.Background()
+
+	// Password spraying: un fallo por cuenta, todos desde la misma IP.
+	for _, account := range []string{"a", "b", "c", "d", "e"} {
+		_, err := th.RecordFailure(ctx, account+"@edugo.com", "203.0.113.7")
+		require.NoError(t, err)
+	}
+	d, err := th.Check(ctx, "f@edugo.com", "203.0.113.7")
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, LoginReasonIPLocked, d.Reason)
+
+	// Otra IP sobre la misma cuenta no está afectada.
+	d, err = th.Check(ctx, "f@edugo.com", "198.51.100.1")
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+
+	require.Len(t, auditLog.events, 1)
+	assert.Equal(t, "ip", auditLog.events[0].ResourceType)
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -16,6 +17,7 @@ const maxPasswordLength = 72
 // HashPassword genera un hash seguro del password usando bcrypt
 // El hash incluye un salt aleatorio automáticamente
 // Retorna error si el password excede 72 bytes (límite de bcrypt)
+// Para hashes nuevos se recomienda DefaultPasswordHasher (Argon2id)
 func HashPassword(password string) (string, error) {
 	if len(password) > maxPasswordLength {
 		return "", fmt.Errorf("password exceeds maximum length of %d bytes", maxPasswordLength)
@@ -28,11 +30,24 @@ func HashPassword(password string) (string, error) {
 	return string(bytes), nil
 }
 
-// VerifyPassword verifica si un password coincide con su hash bcrypt
+// VerifyPassword verifica si un password coincide con su hash bcrypt o Argon2id (PHC)
 // Retorna nil si el password es correcto, error en caso contrario
+// Para detectar hashes a migrar usar VerifyPasswordAndRehash
 func VerifyPassword(hashedPassword, password string) error {
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		_, err := verifyArgon2id(hashedPassword, password)
+		return err
+	}
 	return bcrypt.CompareHashAndPassword(
 		[]byte(hashedPassword),
 		[]byte(password),
 	)
 }
+
+// VerifyPasswordAndRehash verifica el password con DefaultPasswordHasher
+// Retorna needsRehash=true si el hash es bcrypt o Argon2id con parámetros
+// más débiles: el caller debe regenerarlo con DefaultPasswordHasher().Hash
+// y guardarlo tras un login exitoso
+func VerifyPasswordAndRehash(hashedPassword, password string) (needsRehash bool, err error) {
+	return DefaultPasswordHasher().Verify(hashedPassword, password)
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // G505: SHA-1 es el formato del corpus (HIBP), no se usa para proteger datos
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// breachedPrefixLength es el largo en hex del prefijo de rango (k-anonimato),
+// igual al de la API de Have I Been Pwned.
+const breachedPrefixLength = 5
+
+// BreachedPasswordSet es un corpus local de passwords filtrados guardado como
+// hashes SHA-1 ordenados (20 bytes por entrada; 1M de passwords ≈ 20 MB). Las
+// búsquedas son binarias y el password nunca sale del proceso. Es inmutable y
+// seguro para uso concurrente.
+type BreachedPasswordSet struct {
+	hashes [][sha1.Size]byte
+}
+
+var _ BreachedPasswordChecker = (*BreachedPasswordSet)(nil)
+
+// NewBreachedPasswordSet crea un corpus a partir de passwords en texto plano.
+// Útil para listas pequeñas embebidas y tests.
+func NewBreachedPasswordSet(passwords ...string) *BreachedPasswordSet {
+	hashes := make([][sha1.Size]byte, 0, len(passwords))
+	for _, p := range passwords {
+		hashes = append(hashes, sha1.Sum([]byte(p))) //nolint:gosec // G401: ver import
+	}
+	return newBreachedPasswordSet(hashes)
+}
+
+// LoadBreachedPasswords lee un corpus en formato HIBP: una línea por
+// password con su SHA-1 en hex (40 caracteres, mayúsculas o minúsculas),
+// opcionalmente seguido de ":<ocurrencias>". Ignora líneas vacías y las que
+// empiezan con '#'. No requiere que el archivo venga ordenado.
+func LoadBreachedPasswords(r io.Reader) (*BreachedPasswordSet, error) {
+	var hashes [][sha1.Size]byte
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		if i := bytes.IndexByte(text, ':'); i >= 0 {
+			text = text[:i]
+		}
+		var h [sha1.Size]byte
+		if len(text) != 2*sha1.Size {
+			return nil, fmt.Errorf("auth: corpus de passwords, línea %d: se esperaba SHA-1 en hex", line)
+		}
+		if _, err := hex.Decode(h[:], text); err != nil {
+			return nil, fmt.Errorf("auth: corpus de passwords, línea %d: %w", line, err)
+		}
+		hashes = append(hashes, h)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("auth: leyendo corpus de passwords: %w", err)
+	}
+	return newBreachedPasswordSet(hashes), nil
+}
+
+// LoadBreachedPasswordsFile carga el corpus desde un archivo local (ver
+// LoadBreachedPasswords).
+func LoadBreachedPasswordsFile(path string) (*BreachedPasswordSet, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: la ruta viene de la configuración del servicio
+	if err != nil {
+		return nil, fmt.Errorf("auth: abriendo corpus de passwords: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // solo lectura
+	return LoadBreachedPasswords(f)
+}
+
+func newBreachedPasswordSet(hashes [][sha1.Size]byte) *BreachedPasswordSet {
+	slices.SortFunc(hashes, func(a, b [sha1.Size]byte) int { return bytes.Compare(a[:], b[:]) })
+	hashes = slices.CompactFunc(hashes, func(a, b [sha1.Size]byte) bool { return a == b })
+	return &BreachedPasswordSet{hashes: slices.Clip(hashes)}
+}
+
+// Len retorna la cantidad de hashes distintos del corpus.
+func (s *BreachedPasswordSet) Len() int {
+	return len(s.hashes)
+}
+
+// IsBreached reporta si el password aparece en el corpus.
+func (s *BreachedPasswordSet) IsBreached(password string) bool {
+	return s.ContainsHash(sha1.Sum([]byte(password))) //nolint:gosec // G401: ver import
+}
+
+// ContainsHash reporta si el SHA-1 aparece en el corpus.
+func (s *BreachedPasswordSet) ContainsHash(hash [sha1.Size]byte) bool {
+	_, found := slices.BinarySearchFunc(s.hashes, hash, func(e, t [sha1.Size]byte) int {
+		return bytes.Compare(e[:], t[:])
+	})
+	return found
+}
+
+// Range retorna los sufijos (35 caracteres hex en mayúsculas) de los hashes
+// que empiezan con el prefijo de 5 caracteres hex indicado, como la API de
+// rangos de HIBP. Permite que un cliente consulte por k-anonimato enviando
+// solo el prefijo del SHA-1 de su password. Retorna nil si el prefijo es
+// inválido.
+func (s *BreachedPasswordSet) Range(prefix string) []string {
+	prefix = strings.ToUpper(prefix)
+	if len(prefix) != breachedPrefixLength {
+		return nil
+	}
+	// El prefijo de 20 bits se completa con 0 para el inicio del rango.
+	var start [sha1.Size]byte
+	if _, err := hex.Decode(start[:3], []byte(prefix+"0")); err != nil {
+		return nil
+	}
+	i, _ := slices.BinarySearchFunc(s.hashes, start, func(e, t [sha1.Size]byte) int {
+		return bytes.Compare(e[:], t[:])
+	})
+	var out []string
+	for ; i < len(s.hashes); i++ {
+		full := strings.ToUpper(hex.EncodeToString(s.hashes[i][:]))
+		if !strings.HasPrefix(full, prefix) {
+			break
+		}
+		out = append(out, full[breachedPrefixLength:])
+	}
+	return out
+}
@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/EduGoGroup/edugo-shared/common/errors"
+)
+
+// maxArgon2PasswordLength acota el input de Argon2id. No es un límite del
+// algoritmo: evita que un password enorme se use para consumir CPU.
+const maxArgon2PasswordLength = 1024
+
+const argon2idPrefix = "$argon2id$"
+
+var (
+	// ErrPasswordMismatch indica que el password no coincide con el hash.
+	ErrPasswordMismatch = stdErrors.New("auth: el password no coincide")
+	// ErrUnsupportedPasswordHash indica un hash con formato o algoritmo desconocido.
+	ErrUnsupportedPasswordHash = stdErrors.New("auth: formato de hash de password no soportado")
+)
+
+// PasswordHasher genera y verifica hashes de password.
+type PasswordHasher interface {
+	// Hash genera el hash codificado (incluye algoritmo, parámetros y salt).
+	Hash(password string) (string, error)
+	// Verify compara el password con el hash. Retorna ErrPasswordMismatch si
+	// no coincide. needsRehash es true cuando el password es correcto pero el
+	// hash usa otro algoritmo o parámetros más débiles que los actuales: el
+	// llamador debe re-hashear y guardar el nuevo hash.
+	Verify(encodedHash, password string) (needsRehash bool, err error)
+}
+
+// Argon2idParams son los parámetros de Argon2id.
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32 // bytes
+	KeyLength   uint32 // bytes
+}
+
+// DefaultArgon2idParams sigue la segunda recomendación de RFC 9106
+// (64 MiB, t=3, p=4), ~50-100ms por hash en hardware de servidor.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func (p Argon2idParams) validate() error {
+	switch {
+	case p.Memory < 8*uint32(p.Parallelism):
+		return errors.NewValidationError("argon2id: memory debe ser al menos 8 KiB por hilo")
+	case p.Iterations == 0:
+		return errors.NewValidationError("argon2id: iterations debe ser mayor a 0")
+	case p.Parallelism == 0:
+		return errors.NewValidationError("argon2id: parallelism debe ser mayor a 0")
+	case p.SaltLength < 8:
+		return errors.NewValidationError("argon2id: salt debe tener al menos 8 bytes")
+	case p.KeyLength < 16:
+		return errors.NewValidationError("argon2id: key debe tener al menos 16 bytes")
+	}
+	return nil
+}
+
+// weakerThan reporta si p es más débil que target en algún parámetro.
+func (p Argon2idParams) weakerThan(target Argon2idParams) bool {
+	return p.Memory < target.Memory ||
+		p.Iterations < target.Iterations ||
+		p.Parallelism < target.Parallelism ||
+		p.SaltLength < target.SaltLength ||
+		p.KeyLength < target.KeyLength
+}
+
+// Argon2idHasher implementa PasswordHasher con Argon2id y hashes en formato
+// PHC ($argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>). También verifica
+// hashes bcrypt heredados de HashPassword, reportándolos con needsRehash para
+// migrarlos en el siguiente login sin forzar un reset de password.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+var _ PasswordHasher = (*Argon2idHasher)(nil)
+
+// NewArgon2idHasher crea un Argon2idHasher con los parámetros indicados.
+func NewArgon2idHasher(params Argon2idParams) (*Argon2idHasher, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	return &Argon2idHasher{params: params}, nil
+}
+
+// DefaultPasswordHasher retorna el hasher recomendado: Argon2id con
+// DefaultArgon2idParams.
+func DefaultPasswordHasher() *Argon2idHasher {
+	return &Argon2idHasher{params: DefaultArgon2idParams}
+}
+
+// Hash genera un hash Argon2id en formato PHC con salt aleatorio.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	if len(password) > maxArgon2PasswordLength {
+		return "", fmt.Errorf("password exceeds maximum length of %d bytes", maxArgon2PasswordLength)
+	}
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("auth: generando salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return encodeArgon2id(h.params, salt, key), nil
+}
+
+// Verify verifica hashes Argon2id (PHC) y bcrypt. Un hash bcrypt correcto, o
+// uno Argon2id con parámetros más débiles que los del hasher, retorna
+// needsRehash=true.
+func (h *Argon2idHasher) Verify(encodedHash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encodedHash, argon2idPrefix):
+		params, err := verifyArgon2id(encodedHash, password)
+		if err != nil {
+			return false, err
+		}
+		return params.weakerThan(h.params), nil
+	case isBcryptHash(encodedHash):
+		if err := verifyBcrypt(encodedHash, password); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		return false, ErrUnsupportedPasswordHash
+	}
+}
+
+// BcryptHasher implementa PasswordHasher con bcrypt, para servicios que aún
+// no migran a Argon2id. Reporta needsRehash si el costo del hash es menor al
+// configurado.
+type BcryptHasher struct {
+	cost int
+}
+
+var _ PasswordHasher = (*BcryptHasher)(nil)
+
+// NewBcryptHasher crea un BcryptHasher. Un costo fuera de rango usa el
+// costo por defecto del módulo (12).
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcryptCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+// Hash genera un hash bcrypt. Retorna error si el password excede 72 bytes.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > maxPasswordLength {
+		return "", fmt.Errorf("password exceeds maximum length of %d bytes", maxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify verifica un hash bcrypt.
+func (h *BcryptHasher) Verify(encodedHash, password string) (bool, error) {
+	if !isBcryptHash(encodedHash) {
+		return false, ErrUnsupportedPasswordHash
+	}
+	if err := verifyBcrypt(encodedHash, password); err != nil {
+		return false, err
+	}
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrUnsupportedPasswordHash, err)
+	}
+	return cost < h.cost, nil
+}
+
+func isBcryptHash(encodedHash string) bool {
+	return strings.HasPrefix(encodedHash, "$2a$") ||
+		strings.HasPrefix(encodedHash, "$2b$") ||
+		strings.HasPrefix(encodedHash, "$2y$")
+}
+
+func verifyBcrypt(encodedHash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+	switch {
+	case err == nil:
+		return nil
+	case stdErrors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrPasswordMismatch
+	default:
+		return fmt.Errorf("%w: %w", ErrUnsupportedPasswordHash, err)
+	}
+}
+
+func encodeArgon2id(p Argon2idParams, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+// verifyArgon2id compara el password con un hash PHC y retorna los
+// parámetros con que fue generado.
+func verifyArgon2id(encodedHash, password string) (Argon2idParams, error) {
+	params, salt, key, err := decodeArgon2id(encodedHash)
+	if err != nil {
+		return Argon2idParams{}, err
+	}
+	if len(password) > maxArgon2PasswordLength {
+		return Argon2idParams{}, ErrPasswordMismatch
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, computed) != 1 {
+		return Argon2idParams{}, ErrPasswordMismatch
+	}
+	return params, nil
+}
+
+func decodeArgon2id(encodedHash string) (Argon2idParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	var p Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	p.SaltLength = uint32(len(salt)) //nolint:gosec // G115: acotado por el largo del hash codificado
+	p.KeyLength = uint32(len(key))   //nolint:gosec // G115: acotado por el largo del hash codificado
+	// Parámetros absurdos (p. ej. m=4000000000) en un hash manipulado no
+	// deben disparar un cálculo costoso.
+	if err := p.validate(); err != nil || p.Memory > 4*1024*1024 || p.Iterations > 64 {
+		return Argon2idParams{}, nil, nil, ErrUnsupportedPasswordHash
+	}
+	return p, salt, key, nil
+}
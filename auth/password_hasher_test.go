@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams son parámetros baratos para que los tests sean rápidos.
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestArgon2idHasher(t *testing.T, params Argon2idParams) *Argon2idHasher {
+	t.Helper()
+	h, err := NewArgon2idHasher(params)
+	if err != nil {
+		t.Fatalf("NewArgon2idHasher: %v", err)
+	}
+	return h
+}
+
+func TestArgon2idHasher_HashAndVerify(t *testing.T) {
+	h := newTestArgon2idHasher(t, testArgon2idParams)
+
+	hash, err := h.Hash("miPasswordSeguro123")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("hash debe estar en formato PHC, obtuvo %q", hash)
+	}
+
+	needsRehash, err := h.Verify(hash, "miPasswordSeguro123")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if needsRehash {
+		t.Error("un hash con los parámetros actuales no necesita rehash")
+	}
+
+	if _, err := h.Verify(hash, "otroPassword"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("esperaba ErrPasswordMismatch, obtuvo %v", err)
+	}
+
+	other, err := h.Hash("miPasswordSeguro123")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if other == hash {
+		t.Error("dos hashes del mismo password deben diferir por el salt")
+	}
+}
+
+func TestArgon2idHasher_LongPassword(t *testing.T) {
+	h := newTestArgon2idHasher(t, testArgon2idParams)
+
+	// Argon2id no tiene el límite de 72 bytes de bcrypt.
+	long := strings.Repeat("a", 200)
+	hash, err := h.Hash(long)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if _, err := h.Verify(hash, long); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if _, err := h.Verify(hash, strings.Repeat("a", 199)); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("esperaba ErrPasswordMismatch, obtuvo %v", err)
+	}
+
+	if _, err := h.Hash(strings.Repeat("a", maxArgon2PasswordLength+1)); err == nil {
+		t.Error("Hash debe rechazar passwords sobre el límite")
+	}
+}
+
+func TestArgon2idHasher_RehashWeakerParams(t *testing.T) {
+	weak := newTestArgon2idHasher(t, testArgon2idParams)
+	hash, err := weak.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	h := newTestArgon2idHasher(t, stronger)
+
+	needsRehash, err := h.Verify(hash, "password")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !needsRehash {
+		t.Error("un hash con menos iteraciones debe pedir rehash")
+	}
+}
+
+func TestArgon2idHasher_MigratesBcrypt(t *testing.T) {
+	legacy, err := HashPassword("passwordHeredado")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	h := newTestArgon2idHasher(t, testArgon2idParams)
+
+	needsRehash, err := h.Verify(legacy, "passwordHeredado")
+	if err != nil {
+		t.Fatalf("Verify bcrypt: %v", err)
+	}
+	if !needsRehash {
+		t.Fatal("un hash bcrypt debe pedir rehash")
+	}
+	if _, err := h.Verify(legacy, "incorrecto"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("esperaba ErrPasswordMismatch, obtuvo %v", err)
+	}
+
+	// Flujo de login: re-hashear y guardar.
+	upgraded, err := h.Hash("passwordHeredado")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	needsRehash, err = h.Verify(upgraded, "passwordHeredado")
+	if err != nil || needsRehash {
+		t.Errorf("el hash migrado debe verificar sin rehash: needsRehash=%v err=%v", needsRehash, err)
+	}
+
+	// VerifyPassword acepta ambos formatos.
+	if err := VerifyPassword(upgraded, "passwordHeredado"); err != nil {
+		t.Errorf("VerifyPassword con Argon2id: %v", err)
+	}
+	if err := VerifyPassword(upgraded, "incorrecto"); err == nil {
+		t.Error("VerifyPassword debe fallar con password incorrecto")
+	}
+}
+
+func TestArgon2idHasher_InvalidHashes(t *testing.T) {
+	h := newTestArgon2idHasher(t, testArgon2idParams)
+	valid, err := h.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	parts := strings.Split(valid, "$")
+
+	cases := map[string]string{
+		"vacío":                "",
+		"texto plano":          "password",
+		"scrypt":               "$scrypt$ln=16,r=8,p=1$c2FsdA$aGFzaA",
+		"pocas partes":         "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"versión":              strings.Replace(valid, "v=19", "v=16", 1),
+		"parámetros":           strings.Replace(valid, "m=1024,t=1,p=1", "m=x,t=1,p=1", 1),
+		"memoria abusiva":      strings.Replace(valid, "m=1024", "m=4000000000", 1),
+		"salt no base64":       strings.Join([]string{"", parts[1], parts[2], parts[3], "!!!", parts[5]}, "$"),
+		"hash no base64":       strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "!!!"}, "$"),
+		"hash demasiado corto": strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "c2hvcnQ"}, "$"),
+	}
+	for name, hash := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := h.Verify(hash, "password"); !errors.Is(err, ErrUnsupportedPasswordHash) {
+				t.Errorf("esperaba ErrUnsupportedPasswordHash, obtuvo %v", err)
+			}
+		})
+	}
+}
+
+func TestNewArgon2idHasher_InvalidParams(t *testing.T) {
+	cases := map[string]func(p *Argon2idParams){
+		"memoria":     func(p *Argon2idParams) { p.Memory = 4 },
+		"iteraciones": func(p *Argon2idParams) { p.Iterations = 0 },
+		"paralelismo": func(p *Argon2idParams) { p.Parallelism = 0 },
+		"salt":        func(p *Argon2idParams) { p.SaltLength = 4 },
+		"key":         func(p *Argon2idParams) { p.KeyLength = 8 },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			params := testArgon2idParams
+			mutate(&params)
+			if _, err := NewArgon2idHasher(params); err == nil {
+				t.Error("esperaba error de validación")
+			}
+		})
+	}
+}
+
+func TestDefaultPasswordHasher(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Argon2id con parámetros por defecto usa 64 MiB")
+	}
+	h := DefaultPasswordHasher()
+	hash, err := h.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=4$") {
+		t.Fatalf("parámetros por defecto inesperados: %q", hash)
+	}
+	needsRehash, err := h.Verify(hash, "password")
+	if err != nil || needsRehash {
+		t.Errorf("needsRehash=%v err=%v", needsRehash, err)
+	}
+}
+
+func TestBcryptHasher(t *testing.T) {
+	h := NewBcryptHasher(bcrypt.MinCost + 1)
+
+	hash, err := h.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	needsRehash, err := h.Verify(hash, "password")
+	if err != nil || needsRehash {
+		t.Errorf("needsRehash=%v err=%v", needsRehash, err)
+	}
+	if _, err := h.Verify(hash, "otro"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("esperaba ErrPasswordMismatch, obtuvo %v", err)
+	}
+
+	// Un costo menor al configurado pide rehash.
+	weak, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	needsRehash, err = h.Verify(string(weak), "password")
+	if err != nil || !needsRehash {
+		t.Errorf("needsRehash=%v err=%v", needsRehash, err)
+	}
+
+	if _, err := h.Hash(strings.Repeat("a", 73)); err == nil {
+		t.Error("bcrypt debe rechazar passwords de más de 72 bytes")
+	}
+	argonHash, err := newTestArgon2idHasher(t, testArgon2idParams).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Verify(argonHash, "password"); !errors.Is(err, ErrUnsupportedPasswordHash) {
+		t.Errorf("esperaba ErrUnsupportedPasswordHash, obtuvo %v", err)
+	}
+}
+
+func BenchmarkArgon2idHash(b *testing.B) {
+	h := DefaultPasswordHasher()
+	for b.Loop() {
+		if _, err := h.Hash("benchmarkPassword123"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/EduGoGroup/edugo-shared/common/errors"
+)
+
+// PasswordViolationCode identifica una regla de la política de passwords
+// incumplida. Es estable: los clientes pueden usarlo para sus propios textos.
+type PasswordViolationCode string
+
+// Códigos de violación de la política de passwords.
+const (
+	PasswordTooShort          PasswordViolationCode = "PASSWORD_TOO_SHORT"
+	PasswordTooLong           PasswordViolationCode = "PASSWORD_TOO_LONG"
+	PasswordMissingLower      PasswordViolationCode = "PASSWORD_MISSING_LOWER"
+	PasswordMissingUpper      PasswordViolationCode = "PASSWORD_MISSING_UPPER"
+	PasswordMissingDigit      PasswordViolationCode = "PASSWORD_MISSING_DIGIT"
+	PasswordMissingSymbol     PasswordViolationCode = "PASSWORD_MISSING_SYMBOL"
+	PasswordTooFewCharClasses PasswordViolationCode = "PASSWORD_TOO_FEW_CHAR_CLASSES"
+	PasswordRepeatedChars     PasswordViolationCode = "PASSWORD_REPEATED_CHARS"
+	PasswordSequentialChars   PasswordViolationCode = "PASSWORD_SEQUENTIAL_CHARS"
+	PasswordSimilarToIdentity PasswordViolationCode = "PASSWORD_SIMILAR_TO_IDENTITY"
+	PasswordBreached          PasswordViolationCode = "PASSWORD_BREACHED"
+)
+
+// Idiomas soportados por los mensajes de la política.
+const (
+	PasswordPolicyLangES = "es"
+	PasswordPolicyLangEN = "en"
+)
+
+// minIdentityTokenLength es el largo mínimo de un fragmento del email o del
+// nombre para considerarlo en la regla de similitud ("ana" sí, "al" no).
+const minIdentityTokenLength = 3
+
+// PasswordViolation es una regla incumplida. Limit es el umbral de la regla
+// cuando aplica (p. ej. el largo mínimo), para que el mensaje lo incluya.
+type PasswordViolation struct {
+	Code  PasswordViolationCode `json:"code"`
+	Limit int                   `json:"limit,omitempty"`
+}
+
+// Message retorna el mensaje de la violación en el idioma indicado ("es" o
+// "en"); cualquier otro valor usa español.
+func (v PasswordViolation) Message(lang string) string {
+	catalog := passwordPolicyMessagesES
+	if lang == PasswordPolicyLangEN {
+		catalog = passwordPolicyMessagesEN
+	}
+	format, ok := catalog[v.Code]
+	if !ok {
+		return string(v.Code)
+	}
+	if strings.Contains(format, "%d") {
+		return fmt.Sprintf(format, v.Limit)
+	}
+	return format
+}
+
+// PasswordViolations es el resultado de evaluar un password.
+type PasswordViolations []PasswordViolation
+
+// Codes retorna los códigos de las violaciones, en orden.
+func (vs PasswordViolations) Codes() []string {
+	codes := make([]string, len(vs))
+	for i, v := range vs {
+		codes[i] = string(v.Code)
+	}
+	return codes
+}
+
+// Messages retorna los mensajes de las violaciones en el idioma indicado.
+func (vs PasswordViolations) Messages(lang string) []string {
+	msgs := make([]string, len(vs))
+	for i, v := range vs {
+		msgs[i] = v.Message(lang)
+	}
+	return msgs
+}
+
+// Err convierte las violaciones en un error de validación de common/errors
+// (VALIDATION_ERROR, 400) con los campos "field", "violations" (códigos) y
+// "messages" (textos localizados). Retorna nil si no hay violaciones.
+func (vs PasswordViolations) Err(lang string) error {
+	if len(vs) == 0 {
+		return nil
+	}
+	msgs := vs.Messages(lang)
+	title := passwordPolicyTitleES
+	if lang == PasswordPolicyLangEN {
+		title = passwordPolicyTitleEN
+	}
+	return errors.NewValidationError(title).
+		WithDetails(strings.Join(msgs, "; ")).
+		WithField("field", "password").
+		WithField("violations", vs.Codes()).
+		WithField("messages", msgs)
+}
+
+// PasswordSubject son los datos del usuario contra los que se compara el
+// password para detectar similitud (p. ej. "ana.perez2024" para ana.perez@…).
+type PasswordSubject struct {
+	Email string
+	Name  string
+	// Extra admite otros valores a evitar (nombre de la escuela, username…).
+	Extra []string
+}
+
+// BreachedPasswordChecker reporta si un password aparece en un corpus de
+// passwords filtrados. Lo implementa BreachedPasswordSet.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) bool
+}
+
+// PasswordPolicy configura la evaluación de passwords. Los valores cero
+// desactivan la regla correspondiente; DefaultPasswordPolicy da una base
+// razonable.
+type PasswordPolicy struct {
+	// MinLength y MaxLength se miden en caracteres (runes), no en bytes.
+	MinLength int
+	MaxLength int
+
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MinCharClasses exige al menos N de las 4 clases (minúsculas,
+	// mayúsculas, dígitos, símbolos) sin imponer cuáles.
+	MinCharClasses int
+
+	// MaxRepeatedChars es el máximo de caracteres idénticos consecutivos
+	// ("aaa" = 3).
+	MaxRepeatedChars int
+	// MaxSequentialChars es el máximo de caracteres consecutivos en
+	// secuencia ascendente o descendente ("abcd", "4321" = 4).
+	MaxSequentialChars int
+
+	// CheckSimilarity rechaza passwords que contienen fragmentos del email,
+	// el nombre o PasswordSubject.Extra, también con sustituciones tipo
+	// leet (4→a, 3→e, 0→o, …).
+	CheckSimilarity bool
+
+	// Breached es el corpus de passwords filtrados (opcional).
+	Breached BreachedPasswordChecker
+}
+
+// DefaultPasswordPolicy retorna la política recomendada: 10-128 caracteres,
+// 3 de 4 clases, sin más de 3 repetidos ni 4 en secuencia y sin similitud con
+// la identidad del usuario. El corpus de filtrados se configura aparte.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:          10,
+		MaxLength:          128,
+		MinCharClasses:     3,
+		MaxRepeatedChars:   3,
+		MaxSequentialChars: 4,
+		CheckSimilarity:    true,
+	}
+}
+
+// Evaluate evalúa el password y retorna todas las reglas incumplidas, en el
+// orden de declaración de los códigos. Un resultado vacío significa que el
+// password cumple la política.
+func (p PasswordPolicy) Evaluate(password string, subject PasswordSubject) PasswordViolations {
+	var out PasswordViolations
+
+	length := utf8.RuneCountInString(password)
+	if p.MinLength > 0 && length < p.MinLength {
+		out = append(out, PasswordViolation{Code: PasswordTooShort, Limit: p.MinLength})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		out = append(out, PasswordViolation{Code: PasswordTooLong, Limit: p.MaxLength})
+	}
+
+	lower, upper, digit, symbol := charClasses(password)
+	if p.RequireLower && !lower {
+		out = append(out, PasswordViolation{Code: PasswordMissingLower})
+	}
+	if p.RequireUpper && !upper {
+		out = append(out, PasswordViolation{Code: PasswordMissingUpper})
+	}
+	if p.RequireDigit && !digit {
+		out = append(out, PasswordViolation{Code: PasswordMissingDigit})
+	}
+	if p.RequireSymbol && !symbol {
+		out = append(out, PasswordViolation{Code: PasswordMissingSymbol})
+	}
+	if p.MinCharClasses > 0 && countTrue(lower, upper, digit, symbol) < p.MinCharClasses {
+		out = append(out, PasswordViolation{Code: PasswordTooFewCharClasses, Limit: p.MinCharClasses})
+	}
+
+	repeated, sequential := longestRuns(password)
+	if p.MaxRepeatedChars > 0 && repeated > p.MaxRepeatedChars {
+		out = append(out, PasswordViolation{Code: PasswordRepeatedChars, Limit: p.MaxRepeatedChars})
+	}
+	if p.MaxSequentialChars > 0 && sequential > p.MaxSequentialChars {
+		out = append(out, PasswordViolation{Code: PasswordSequentialChars, Limit: p.MaxSequentialChars})
+	}
+
+	if p.CheckSimilarity && similarToSubject(password, subject) {
+		out = append(out, PasswordViolation{Code: PasswordSimilarToIdentity})
+	}
+	if p.Breached != nil && password != "" && p.Breached.IsBreached(password) {
+		out = append(out, PasswordViolation{Code: PasswordBreached})
+	}
+	return out
+}
+
+// Validate evalúa el password y retorna las violaciones como error de
+// validación localizado (ver PasswordViolations.Err), o nil si cumple.
+func (p PasswordPolicy) Validate(password string, subject PasswordSubject, lang string) error {
+	return p.Evaluate(password, subject).Err(lang)
+}
+
+func charClasses(password string) (lower, upper, digit, symbol bool) {
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsLetter(r):
+			// Letras sin caja (p. ej. CJK) no cuentan como símbolo.
+		default:
+			symbol = true
+		}
+	}
+	return lower, upper, digit, symbol
+}
+
+func countTrue(flags ...bool) int {
+	n := 0
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
+
+// longestRuns retorna el run más largo de caracteres idénticos y el run más
+// largo en secuencia (±1 por paso, sin distinguir mayúsculas).
+func longestRuns(password string) (repeated, sequential int) {
+	var prev rune
+	var rep, seq, dir int
+	for i, r := range []rune(strings.ToLower(password)) {
+		if i == 0 {
+			prev, rep, seq = r, 1, 1
+			repeated, sequential = 1, 1
+			continue
+		}
+		if r == prev {
+			rep++
+		} else {
+			rep = 1
+		}
+		switch step := int(r - prev); {
+		case (step == 1 || step == -1) && step == dir:
+			seq++
+		case step == 1 || step == -1:
+			seq, dir = 2, step
+		default:
+			seq, dir = 1, 0
+		}
+		repeated = max(repeated, rep)
+		sequential = max(sequential, seq)
+		prev = r
+	}
+	return repeated, sequential
+}
+
+// leetReplacer deshace las sustituciones más comunes para la regla de similitud.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i",
+)
+
+func similarToSubject(password string, subject PasswordSubject) bool {
+	lowered := strings.ToLower(password)
+	folded := leetReplacer.Replace(lowered)
+	for _, token := range identityTokens(subject) {
+		if strings.Contains(lowered, token) || strings.Contains(folded, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityTokens parte el email (solo la parte local), el nombre y los extras
+// en fragmentos alfanuméricos en minúsculas.
+func identityTokens(subject PasswordSubject) []string {
+	sources := make([]string, 0, 2+len(subject.Extra))
+	local, _, _ := strings.Cut(subject.Email, "@")
+	sources = append(sources, local, subject.Name)
+	sources = append(sources, subject.Extra...)
+
+	var tokens []string
+	for _, src := range sources {
+		fields := strings.FieldsFunc(strings.ToLower(src), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, f := range fields {
+			if utf8.RuneCountInString(f) >= minIdentityTokenLength {
+				tokens = append(tokens, f)
+			}
+		}
+	}
+	return tokens
+}
+
+const (
+	passwordPolicyTitleES = "la contraseña no cumple la política de seguridad"
+	passwordPolicyTitleEN = "password does not meet the security policy"
+)
+
+var passwordPolicyMessagesES = map[PasswordViolationCode]string{
+	PasswordTooShort:          "debe tener al menos %d caracteres",
+	PasswordTooLong:           "debe tener como máximo %d caracteres",
+	PasswordMissingLower:      "debe incluir una letra minúscula",
+	PasswordMissingUpper:      "debe incluir una letra mayúscula",
+	PasswordMissingDigit:      "debe incluir un número",
+	PasswordMissingSymbol:     "debe incluir un símbolo",
+	PasswordTooFewCharClasses: "debe combinar al menos %d tipos de caracteres (minúsculas, mayúsculas, números, símbolos)",
+	PasswordRepeatedChars:     "no debe repetir el mismo carácter más de %d veces seguidas",
+	PasswordSequentialChars:   "no debe contener secuencias de más de %d caracteres (como abcdef o 123456)",
+	PasswordSimilarToIdentity: "no debe contener tu nombre ni tu email",
+	PasswordBreached:          "aparece en filtraciones de datos conocidas; elige otra",
+}
+
+var passwordPolicyMessagesEN = map[PasswordViolationCode]string{
+	PasswordTooShort:          "must be at least %d characters long",
+	PasswordTooLong:           "must be at most %d characters long",
+	PasswordMissingLower:      "must include a lowercase letter",
+	PasswordMissingUpper:      "must include an uppercase letter",
+	PasswordMissingDigit:      "must include a digit",
+	PasswordMissingSymbol:     "must include a symbol",
+	PasswordTooFewCharClasses: "must combine at least %d character types (lowercase, uppercase, digits, symbols)",
+	PasswordRepeatedChars:     "must not repeat the same character more than %d times in a row",
+	PasswordSequentialChars:   "must not contain sequences longer than %d characters (like abcdef or 123456)",
+	PasswordSimilarToIdentity: "must not contain your name or email",
+	PasswordBreached:          "appears in known data breaches; choose another one",
+}
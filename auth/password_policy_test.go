@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto/sha1" //nolint:gosec // G505: formato del corpus
+	"encoding/hex"
+	stdErrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/EduGoGroup/edugo-shared/common/errors"
+)
+
+func codesOf(vs PasswordViolations) []PasswordViolationCode {
+	var out []PasswordViolationCode
+	for _, v := range vs {
+		out = append(out, v.Code)
+	}
+	return out
+}
+
+func TestPasswordPolicy_Default(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	subject := PasswordSubject{Email: "ana.perez@edugo.com", Name: "Ana Pérez"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []PasswordViolationCode
+	}{
+		{"válido", "Río-Verde_83", nil},
+		{"válido unicode", "Ñandú sin alas 7", nil},
+		{"corto", "Ab1!x", []PasswordViolationCode{PasswordTooShort}},
+		{"pocas clases", "solominusculas", []PasswordViolationCode{PasswordTooFewCharClasses}},
+		{"repetidos", "Viento-aaaa-93", []PasswordViolationCode{PasswordRepeatedChars}},
+		{"secuencia", "Clave-12345-x", []PasswordViolationCode{PasswordSequentialChars}},
+		{"secuencia descendente", "Clave-edcba-9", []PasswordViolationCode{PasswordSequentialChars}},
+		{"email", "PEREZ-seguro-1", []PasswordViolationCode{PasswordSimilarToIdentity}},
+		{"nombre con leet", "4n4-Secreta-91", []PasswordViolationCode{PasswordSimilarToIdentity}},
+		{"varias", "aaaa", []PasswordViolationCode{PasswordTooShort, PasswordTooFewCharClasses, PasswordRepeatedChars}},
+		{"largo", strings.Repeat("Ab1-", 33), []PasswordViolationCode{PasswordTooLong}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Evaluate(tt.password, subject)
+			assert.Equal(t, tt.want, codesOf(got))
+		})
+	}
+}
+
+func TestPasswordPolicy_RequiredClasses(t *testing.T) {
+	policy := PasswordPolicy{RequireLower: true, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	got := policy.Evaluate("", PasswordSubject{})
+	assert.Equal(t, []PasswordViolationCode{
+		PasswordMissingLower, PasswordMissingUpper, PasswordMissingDigit, PasswordMissingSymbol,
+	}, codesOf(got))
+
+	assert.Empty(t, policy.Evaluate("aB3 ", PasswordSubject{}))
+}
+
+func TestPasswordPolicy_ZeroValueAcceptsEverything(t *testing.T) {
+	assert.Empty(t, PasswordPolicy{}.Evaluate("a", PasswordSubject{Name: "a"}))
+}
+
+func TestPasswordPolicy_ShortIdentityTokensIgnored(t *testing.T) {
+	policy := PasswordPolicy{CheckSimilarity: true}
+	// "al" y "li" son demasiado cortos para considerarse.
+	assert.Empty(t, policy.Evaluate("alimentos", PasswordSubject{Email: "al.li@x.com"}))
+	assert.Equal(t, []PasswordViolationCode{PasswordSimilarToIdentity},
+		codesOf(policy.Evaluate("mi-escuela-andina", PasswordSubject{Extra: []string{"Escuela Andina"}})))
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	policy.Breached = NewBreachedPasswordSet("Bienvenido2024!")
+
+	got := policy.Evaluate("Bienvenido2024!", PasswordSubject{})
+	assert.Equal(t, []PasswordViolationCode{PasswordBreached}, codesOf(got))
+	assert.Empty(t, policy.Evaluate("Bienvenida2024!", PasswordSubject{}))
+}
+
+func TestPasswordViolations_Err(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+	violations := policy.Evaluate("abc", PasswordSubject{})
+	require.NotEmpty(t, violations)
+
+	err := violations.Err(PasswordPolicyLangEN)
+	var appErr *errors.AppError
+	require.True(t, stdErrors.As(err, &appErr))
+	assert.Equal(t, errors.ErrorCodeValidation, appErr.Code)
+	assert.Equal(t, 400, appErr.StatusCode)
+	assert.Equal(t, "password does not meet the security policy", appErr.Message)
+	assert.Equal(t, "password", appErr.Fields["field"])
+	assert.Equal(t, []string{"PASSWORD_TOO_SHORT", "PASSWORD_TOO_FEW_CHAR_CLASSES"}, appErr.Fields["violations"])
+	assert.Contains(t, appErr.Details, "must be at least 10 characters long")
+
+	err = policy.Validate("abc", PasswordSubject{}, PasswordPolicyLangES)
+	require.True(t, stdErrors.As(err, &appErr))
+	assert.Equal(t, "la contraseña no cumple la política de seguridad", appErr.Message)
+	assert.Contains(t, appErr.Details, "debe tener al menos 10 caracteres")
+
+	assert.NoError(t, PasswordViolations(nil).Err(PasswordPolicyLangES))
+}
+
+func TestPasswordViolation_Message(t *testing.T) {
+	v := PasswordViolation{Code: PasswordRepeatedChars, Limit: 3}
+	assert.Equal(t, "no debe repetir el mismo carácter más de 3 veces seguidas", v.Message(PasswordPolicyLangES))
+	assert.Equal(t, "must not repeat the same character more than 3 times in a row", v.Message(PasswordPolicyLangEN))
+	// Idioma desconocido: español.
+	assert.Equal(t, v.Message(PasswordPolicyLangES), v.Message("pt"))
+	// Código desconocido: el código tal cual.
+	assert.Equal(t, "CUSTOM", PasswordViolation{Code: "CUSTOM"}.Message(PasswordPolicyLangEN))
+
+	// Todos los códigos tienen mensaje en ambos idiomas.
+	for code := range passwordPolicyMessagesES {
+		assert.Contains(t, passwordPolicyMessagesEN, code)
+	}
+	assert.Len(t, passwordPolicyMessagesEN, len(passwordPolicyMessagesES))
+}
+
+func TestLoadBreachedPasswordsFile(t *testing.T) {
+	set, err := LoadBreachedPasswordsFile("testdata/breached_passwords.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 8, set.Len())
+
+	for _, p := range []string{"123456", "password", "iloveyou", "Contrasena123"} {
+		assert.True(t, set.IsBreached(p), p)
+	}
+	assert.False(t, set.IsBreached("correct horse battery staple"))
+
+	// Range: k-anonimato, solo viaja el prefijo.
+	sum := sha1.Sum([]byte("password")) //nolint:gosec // G401: formato del corpus
+	full := strings.ToUpper(hex.EncodeToString(sum[:]))
+	assert.Equal(t, []string{full[5:]}, set.Range(full[:5]))
+	assert.Equal(t, []string{full[5:]}, set.Range(strings.ToLower(full[:5])))
+	assert.Empty(t, set.Range("00000"))
+	assert.Nil(t, set.Range("ZZZZZ"))
+	assert.Nil(t, set.Range("5BAA"))
+
+	_, err = LoadBreachedPasswordsFile("testdata/no-existe.txt")
+	require.Error(t, err)
+}
+
+func TestLoadBreachedPasswords_InvalidAndDuplicates(t *testing.T) {
+	_, err := LoadBreachedPasswords(strings.NewReader("# ok\nnot-a-hash\n"))
+	require.ErrorContains(t, err, "línea 2")
+
+	_, err = LoadBreachedPasswords(strings.NewReader(strings.Repeat("Z", 40) + "\n"))
+	require.ErrorContains(t, err, "línea 1")
+
+	sum := sha1.Sum([]byte("x")) //nolint:gosec // G401: formato del corpus
+	h := hex.EncodeToString(sum[:])
+	set, err := LoadBreachedPasswords(strings.NewReader(h + ":1\n" + strings.ToUpper(h) + ":9\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, set.Len())
+	assert.True(t, set.IsBreached("x"))
+}
+
+func BenchmarkPasswordPolicy_Evaluate(b *testing.B) {
+	policy := DefaultPasswordPolicy()
+	policy.Breached = NewBreachedPasswordSet("123456", "password")
+	subject := PasswordSubject{Email: "ana.perez@edugo.com", Name: "Ana Pérez"}
+	for b.Loop() {
+		_ = policy.Evaluate("Río-Verde_83", subject)
+	}
+}
@@ -176,3 +176,29 @@ func BenchmarkVerifyPassword(b *testing.B) {
 		}
 	}
 }
+
+func TestVerifyPasswordAndRehash(t *testing.T) {
+	password := "migrarAlLogin123"
+
+	legacy, err := HashPassword(password)
+	if err != nil {
+		t.Fatalf("Error al hashear: %v", err)
+	}
+	needsRehash, err := VerifyPasswordAndRehash(legacy, password)
+	if err != nil || !needsRehash {
+		t.Errorf("Hash bcrypt debe verificar y pedir rehash, obtuvo (%v, %v)", needsRehash, err)
+	}
+
+	current, err := DefaultPasswordHasher().Hash(password)
+	if err != nil {
+		t.Fatalf("Error al hashear: %v", err)
+	}
+	needsRehash, err = VerifyPasswordAndRehash(current, password)
+	if err != nil || needsRehash {
+		t.Errorf("Hash Argon2id vigente no debe pedir rehash, obtuvo (%v, %v)", needsRehash, err)
+	}
+
+	if _, err := VerifyPasswordAndRehash(current, "otroPassword"); err == nil {
+		t.Error("Password incorrecto debe retornar error")
+	}
+}
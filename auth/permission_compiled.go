@@ -0,0 +1,213 @@
+package auth
+
+import "strings"
+
+// CompiledGrants es la versión indexada de Grants para evaluar muchos
+// permisos contra el mismo conjunto de patterns. EvaluateGrants recorre
+// todos los deny y allow con PermissionMatches en cada chequeo; acá cada
+// forma de pattern va a su índice:
+//
+//	`pattern`           → mapa de exactos
+//	`prefix.*`          → trie de prefijos
+//	`*.suffix`          → trie de sufijos (bytes en orden inverso)
+//	`prefix.*.suffix`   → trie de prefijos cuyo nodo final tiene su
+//	                      propio trie inverso de sufijos
+//
+// de modo que el costo de Evaluate depende del largo del request y no de
+// la cantidad de patterns. La semántica es idéntica a EvaluateGrants
+// (deny precedence, default deny, `:own` como string distinto); patterns
+// fuera de la gramática se comparan solo por igualdad, igual que en
+// PermissionMatches.
+//
+// Compilar cuesta más que una evaluación lineal: conviene cuando se evalúan
+// varios permisos contra los mismos grants (p. ej. una vez por request en el
+// middleware). Es inmutable y seguro para uso concurrente.
+type CompiledGrants struct {
+	allow compiledPatterns
+	deny  compiledPatterns
+}
+
+// CompileGrants indexa los patterns allow y deny de g.
+func CompileGrants(g Grants) *CompiledGrants {
+	return &CompiledGrants{
+		allow: compilePatterns(g.Allow),
+		deny:  compilePatterns(g.Deny),
+	}
+}
+
+// Evaluate es el equivalente de EvaluateGrants sobre los grants compilados.
+func (cg *CompiledGrants) Evaluate(request string) bool {
+	return !cg.deny.matches(request) && cg.allow.matches(request)
+}
+
+// compiledPatterns agrupa los índices de una lista de patterns; matches
+// equivale a "algún pattern de la lista cumple PermissionMatches".
+type compiledPatterns struct {
+	any     bool
+	exact   map[string]struct{}
+	subtree *grantTrieNode // claves: `prefix` de `prefix.*`
+	suffix  *grantTrieNode // claves: `.suffix` de `*.suffix`, invertidas
+	middle  *grantTrieNode // claves: `prefix.` de `prefix.*.suffix`; tails: `.suffix` invertidas
+}
+
+// grantTrieNode es un nodo de trie por byte. end marca el fin de una clave;
+// tails solo se usa en el trie middle.
+type grantTrieNode struct {
+	children map[byte]*grantTrieNode
+	end      bool
+	tails    *grantTrieNode
+}
+
+// insert agrega key (o key invertida si reverse) y retorna su nodo final.
+func (n *grantTrieNode) insert(key string, reverse bool) *grantTrieNode {
+	node := n
+	for i := range len(key) {
+		b := key[i]
+		if reverse {
+			b = key[len(key)-1-i]
+		}
+		child, ok := node.children[b]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[byte]*grantTrieNode)
+			}
+			child = &grantTrieNode{}
+			node.children[b] = child
+		}
+		node = child
+	}
+	node.end = true
+	return node
+}
+
+// compilePatterns clasifica cada pattern en el mismo orden en que
+// PermissionMatches prueba sus reglas.
+func compilePatterns(patterns []string) compiledPatterns {
+	var cp compiledPatterns
+	for _, p := range patterns {
+		if p == "*" {
+			cp.any = true
+			continue
+		}
+		// La igualdad se prueba antes que cualquier regla, para todo pattern.
+		if cp.exact == nil {
+			cp.exact = make(map[string]struct{}, len(patterns))
+		}
+		cp.exact[p] = struct{}{}
+
+		switch {
+		case strings.HasSuffix(p, ".*"):
+			if cp.subtree == nil {
+				cp.subtree = &grantTrieNode{}
+			}
+			cp.subtree.insert(p[:len(p)-2], false)
+		case strings.HasPrefix(p, "*."):
+			if cp.suffix == nil {
+				cp.suffix = &grantTrieNode{}
+			}
+			cp.suffix.insert(p[1:], true)
+		default:
+			i := strings.Index(p, ".*.")
+			if i <= 0 {
+				continue
+			}
+			head, tail := p[:i+1], p[i+2:]
+			if strings.Contains(head, "*") || strings.Contains(tail, "*") {
+				continue
+			}
+			if cp.middle == nil {
+				cp.middle = &grantTrieNode{}
+			}
+			node := cp.middle.insert(head, false)
+			if node.tails == nil {
+				node.tails = &grantTrieNode{}
+			}
+			node.tails.insert(tail, true)
+		}
+	}
+	return cp
+}
+
+func (cp *compiledPatterns) matches(request string) bool {
+	if cp.any {
+		return true
+	}
+	if _, ok := cp.exact[request]; ok {
+		return true
+	}
+	return cp.matchesSubtree(request) || cp.matchesSuffix(request) || cp.matchesMiddle(request)
+}
+
+// matchesSubtree: request == prefix o request empieza con `prefix.`.
+func (cp *compiledPatterns) matchesSubtree(request string) bool {
+	node := cp.subtree
+	if node == nil {
+		return false
+	}
+	if node.end && (request == "" || request[0] == '.') {
+		return true
+	}
+	for i := range len(request) {
+		if node = node.children[request[i]]; node == nil {
+			return false
+		}
+		if node.end && (i+1 == len(request) || request[i+1] == '.') {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSuffix: request termina con `.suffix` y es más largo que él.
+func (cp *compiledPatterns) matchesSuffix(request string) bool {
+	node := cp.suffix
+	if node == nil {
+		return false
+	}
+	for k := 1; k < len(request); k++ {
+		if node = node.children[request[len(request)-k]]; node == nil {
+			return false
+		}
+		if node.end {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMiddle: request empieza con `prefix.`, termina con `.suffix` y
+// entre ambos queda al menos un segmento que no empieza ni termina en punto.
+func (cp *compiledPatterns) matchesMiddle(request string) bool {
+	node := cp.middle
+	if node == nil {
+		return false
+	}
+	for i := range len(request) {
+		if node = node.children[request[i]]; node == nil {
+			return false
+		}
+		if node.tails != nil && matchesTail(node.tails, request, i+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTail busca en el trie inverso tails un `.suffix` que deje un
+// segmento intermedio válido después de los headLen bytes del prefijo.
+func matchesTail(tails *grantTrieNode, request string, headLen int) bool {
+	node := tails
+	for k := 1; headLen+k < len(request); k++ {
+		if node = node.children[request[len(request)-k]]; node == nil {
+			return false
+		}
+		if !node.end {
+			continue
+		}
+		middle := request[headLen : len(request)-k]
+		if middle[0] != '.' && middle[len(middle)-1] != '.' {
+			return true
+		}
+	}
+	return false
+}
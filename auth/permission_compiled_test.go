@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestCompiledGrantsGolden valida CompiledGrants contra el fixture
+// cross-language, tanto por pattern suelto como por conjunto de grants.
+func TestCompiledGrantsGolden(t *testing.T) {
+	gf := loadGolden(t)
+	for _, c := range gf.MatcherCases {
+		got := CompileGrants(Grants{Allow: []string{c.Pattern}}).Evaluate(c.Request)
+		if got != c.Expected {
+			t.Errorf("[%s] %s: pattern=%q request=%q want=%v got=%v",
+				c.ID, c.Name, c.Pattern, c.Request, c.Expected, got)
+		}
+	}
+	for _, c := range gf.GrantsCases {
+		got := CompileGrants(Grants{Allow: c.Allow, Deny: c.Deny}).Evaluate(c.Request)
+		if got != c.Expected {
+			t.Errorf("[%s] %s: allow=%v deny=%v request=%q want=%v got=%v",
+				c.ID, c.Name, c.Allow, c.Deny, c.Request, c.Expected, got)
+		}
+	}
+}
+
+func TestCompiledGrants_Semantics(t *testing.T) {
+	g := Grants{
+		Allow: []string{
+			"admin.users.*",
+			"*.read",
+			"academic.*.list",
+			"content.materials.update:own",
+			"reports.view",
+		},
+		Deny: []string{"admin.users.delete", "*.export"},
+	}
+	cg := CompileGrants(g)
+	tests := []struct {
+		request string
+		want    bool
+	}{
+		{"admin.users", true},
+		{"admin.users.update", true},
+		{"admin.usersx", false},
+		{"admin.users.delete", false}, // deny gana
+		{"academic.units.read", true},
+		{"read", false},
+		{".read", false},
+		{"academic.units.list", true},
+		{"academic.units.members.list", true},
+		{"academic.list", false},
+		{"academic..list", false},
+		{"content.materials.update:own", true},
+		{"content.materials.update", false}, // :own no cubre al permiso sin :own
+		{"reports.view", true},
+		{"reports.export", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := cg.Evaluate(tt.request); got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.request, got, tt.want)
+		}
+		if got := EvaluateGrants(g, tt.request); got != tt.want {
+			t.Errorf("EvaluateGrants(%q) = %v, want %v", tt.request, got, tt.want)
+		}
+	}
+
+	if CompileGrants(Grants{}).Evaluate("admin.users.read") {
+		t.Error("grants vacíos deben denegar")
+	}
+	if !CompileGrants(Grants{Allow: []string{"*"}}).Evaluate("cualquier.cosa") {
+		t.Error("`*` debe cubrir cualquier request")
+	}
+	if CompileGrants(Grants{Allow: []string{"*"}, Deny: []string{"*"}}).Evaluate("x") {
+		t.Error("deny `*` debe ganar")
+	}
+}
+
+// compiledMatches compara un solo pattern como allow y como deny.
+func compiledMatches(pattern, request string) (allow, deny bool) {
+	allow = CompileGrants(Grants{Allow: []string{pattern}}).Evaluate(request)
+	deny = CompileGrants(Grants{Allow: []string{"*"}, Deny: []string{pattern}}).Evaluate(request)
+	return allow, deny
+}
+
+// FuzzCompiledGrantsDifferential compara CompiledGrants contra
+// PermissionMatches/EvaluateGrants sobre strings arbitrarios, incluidos
+// patterns fuera de la gramática. Correr con:
+//
+//	go test -run '^$' -fuzz FuzzCompiledGrantsDifferential ./auth
+func FuzzCompiledGrantsDifferential(f *testing.F) {
+	seeds := [][3]string{
+		{"*", "", "a"},
+		{"a.*", "a.b.*", "a.b.c"},
+		{".*", "*.", "."},
+		{"*.*", "*.a", "*.a"},
+		{"*.create", "admin.*.create", "admin.users.create"},
+		{"a.*.b", "a.*.*.b", "a..b"},
+		{"a.*.b", "a.x*.*.b", "a.x.b"},
+		{"a.*.b.*.c", "a.*.b", "a.x.b.y.c"},
+		{"x.*.:own", "x.*.y:own", "x.y.y:own"},
+		{"content.materials.update:own", "content.materials.*", "content.materials.update"},
+	}
+	// El fixture golden entra al corpus inicial.
+	var gf goldenFile
+	if raw, err := os.ReadFile("testdata/permission_matcher_golden.json"); err == nil {
+		_ = json.Unmarshal(raw, &gf)
+	}
+	for _, c := range gf.MatcherCases {
+		seeds = append(seeds, [3]string{c.Pattern, "", c.Request})
+	}
+	for _, s := range seeds {
+		f.Add(s[0], s[1], s[2])
+	}
+
+	f.Fuzz(func(t *testing.T, p1, p2, request string) {
+		for _, p := range []string{p1, p2} {
+			want := PermissionMatches(p, request)
+			allow, deny := compiledMatches(p, request)
+			if allow != want || deny == want {
+				t.Fatalf("pattern=%q request=%q: PermissionMatches=%v compiled allow=%v deny=%v",
+					p, request, want, allow, !deny)
+			}
+		}
+		for _, g := range []Grants{
+			{Allow: []string{p1, p2}},
+			{Allow: []string{p1}, Deny: []string{p2}},
+			{Allow: []string{p2, "*"}, Deny: []string{p1}},
+		} {
+			if got, want := CompileGrants(g).Evaluate(request), EvaluateGrants(g, request); got != want {
+				t.Fatalf("grants=%+v request=%q: EvaluateGrants=%v compiled=%v", g, request, want, got)
+			}
+		}
+	})
+}
+
+// TestCompiledGrants_RandomDifferential complementa el fuzz con conjuntos
+// grandes de patterns generados sobre un alfabeto chico, para que haya
+// muchas colisiones de prefijos y sufijos entre patterns.
+func TestCompiledGrants_RandomDifferential(t *testing.T) {
+	rng := rand.New(rand.NewPCG(20260504, 15))
+	segments := []string{"a", "b", "ab", "*", "", "x:own", "a:own"}
+	randomPath := func(maxSegments int) string {
+		n := 1 + rng.IntN(maxSegments)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = segments[rng.IntN(len(segments))]
+		}
+		return strings.Join(parts, ".")
+	}
+
+	for round := range 300 {
+		g := Grants{}
+		for range rng.IntN(40) {
+			g.Allow = append(g.Allow, randomPath(4))
+		}
+		for range rng.IntN(10) {
+			g.Deny = append(g.Deny, randomPath(4))
+		}
+		cg := CompileGrants(g)
+		for range 50 {
+			request := randomPath(5)
+			if got, want := cg.Evaluate(request), EvaluateGrants(g, request); got != want {
+				t.Fatalf("round %d grants=%+v request=%q: EvaluateGrants=%v compiled=%v", round, g, request, want, got)
+			}
+		}
+	}
+}
+
+// benchmarkGrants arma un conjunto grande de grants (≈ 300 patterns) como
+// el de un super admin con overrides por recurso.
+func benchmarkGrants() Grants {
+	g := Grants{}
+	for i := range 100 {
+		g.Allow = append(g.Allow,
+			fmt.Sprintf("module%d.resource%d.read", i%10, i),
+			fmt.Sprintf("module%d.resource%d.*", i%10, i),
+			fmt.Sprintf("module%d.*.export%d", i%10, i),
+		)
+	}
+	for i := range 20 {
+		g.Deny = append(g.Deny, fmt.Sprintf("module%d.resource%d.delete", i%10, i))
+	}
+	return g
+}
+
+var benchmarkRequests = []string{
+	"module3.resource93.update",
+	"module9.resource5.delete",
+	"admin.users.read",
+	"module1.resource0.delete",
+}
+
+func BenchmarkEvaluateGrants_Large(b *testing.B) {
+	g := benchmarkGrants()
+	for b.Loop() {
+		for _, r := range benchmarkRequests {
+			_ = EvaluateGrants(g, r)
+		}
+	}
+}
+
+func BenchmarkCompiledGrants_Large(b *testing.B) {
+	cg := CompileGrants(benchmarkGrants())
+	for b.Loop() {
+		for _, r := range benchmarkRequests {
+			_ = cg.Evaluate(r)
+		}
+	}
+}
+
+func BenchmarkCompileGrants_Large(b *testing.B) {
+	g := benchmarkGrants()
+	for b.Loop() {
+		_ = CompileGrants(g)
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOwnPermission(t *testing.T) {
+	if got := OwnPermission("admin.users.read"); got != "admin.users.read:own" {
+		t.Errorf("OwnPermission = %q", got)
+	}
+	if got := OwnPermission("admin.users.read:own"); got != "admin.users.read:own" {
+		t.Errorf("OwnPermission sobre :own = %q", got)
+	}
+}
+
+func TestEvaluateGrantsScope(t *testing.T) {
+	g := Grants{
+		Allow: []string{"admin.users.read:own", "admin.users.update:own", "reports.*"},
+		Deny:  []string{"admin.users.update:own"},
+	}
+	tests := []struct {
+		permission string
+		want       PermissionScope
+	}{
+		{"reports.read", PermissionScopeAll},
+		{"admin.users.read", PermissionScopeOwn},
+		{"admin.users.read:own", PermissionScopeOwn},
+		{"admin.users.update", PermissionScopeNone}, // deny sobre la variante :own
+		{"admin.users.delete", PermissionScopeNone},
+	}
+	cg := CompileGrants(g)
+	for _, tt := range tests {
+		if got := EvaluateGrantsScope(g, tt.permission); got != tt.want {
+			t.Errorf("EvaluateGrantsScope(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+		if got := cg.Scope(tt.permission); got != tt.want {
+			t.Errorf("CompiledGrants.Scope(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateGrantsScope_DenyOnFullPermissionBlocksOwn(t *testing.T) {
+	g := Grants{
+		Allow: []string{"admin.*"},
+		Deny:  []string{"admin.users.delete", "admin.schools.*"},
+	}
+	tests := []struct {
+		permission string
+		want       PermissionScope
+	}{
+		{"admin.users.delete", PermissionScopeNone},
+		{"admin.users.delete:own", PermissionScopeNone},
+		{"admin.schools.update", PermissionScopeNone},
+		{"admin.schools.update:own", PermissionScopeNone},
+		{"admin.users.read", PermissionScopeAll},
+		{"admin.users.read:own", PermissionScopeOwn},
+	}
+	cg := CompileGrants(g)
+	for _, tt := range tests {
+		if got := EvaluateGrantsScope(g, tt.permission); got != tt.want {
+			t.Errorf("EvaluateGrantsScope(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+		if got := cg.Scope(tt.permission); got != tt.want {
+			t.Errorf("CompiledGrants.Scope(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestClaims_OwnerIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+		want   []string
+	}{
+		{
+			name:   "contexto propio",
+			claims: Claims{UserID: "u1", ActiveContext: &UserContext{SubjectStudentID: "s1"}},
+			want:   []string{"u1"},
+		},
+		{
+			name: "representante",
+			claims: Claims{UserID: "u1", ActiveContext: &UserContext{
+				SubjectStudentID: "s1",
+				ActorMode:        ActorModeWard,
+			}},
+			want: []string{"u1", "s1"},
+		},
+		{
+			name:   "snapshot de refresh",
+			claims: Claims{UserID: "u1", SubjectStudentID: "s1", ActorMode: ActorModeWard},
+			want:   []string{"u1", "s1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.OwnerIDs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OwnerIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
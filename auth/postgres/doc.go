@@ -0,0 +1,14 @@
+// Package postgres proporciona una implementación de auth.RefreshTokenStore
+// para PostgreSQL.
+//
+// Persiste las familias de refresh tokens en la tabla auth.refresh_tokens
+// usando GORM. Ver docs/README.md para la migración.
+//
+// Ejemplo de uso:
+//
+//	db := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+//	store := postgres.NewRefreshTokenStore(db)
+//	svc := auth.NewRefreshTokenService(store, issueAccessToken, auth.RefreshTokenServiceConfig{})
+//
+//	pair, err := svc.Rotate(ctx, presentedRefreshToken)
+package postgres
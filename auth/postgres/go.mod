@@ -0,0 +1,24 @@
+module github.com/EduGoGroup/edugo-shared/auth/postgres
+
+go 1.25.0
+
+require (
+	github.com/EduGoGroup/edugo-shared/auth v0.1.1
+	gorm.io/gorm v1.31.1
+)
+
+require (
+	github.com/EduGoGroup/edugo-shared/audit v0.900.1 // indirect
+	github.com/EduGoGroup/edugo-shared/common v0.900.5 // indirect
+	github.com/golang-jwt/jwt/v5 v5.3.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/crypto v0.51.0 // indirect
+	golang.org/x/sys v0.44.0 // indirect
+	golang.org/x/text v0.37.0 // indirect
+)
+
+replace github.com/EduGoGroup/edugo-shared/audit => ../../audit
+
+replace github.com/EduGoGroup/edugo-shared/auth => ../
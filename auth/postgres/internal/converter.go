@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"strings"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+)
+
+// amrSeparator separa los métodos de AMR en la columna amr. Los valores de
+// RFC 8176 no contienen espacios.
+const amrSeparator = " "
+
+// ToDBModel convierte un auth.RefreshTokenRecord al modelo GORM RefreshTokenDB.
+func ToDBModel(record auth.RefreshTokenRecord) RefreshTokenDB {
+	r := RefreshTokenDB{
+		TokenHash: record.TokenHash,
+		FamilyID:  record.FamilyID,
+		UserID:    record.UserID,
+		IssuedAt:  record.IssuedAt,
+		ExpiresAt: record.ExpiresAt,
+		RotatedAt: record.RotatedAt,
+		RevokedAt: record.RevokedAt,
+		AMR:       strings.Join(record.AMR, amrSeparator),
+	}
+	if record.ParentHash != "" {
+		r.ParentHash = &record.ParentHash
+	}
+	if !record.AuthTime.IsZero() {
+		r.AuthTime = &record.AuthTime
+	}
+	if !record.MFAAt.IsZero() {
+		r.MFAAt = &record.MFAAt
+	}
+	return r
+}
+
+// ToRecord convierte el modelo GORM al auth.RefreshTokenRecord público.
+func ToRecord(r RefreshTokenDB) auth.RefreshTokenRecord {
+	record := auth.RefreshTokenRecord{
+		TokenHash: r.TokenHash,
+		FamilyID:  r.FamilyID,
+		UserID:    r.UserID,
+		IssuedAt:  r.IssuedAt,
+		ExpiresAt: r.ExpiresAt,
+		RotatedAt: r.RotatedAt,
+		RevokedAt: r.RevokedAt,
+	}
+	if r.ParentHash != nil {
+		record.ParentHash = *r.ParentHash
+	}
+	if r.AuthTime != nil {
+		record.AuthTime = *r.AuthTime
+	}
+	if r.AMR != "" {
+		record.AMR = strings.Split(r.AMR, amrSeparator)
+	}
+	if r.MFAAt != nil {
+		record.MFAAt = *r.MFAAt
+	}
+	return record
+}
@@ -0,0 +1,23 @@
+package internal
+
+import "time"
+
+// RefreshTokenDB es el modelo GORM para la tabla auth.refresh_tokens.
+type RefreshTokenDB struct {
+	TokenHash  string     `gorm:"column:token_hash;primaryKey"`
+	FamilyID   string     `gorm:"column:family_id;not null"`
+	ParentHash *string    `gorm:"column:parent_hash"`
+	UserID     string     `gorm:"column:user_id;not null"`
+	IssuedAt   time.Time  `gorm:"column:issued_at;not null"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at;not null"`
+	AuthTime   *time.Time `gorm:"column:auth_time"`
+	AMR        string     `gorm:"column:amr;not null;default:''"`
+	MFAAt      *time.Time `gorm:"column:mfa_at"`
+	RotatedAt  *time.Time `gorm:"column:rotated_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+}
+
+// TableName especifica el nombre de la tabla en la base de datos.
+func (RefreshTokenDB) TableName() string {
+	return "auth.refresh_tokens"
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/auth/postgres/internal"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenStore implementa auth.RefreshTokenStore sobre la tabla
+// auth.refresh_tokens. La rotación marca al padre con un UPDATE condicional
+// (rotated_at IS NULL) dentro de la misma transacción que inserta al hijo, de
+// modo que dos canjes concurrentes del mismo token no pueden ganar ambos.
+type RefreshTokenStore struct {
+	db *gorm.DB
+}
+
+var _ auth.RefreshTokenStore = (*RefreshTokenStore)(nil)
+
+// NewRefreshTokenStore crea un RefreshTokenStore.
+func NewRefreshTokenStore(db *gorm.DB) *RefreshTokenStore {
+	return &RefreshTokenStore{db: db}
+}
+
+// Create guarda un token raíz (nueva familia).
+func (s *RefreshTokenStore) Create(ctx context.Context, record auth.RefreshTokenRecord) error {
+	row := internal.ToDBModel(record)
+	if err := s.db.WithContext(ctx).Create(&row).Error; err != nil {
+		return fmt.Errorf("auth: guardando refresh token: %w", err)
+	}
+	return nil
+}
+
+// Get retorna el registro por hash, o auth.ErrRefreshTokenNotFound.
+func (s *RefreshTokenStore) Get(ctx context.Context, tokenHash string) (*auth.RefreshTokenRecord, error) {
+	var row internal.RefreshTokenDB
+	err := s.db.WithContext(ctx).Where("token_hash = ?", tokenHash).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: consultando refresh token: %w", err)
+	}
+	record := internal.ToRecord(row)
+	return &record, nil
+}
+
+// Rotate marca parentHash como rotado y guarda next en una transacción.
+// Retorna auth.ErrRefreshTokenReused si el padre ya estaba rotado o revocado.
+func (s *RefreshTokenStore) Rotate(ctx context.Context, parentHash string, next auth.RefreshTokenRecord, at time.Time) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return rotate(tx, parentHash, next, at)
+	})
+}
+
+// rotate ejecuta la rotación dentro de tx.
+func rotate(tx *gorm.DB, parentHash string, next auth.RefreshTokenRecord, at time.Time) error {
+	res := tx.Model(&internal.RefreshTokenDB{}).
+		Where("token_hash = ? AND rotated_at IS NULL AND revoked_at IS NULL", parentHash).
+		Update("rotated_at", at)
+	if res.Error != nil {
+		return fmt.Errorf("auth: rotando refresh token: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(&internal.RefreshTokenDB{}).Where("token_hash = ?", parentHash).Count(&count).Error; err != nil {
+			return fmt.Errorf("auth: rotando refresh token: %w", err)
+		}
+		if count == 0 {
+			return auth.ErrRefreshTokenNotFound
+		}
+		return auth.ErrRefreshTokenReused
+	}
+	row := internal.ToDBModel(next)
+	if err := tx.Create(&row).Error; err != nil {
+		return fmt.Errorf("auth: guardando refresh token rotado: %w", err)
+	}
+	return nil
+}
+
+// RevokeFamily revoca todos los tokens de la familia.
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&internal.RefreshTokenDB{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("auth: revocando familia de refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revoca todas las familias del usuario.
+func (s *RefreshTokenStore) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	err := s.db.WithContext(ctx).Model(&internal.RefreshTokenDB{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return fmt.Errorf("auth: revocando refresh tokens del usuario: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired borra los registros expirados antes de `before`.
+func (s *RefreshTokenStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&internal.RefreshTokenDB{})
+	if res.Error != nil {
+		return 0, fmt.Errorf("auth: borrando refresh tokens expirados: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/auth/postgres/internal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// capturingDB abre una conexión dry-run que registra el SQL generado.
+func capturingDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	var statements []string
+	capture := func(tx *gorm.DB) { statements = append(statements, tx.Statement.SQL.String()) }
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := cb.Query().After("gorm:query").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := cb.Update().After("gorm:update").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	if err := cb.Delete().After("gorm:delete").Register("test:capture", capture); err != nil {
+		t.Fatal(err)
+	}
+	return db, &statements
+}
+
+func assertSQL(t *testing.T, sql string, fragments ...string) {
+	t.Helper()
+	for _, f := range fragments {
+		if !strings.Contains(sql, f) {
+			t.Errorf("expected %q in SQL: %s", f, sql)
+		}
+	}
+}
+
+func TestConverterRoundTrip(t *testing.T) {
+	rotated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	record := auth.RefreshTokenRecord{
+		TokenHash:  "child",
+		FamilyID:   "family-1",
+		ParentHash: "parent",
+		UserID:     "user-1",
+		IssuedAt:   rotated.Add(-time.Hour),
+		ExpiresAt:  rotated.Add(time.Hour),
+		AuthTime:   rotated.Add(-2 * time.Hour),
+		AMR:        []string{auth.AMRPassword, auth.AMROTP, auth.AMRMultiFactor},
+		MFAAt:      rotated.Add(-2 * time.Hour),
+		RotatedAt:  &rotated,
+	}
+	row := internal.ToDBModel(record)
+	if row.ParentHash == nil || *row.ParentHash != "parent" {
+		t.Fatalf("parent hash not mapped: %+v", row)
+	}
+	if row.AMR != "pwd otp mfa" {
+		t.Fatalf("amr not mapped: %q", row.AMR)
+	}
+	if got := internal.ToRecord(row); !reflect.DeepEqual(got, record) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, record)
+	}
+
+	root := internal.ToDBModel(auth.RefreshTokenRecord{TokenHash: "root"})
+	if root.ParentHash != nil {
+		t.Fatal("root token should have NULL parent_hash")
+	}
+	if root.AuthTime != nil || root.AMR != "" || root.MFAAt != nil {
+		t.Fatalf("empty auth fields should map to NULL/'': %+v", root)
+	}
+	if back := internal.ToRecord(root); back.AMR != nil || !back.AuthTime.IsZero() || !back.MFAAt.IsZero() {
+		t.Fatalf("empty auth fields should round trip to zero values: %+v", back)
+	}
+}
+
+func TestTableName(t *testing.T) {
+	if got := (internal.RefreshTokenDB{}).TableName(); got != "auth.refresh_tokens" {
+		t.Fatalf("unexpected table name %q", got)
+	}
+}
+
+func TestCreateAndGetSQL(t *testing.T) {
+	db, statements := capturingDB(t)
+	store := NewRefreshTokenStore(db)
+	ctx := context.Background()
+
+	if err := store.Create(ctx, auth.RefreshTokenRecord{TokenHash: "h", FamilyID: "f", UserID: "u"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := store.Get(ctx, "h"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if len(*statements) != 2 {
+		t.Fatalf("expected 2 statements, got %d: %v", len(*statements), *statements)
+	}
+	assertSQL(t, (*statements)[0], "INSERT INTO", "refresh_tokens", "token_hash", "family_id", "parent_hash")
+	assertSQL(t, (*statements)[1], "SELECT", "token_hash = ?", "LIMIT")
+}
+
+func TestRotateSQL(t *testing.T) {
+	db, statements := capturingDB(t)
+
+	// El dialecto dry-run no abre transacciones: se prueba el cuerpo de Rotate.
+	// El UPDATE no afecta filas y el COUNT retorna 0: el padre no existe.
+	err := rotate(db, "parent", auth.RefreshTokenRecord{TokenHash: "child"}, time.Now())
+	if !errors.Is(err, auth.ErrRefreshTokenNotFound) {
+		t.Fatalf("expected ErrRefreshTokenNotFound, got %v", err)
+	}
+	if len(*statements) < 2 {
+		t.Fatalf("expected UPDATE and COUNT, got %v", *statements)
+	}
+	assertSQL(t, (*statements)[0], "UPDATE", "rotated_at", "token_hash = ?", "rotated_at IS NULL", "revoked_at IS NULL")
+	assertSQL(t, (*statements)[1], "count(*)", "token_hash = ?")
+}
+
+func TestRevokeAndDeleteSQL(t *testing.T) {
+	db, statements := capturingDB(t)
+	store := NewRefreshTokenStore(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	if err := store.RevokeFamily(ctx, "family-1", now); err != nil {
+		t.Fatalf("RevokeFamily: %v", err)
+	}
+	if err := store.RevokeUser(ctx, "user-1", now); err != nil {
+		t.Fatalf("RevokeUser: %v", err)
+	}
+	if _, err := store.DeleteExpired(ctx, now); err != nil {
+		t.Fatalf("DeleteExpired: %v", err)
+	}
+
+	if len(*statements) != 3 {
+		t.Fatalf("expected 3 statements, got %v", *statements)
+	}
+	assertSQL(t, (*statements)[0], "UPDATE", "revoked_at", "family_id = ?", "revoked_at IS NULL")
+	assertSQL(t, (*statements)[1], "UPDATE", "revoked_at", "user_id = ?", "revoked_at IS NULL")
+	assertSQL(t, (*statements)[2], "DELETE FROM", "expires_at < ?")
+}
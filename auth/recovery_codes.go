@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// DefaultRecoveryCodeCount es la cantidad de códigos de recuperación por
+// enrolamiento.
+const DefaultRecoveryCodeCount = 10
+
+// recoveryCodeAlphabet es base32 en minúsculas sin 0/1/8/9, para que los
+// códigos se puedan dictar y copiar sin confundir caracteres.
+const recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+// recoveryCodeLength es la cantidad de caracteres sin guion (50 bits de
+// entropía): suficiente para hashear con SHA-256 en lugar de un KDF lento.
+const recoveryCodeLength = 10
+
+// GenerateRecoveryCodes genera n códigos de recuperación de un solo uso con
+// formato "xxxxx-xxxxx". Retorna los códigos en texto plano (se muestran al
+// usuario una única vez) y sus hashes (se guardan en BD), en el mismo orden.
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	if n <= 0 {
+		n = DefaultRecoveryCodeCount
+	}
+	codes = make([]string, n)
+	hashes = make([]string, n)
+	buf := make([]byte, recoveryCodeLength)
+	for i := range n {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("auth: generando códigos de recuperación: %w", err)
+		}
+		var b strings.Builder
+		for j, v := range buf {
+			if j == recoveryCodeLength/2 {
+				b.WriteByte('-')
+			}
+			b.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+		}
+		codes[i] = b.String()
+		hashes[i] = HashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode retorna el hash SHA-256 (hex) del código normalizado:
+// ignora mayúsculas, espacios y guiones.
+func HashRecoveryCode(code string) string {
+	return HashToken(normalizeRecoveryCode(code))
+}
+
+// ConsumeRecoveryCode busca el código entre los hashes guardados. Si
+// coincide retorna los hashes restantes (sin el usado), que el llamador debe
+// persistir para que el código no vuelva a servir, y true. Si no coincide
+// retorna los hashes sin cambios y false. Compara todos los hashes en tiempo
+// constante.
+func ConsumeRecoveryCode(code string, hashes []string) ([]string, bool) {
+	candidate := []byte(HashRecoveryCode(code))
+	match := -1
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(candidate, []byte(h)) == 1 && match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return hashes, false
+	}
+	remaining := make([]string, 0, len(hashes)-1)
+	remaining = append(remaining, hashes[:match]...)
+	remaining = append(remaining, hashes[match+1:]...)
+	return remaining, true
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '-' || r == ' ':
+			return -1
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return r
+		}
+	}, code)
+}
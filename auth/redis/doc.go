@@ -0,0 +1,13 @@
+// Package redis proporciona una implementación de auth.SessionStore para
+// Redis.
+//
+// Cada sesión es un hash con TTL hasta su vencimiento, indexado por usuario
+// en un sorted set. Ver docs/README.md para el layout de claves.
+//
+// Ejemplo de uso:
+//
+//	store := redis.NewSessionStore(client, redis.SessionStoreConfig{})
+//	sessions := auth.NewSessionRegistry(store, blacklist, auth.SessionRegistryConfig{Families: refreshService})
+//
+//	list, err := sessions.List(ctx, userID)
+package redis
@@ -0,0 +1,26 @@
+module github.com/EduGoGroup/edugo-shared/auth/redis
+
+go 1.25.0
+
+require (
+	github.com/EduGoGroup/edugo-shared/auth v0.1.1
+	github.com/alicebob/miniredis/v2 v2.37.0
+	github.com/redis/go-redis/v9 v9.18.0
+)
+
+require (
+	github.com/EduGoGroup/edugo-shared/audit v0.900.1 // indirect
+	github.com/EduGoGroup/edugo-shared/common v0.900.5 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/golang-jwt/jwt/v5 v5.3.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	go.uber.org/atomic v1.11.0 // indirect
+	golang.org/x/crypto v0.51.0 // indirect
+	golang.org/x/sys v0.44.0 // indirect
+)
+
+replace github.com/EduGoGroup/edugo-shared/audit => ../../audit
+
+replace github.com/EduGoGroup/edugo-shared/auth => ../
@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultSessionKeyPrefix es el prefijo de claves por defecto.
+const DefaultSessionKeyPrefix = "auth:session:"
+
+// Campos del hash de cada sesión. last_seen_at y last_ip se actualizan en
+// cada Touch sin reescribir el resto.
+const (
+	fieldData       = "data"
+	fieldLastSeenAt = "last_seen_at"
+	fieldLastIP     = "last_ip"
+)
+
+// saveBody escribe la sesión (clave sk) y la indexa en el sorted set del
+// usuario (clave uk, score = vencimiento), extendiendo el TTL del índice si
+// hace falta. ARGV: data, last_seen_at, last_ip, ttl (ms), vencimiento (ms),
+// id.
+const saveBody = `
+redis.call('DEL', sk)
+redis.call('HSET', sk, 'data', ARGV[1], 'last_seen_at', ARGV[2], 'last_ip', ARGV[3])
+redis.call('PEXPIRE', sk, ARGV[4])
+redis.call('ZADD', uk, ARGV[5], ARGV[6])
+if redis.call('PTTL', uk) < tonumber(ARGV[4]) then
+  redis.call('PEXPIRE', uk, ARGV[4])
+end
+return 1
+`
+
+var (
+	// saveSessionScript: KEYS sesión, índice del usuario.
+	saveSessionScript = goredis.NewScript(`local sk, uk = KEYS[1], KEYS[2]` + saveBody)
+
+	// replaceSessionScript borra la sesión vieja y guarda la nueva; retorna 0
+	// sin cambios si la vieja no existe. KEYS: sesión vieja, sesión nueva,
+	// índice del usuario. ARGV: los de saveBody más el id viejo.
+	replaceSessionScript = goredis.NewScript(`
+local sk, uk = KEYS[2], KEYS[3]
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('DEL', KEYS[1])
+redis.call('ZREM', uk, ARGV[7])` + saveBody)
+
+	// touchSessionScript actualiza last_seen_at y last_ip si la sesión existe.
+	touchSessionScript = goredis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return 0
+end
+redis.call('HSET', KEYS[1], 'last_seen_at', ARGV[1])
+if ARGV[2] ~= '' then
+  redis.call('HSET', KEYS[1], 'last_ip', ARGV[2])
+end
+return 1
+`)
+)
+
+// SessionStoreConfig configura un SessionStore.
+type SessionStoreConfig struct {
+	// KeyPrefix separa las claves de sesiones (default "auth:session:").
+	KeyPrefix string
+}
+
+// SessionStore implementa auth.SessionStore sobre Redis. Cada sesión es un
+// hash que expira junto con la sesión; un sorted set por usuario indexa sus
+// sesiones por vencimiento. Las entradas del índice de sesiones ya borradas
+// o vencidas se limpian al listar.
+type SessionStore struct {
+	client *goredis.Client
+	cfg    SessionStoreConfig
+	now    func() time.Time // reemplazable en tests
+}
+
+var _ auth.SessionStore = (*SessionStore)(nil)
+
+// NewSessionStore crea un SessionStore.
+func NewSessionStore(client *goredis.Client, cfg SessionStoreConfig) *SessionStore {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = DefaultSessionKeyPrefix
+	}
+	return &SessionStore{client: client, cfg: cfg, now: time.Now}
+}
+
+// Save crea o reemplaza la sesión. Las sesiones ya vencidas se ignoran.
+func (s *SessionStore) Save(ctx context.Context, session auth.Session) error {
+	args, ok, err := s.saveArgs(session)
+	if err != nil || !ok {
+		return err
+	}
+	keys := []string{s.sessionKey(session.ID), s.userKey(session.UserID)}
+	if err := saveSessionScript.Run(ctx, s.client, keys, args...).Err(); err != nil {
+		return fmt.Errorf("auth: guardando sesión: %w", err)
+	}
+	return nil
+}
+
+// Replace guarda next y borra oldID en un solo script. Retorna
+// auth.ErrSessionNotFound si oldID no existe.
+func (s *SessionStore) Replace(ctx context.Context, oldID string, next auth.Session) error {
+	args, ok, err := s.saveArgs(next)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.Delete(ctx, oldID)
+	}
+	keys := []string{s.sessionKey(oldID), s.sessionKey(next.ID), s.userKey(next.UserID)}
+	replaced, err := replaceSessionScript.Run(ctx, s.client, keys, append(args, oldID)...).Int()
+	if err != nil {
+		return fmt.Errorf("auth: rotando sesión: %w", err)
+	}
+	if replaced == 0 {
+		return auth.ErrSessionNotFound
+	}
+	return nil
+}
+
+// Get retorna la sesión, o auth.ErrSessionNotFound.
+func (s *SessionStore) Get(ctx context.Context, id string) (*auth.Session, error) {
+	fields, err := s.client.HGetAll(ctx, s.sessionKey(id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("auth: consultando sesión: %w", err)
+	}
+	session, ok, err := decodeSession(fields)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, auth.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+// ListByUser retorna las sesiones vigentes del usuario.
+func (s *SessionStore) ListByUser(ctx context.Context, userID string) ([]auth.Session, error) {
+	userKey := s.userKey(userID)
+	now := strconv.FormatInt(s.now().UnixMilli(), 10)
+	if err := s.client.ZRemRangeByScore(ctx, userKey, "-inf", now).Err(); err != nil {
+		return nil, fmt.Errorf("auth: listando sesiones: %w", err)
+	}
+	ids, err := s.client.ZRange(ctx, userKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("auth: listando sesiones: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*goredis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, s.sessionKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("auth: listando sesiones: %w", err)
+	}
+
+	sessions := make([]auth.Session, 0, len(ids))
+	var stale []any
+	for i, cmd := range cmds {
+		session, ok, err := decodeSession(cmd.Val())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			stale = append(stale, ids[i])
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+	if len(stale) > 0 {
+		// Sesiones borradas con Delete: el índice se limpia aquí.
+		if err := s.client.ZRem(ctx, userKey, stale...).Err(); err != nil {
+			return nil, fmt.Errorf("auth: limpiando índice de sesiones: %w", err)
+		}
+	}
+	return sessions, nil
+}
+
+// Touch actualiza LastSeenAt y LastIP. Ignora sesiones inexistentes.
+func (s *SessionStore) Touch(ctx context.Context, id string, at time.Time, ip string) error {
+	err := touchSessionScript.Run(ctx, s.client, []string{s.sessionKey(id)},
+		strconv.FormatInt(at.UnixNano(), 10), ip).Err()
+	if err != nil {
+		return fmt.Errorf("auth: actualizando sesión: %w", err)
+	}
+	return nil
+}
+
+// Delete borra las sesiones dadas. Ignora las inexistentes.
+func (s *SessionStore) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = s.sessionKey(id)
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("auth: borrando sesiones: %w", err)
+	}
+	return nil
+}
+
+// saveArgs arma los ARGV de saveBody. ok es false si la sesión ya venció.
+func (s *SessionStore) saveArgs(session auth.Session) (args []any, ok bool, err error) {
+	ttl := session.ExpiresAt.Sub(s.now())
+	if ttl <= 0 {
+		return nil, false, nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, false, fmt.Errorf("auth: serializando sesión: %w", err)
+	}
+	return []any{
+		data,
+		strconv.FormatInt(session.LastSeenAt.UnixNano(), 10),
+		session.LastIP,
+		max(ttl.Milliseconds(), 1),
+		session.ExpiresAt.UnixMilli(),
+		session.ID,
+	}, true, nil
+}
+
+func (s *SessionStore) sessionKey(id string) string {
+	return s.cfg.KeyPrefix + "id:" + id
+}
+
+func (s *SessionStore) userKey(userID string) string {
+	return s.cfg.KeyPrefix + "user:" + userID
+}
+
+// decodeSession arma la sesión desde los campos del hash. ok es false si el
+// hash no existe.
+func decodeSession(fields map[string]string) (*auth.Session, bool, error) {
+	data, ok := fields[fieldData]
+	if !ok {
+		return nil, false, nil
+	}
+	var session auth.Session
+	if err := json.Unmarshal([]byte(data), &session); err != nil {
+		return nil, false, fmt.Errorf("auth: decodificando sesión: %w", err)
+	}
+	if ns, err := strconv.ParseInt(fields[fieldLastSeenAt], 10, 64); err == nil {
+		session.LastSeenAt = time.Unix(0, ns)
+	}
+	session.LastIP = fields[fieldLastIP]
+	return &session, true, nil
+}
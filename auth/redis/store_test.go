@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestStore(t *testing.T) (*SessionStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("starting miniredis: %v", err)
+	}
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close() //nolint:errcheck,gosec // best-effort cleanup
+		mr.Close()
+	})
+	return NewSessionStore(client, SessionStoreConfig{}), mr
+}
+
+func testSession(id, userID string, expiresIn time.Duration) auth.Session {
+	now := time.Now().Truncate(time.Millisecond)
+	return auth.Session{
+		ID:         id,
+		UserID:     userID,
+		FamilyID:   "fam-" + id,
+		Device:     auth.DeviceInfo{UserAgent: "Firefox", IP: "10.0.0.1"},
+		LastIP:     "10.0.0.1",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(expiresIn),
+		Tokens:     []auth.SessionToken{{JTI: id, ExpiresAt: now.Add(15 * time.Minute)}},
+	}
+}
+
+func TestSessionStore_SaveGetTouch(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+
+	s := testSession("jti-1", "user-1", time.Hour)
+	if err := store.Save(ctx, s); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if ttl := mr.TTL(DefaultSessionKeyPrefix + "id:jti-1"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("la clave debe expirar con la sesión, TTL = %v", ttl)
+	}
+
+	got, err := store.Get(ctx, "jti-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.FamilyID != "fam-jti-1" || got.Device.UserAgent != "Firefox" || len(got.Tokens) != 1 {
+		t.Fatalf("Get = %+v", got)
+	}
+
+	seen := s.LastSeenAt.Add(time.Minute)
+	if err := store.Touch(ctx, "jti-1", seen, "10.0.0.9"); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	got, _ = store.Get(ctx, "jti-1")
+	if !got.LastSeenAt.Equal(seen) || got.LastIP != "10.0.0.9" {
+		t.Fatalf("Touch no actualizó: %v %q", got.LastSeenAt, got.LastIP)
+	}
+
+	// Touch de una sesión inexistente no la crea.
+	if err := store.Touch(ctx, "ghost", seen, ""); err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	if _, err := store.Get(ctx, "ghost"); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("Get(ghost) = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestSessionStore_ReplaceAndList(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+
+	for _, s := range []auth.Session{
+		testSession("jti-1", "user-1", time.Hour),
+		testSession("jti-2", "user-1", time.Hour),
+		testSession("jti-3", "user-2", time.Hour),
+	} {
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	next := testSession("jti-1b", "user-1", 2*time.Hour)
+	if err := store.Replace(ctx, "jti-1", next); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	if err := store.Replace(ctx, "jti-1", next); !errors.Is(err, auth.ErrSessionNotFound) {
+		t.Fatalf("Replace repetido = %v, want ErrSessionNotFound", err)
+	}
+	if ttl := mr.TTL(DefaultSessionKeyPrefix + "user:user-1"); ttl <= time.Hour {
+		t.Fatalf("el índice debe extenderse a la sesión más larga, TTL = %v", ttl)
+	}
+
+	if err := store.Delete(ctx, "jti-2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	sessions, err := store.ListByUser(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "jti-1b" {
+		t.Fatalf("ListByUser = %+v", sessions)
+	}
+	// La sesión borrada se limpió del índice.
+	if members, _ := mr.ZMembers(DefaultSessionKeyPrefix + "user:user-1"); len(members) != 1 {
+		t.Fatalf("índice = %v", members)
+	}
+}
+
+func TestSessionStore_WithRegistry(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	bl := auth.NewInMemoryBlacklist(t.Context())
+	registry := auth.NewSessionRegistry(store, bl, auth.SessionRegistryConfig{})
+
+	claims := func(jti string) *auth.Claims {
+		c := &auth.Claims{UserID: "user-1"}
+		c.ID = jti
+		return c
+	}
+	for _, jti := range []string{"a", "b"} {
+		if _, err := registry.Record(ctx, claims(jti), auth.DeviceInfo{}, ""); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if n, err := registry.RevokeOthers(ctx, "user-1", "b"); err != nil || n != 1 {
+		t.Fatalf("RevokeOthers = %d, %v", n, err)
+	}
+	sessions, err := registry.List(ctx, "user-1")
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "b" {
+		t.Fatalf("List = %+v, %v", sessions, err)
+	}
+}
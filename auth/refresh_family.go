@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/EduGoGroup/edugo-shared/common/errors"
+)
+
+// DefaultRefreshTokenTTL es la vigencia por defecto de cada refresh token de una familia.
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrRefreshTokenNotFound indica que el refresh token presentado no existe.
+	ErrRefreshTokenNotFound = stdErrors.New("auth: refresh token no encontrado")
+	// ErrRefreshTokenExpired indica que el refresh token presentado expiró.
+	ErrRefreshTokenExpired = stdErrors.New("auth: refresh token expirado")
+	// ErrRefreshTokenRevoked indica que la familia del refresh token fue revocada.
+	ErrRefreshTokenRevoked = stdErrors.New("auth: refresh token revocado")
+	// ErrRefreshTokenReused indica que se presentó un refresh token ya rotado.
+	// La familia completa queda revocada.
+	ErrRefreshTokenReused = stdErrors.New("auth: refresh token reutilizado, familia revocada")
+)
+
+// RefreshTokenRecord es el registro persistido de un refresh token. Cada
+// rotación crea un registro hijo en la misma familia (FamilyID) que apunta a
+// su padre (ParentHash); el token en texto plano nunca se guarda.
+type RefreshTokenRecord struct {
+	TokenHash  string
+	FamilyID   string
+	ParentHash string // vacío en el token raíz (login)
+	UserID     string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+	// AuthTime y AMR son el momento y los métodos del login que inició la
+	// familia, y MFAAt el del segundo factor (cero si no hubo). Se copian en
+	// cada rotación para que el access token emitido en un refresh conserve
+	// auth_time, amr y mfa_at (ver AuthOptions).
+	AuthTime  time.Time
+	AMR       []string
+	MFAAt     time.Time
+	RotatedAt *time.Time // no nil cuando ya se canjeó por un hijo
+	RevokedAt *time.Time // no nil cuando la familia fue revocada
+}
+
+// RefreshTokenStore persiste las familias de refresh tokens.
+type RefreshTokenStore interface {
+	// Create guarda un token raíz (nueva familia).
+	Create(ctx context.Context, record RefreshTokenRecord) error
+	// Get retorna el registro por hash, o ErrRefreshTokenNotFound.
+	Get(ctx context.Context, tokenHash string) (*RefreshTokenRecord, error)
+	// Rotate marca parentHash como rotado en `at` y guarda next, de forma
+	// atómica. Si el padre ya estaba rotado o revocado retorna
+	// ErrRefreshTokenReused sin guardar next.
+	Rotate(ctx context.Context, parentHash string, next RefreshTokenRecord, at time.Time) error
+	// RevokeFamily revoca todos los tokens de la familia.
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUser revoca todas las familias del usuario (logout global).
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	// DeleteExpired borra los registros expirados antes de `before` y retorna cuántos.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuthOptions retorna las opciones de token que fijan auth_time, amr y
+// mfa_at del login de la familia.
+func (r RefreshTokenRecord) AuthOptions() []TokenOption {
+	var opts []TokenOption
+	if !r.AuthTime.IsZero() {
+		opts = append(opts, WithAuthTime(r.AuthTime))
+	}
+	if len(r.AMR) > 0 {
+		opts = append(opts, WithAMR(r.AMR...))
+	}
+	if !r.MFAAt.IsZero() {
+		opts = append(opts, WithMFA(r.MFAAt))
+	}
+	return opts
+}
+
+// AccessTokenIssuer emite el access token que acompaña a un refresh token.
+// Recibe el registro del refresh token nuevo (usuario y familia); típicamente
+// carga el contexto activo del usuario y llama a
+// JWTManager.GenerateTokenWithContext pasando refresh.AuthOptions().
+type AccessTokenIssuer func(ctx context.Context, refresh RefreshTokenRecord) (token string, expiresAt time.Time, err error)
+
+// TokenPair es el par de tokens entregado al cliente en login y refresh.
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	FamilyID         string
+}
+
+// RefreshTokenServiceConfig configura un RefreshTokenService.
+type RefreshTokenServiceConfig struct {
+	// TTL es la vigencia de cada refresh token (default 30 días).
+	TTL time.Duration
+}
+
+// RefreshTokenService emite y rota refresh tokens por familias, con
+// detección de reutilización (OAuth 2.1 §4.3.1): cada refresh token es de un
+// solo uso y presentar uno ya rotado revoca la familia completa, de modo que
+// un token robado deja de servir tanto al atacante como al cliente legítimo.
+type RefreshTokenService struct {
+	store  RefreshTokenStore
+	issuer AccessTokenIssuer
+	ttl    time.Duration
+	now    func() time.Time // reemplazable en tests
+}
+
+// NewRefreshTokenService crea un RefreshTokenService.
+func NewRefreshTokenService(store RefreshTokenStore, issuer AccessTokenIssuer, cfg RefreshTokenServiceConfig) *RefreshTokenService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultRefreshTokenTTL
+	}
+	return &RefreshTokenService{store: store, issuer: issuer, ttl: cfg.TTL, now: time.Now}
+}
+
+// Issue inicia una familia nueva para el usuario (login) y retorna el par de
+// tokens. amr son los métodos de autenticación del login (p. ej. AMRPassword);
+// el momento del login queda como AuthTime de la familia.
+func (s *RefreshTokenService) Issue(ctx context.Context, userID string, amr ...string) (*TokenPair, error) {
+	return s.issue(ctx, userID, time.Time{}, amr)
+}
+
+// IssueWithMFA es Issue para un login que verificó un segundo factor en
+// mfaAt: la familia conserva MFAAt (y AMRMultiFactor en AMR), de modo que
+// los access tokens emitidos al rotar siguen pasando RequireMFA.
+func (s *RefreshTokenService) IssueWithMFA(ctx context.Context, userID string, mfaAt time.Time, amr ...string) (*TokenPair, error) {
+	if !slices.Contains(amr, AMRMultiFactor) {
+		amr = append(slices.Clone(amr), AMRMultiFactor)
+	}
+	return s.issue(ctx, userID, mfaAt, amr)
+}
+
+func (s *RefreshTokenService) issue(ctx context.Context, userID string, mfaAt time.Time, amr []string) (*TokenPair, error) {
+	if userID == "" {
+		return nil, errors.NewValidationError("userID no puede estar vacío")
+	}
+	now := s.now()
+	refresh, err := GenerateRefreshToken(s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	record := RefreshTokenRecord{
+		TokenHash: refresh.TokenHash,
+		FamilyID:  uuid.NewString(),
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+		AuthTime:  now,
+		AMR:       amr,
+		MFAAt:     mfaAt,
+	}
+	accessToken, accessExp, err := s.issuer(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh.Token,
+		RefreshExpiresAt: record.ExpiresAt,
+		FamilyID:         record.FamilyID,
+	}, nil
+}
+
+// Rotate canjea el refresh token presentado por un par nuevo de la misma
+// familia. Si el token ya había sido rotado revoca la familia y retorna
+// ErrRefreshTokenReused; si no existe, expiró o la familia está revocada
+// retorna ErrRefreshTokenNotFound, ErrRefreshTokenExpired o ErrRefreshTokenRevoked.
+func (s *RefreshTokenService) Rotate(ctx context.Context, presented string) (*TokenPair, error) {
+	now := s.now()
+	parentHash := HashToken(presented)
+	parent, err := s.store.Get(ctx, parentHash)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parent.RevokedAt != nil:
+		return nil, ErrRefreshTokenRevoked
+	case parent.RotatedAt != nil:
+		return nil, s.revokeReused(ctx, parent.FamilyID, now)
+	case !now.Before(parent.ExpiresAt):
+		return nil, ErrRefreshTokenExpired
+	}
+
+	refresh, err := GenerateRefreshToken(s.ttl)
+	if err != nil {
+		return nil, err
+	}
+	next := RefreshTokenRecord{
+		TokenHash:  refresh.TokenHash,
+		FamilyID:   parent.FamilyID,
+		ParentHash: parentHash,
+		UserID:     parent.UserID,
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(s.ttl),
+		AuthTime:   parent.AuthTime,
+		AMR:        parent.AMR,
+		MFAAt:      parent.MFAAt,
+	}
+	accessToken, accessExp, err := s.issuer(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Rotate(ctx, parentHash, next, now); err != nil {
+		if stdErrors.Is(err, ErrRefreshTokenReused) {
+			// Otra solicitud canjeó el mismo token primero.
+			return nil, s.revokeReused(ctx, parent.FamilyID, now)
+		}
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh.Token,
+		RefreshExpiresAt: next.ExpiresAt,
+		FamilyID:         next.FamilyID,
+	}, nil
+}
+
+// RevokeFamily revoca la familia (logout de una sesión).
+func (s *RefreshTokenService) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.store.RevokeFamily(ctx, familyID, s.now())
+}
+
+// RevokeUser revoca todas las familias del usuario (logout global).
+func (s *RefreshTokenService) RevokeUser(ctx context.Context, userID string) error {
+	return s.store.RevokeUser(ctx, userID, s.now())
+}
+
+func (s *RefreshTokenService) revokeReused(ctx context.Context, familyID string, now time.Time) error {
+	if err := s.store.RevokeFamily(ctx, familyID, now); err != nil {
+		return stdErrors.Join(ErrRefreshTokenReused, err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// InMemoryRefreshTokenStore implementa RefreshTokenStore en memoria. Útil
+// para tests y servicios de una sola instancia. Es seguro para uso concurrente.
+type InMemoryRefreshTokenStore struct {
+	mu       sync.Mutex
+	records  map[string]*RefreshTokenRecord // tokenHash -> registro
+	families map[string][]string            // familyID -> tokenHashes
+}
+
+// NewInMemoryRefreshTokenStore crea un InMemoryRefreshTokenStore vacío.
+func NewInMemoryRefreshTokenStore() *InMemoryRefreshTokenStore {
+	return &InMemoryRefreshTokenStore{
+		records:  make(map[string]*RefreshTokenRecord),
+		families: make(map[string][]string),
+	}
+}
+
+// Create guarda un token raíz.
+func (s *InMemoryRefreshTokenStore) Create(_ context.Context, record RefreshTokenRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(record)
+	return nil
+}
+
+// Get retorna una copia del registro.
+func (s *InMemoryRefreshTokenStore) Get(_ context.Context, tokenHash string) (*RefreshTokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[tokenHash]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+	out := *rec
+	out.AMR = slices.Clone(rec.AMR)
+	return &out, nil
+}
+
+// Rotate marca el padre como rotado y guarda el hijo de forma atómica.
+func (s *InMemoryRefreshTokenStore) Rotate(_ context.Context, parentHash string, next RefreshTokenRecord, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	parent, ok := s.records[parentHash]
+	if !ok {
+		return ErrRefreshTokenNotFound
+	}
+	if parent.RotatedAt != nil || parent.RevokedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	parent.RotatedAt = &at
+	s.put(next)
+	return nil
+}
+
+// RevokeFamily revoca todos los tokens de la familia.
+func (s *InMemoryRefreshTokenStore) RevokeFamily(_ context.Context, familyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeFamily(familyID, at)
+	return nil
+}
+
+// RevokeUser revoca todas las familias del usuario.
+func (s *InMemoryRefreshTokenStore) RevokeUser(_ context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for familyID, hashes := range s.families {
+		if len(hashes) > 0 && s.records[hashes[0]].UserID == userID {
+			s.revokeFamily(familyID, at)
+		}
+	}
+	return nil
+}
+
+// DeleteExpired borra los registros expirados antes de `before`.
+func (s *InMemoryRefreshTokenStore) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for familyID, hashes := range s.families {
+		kept := hashes[:0]
+		for _, h := range hashes {
+			if s.records[h].ExpiresAt.Before(before) {
+				delete(s.records, h)
+				deleted++
+				continue
+			}
+			kept = append(kept, h)
+		}
+		if len(kept) == 0 {
+			delete(s.families, familyID)
+		} else {
+			s.families[familyID] = kept
+		}
+	}
+	return deleted, nil
+}
+
+func (s *InMemoryRefreshTokenStore) put(record RefreshTokenRecord) {
+	rec := record
+	rec.AMR = slices.Clone(record.AMR)
+	s.records[rec.TokenHash] = &rec
+	s.families[rec.FamilyID] = append(s.families[rec.FamilyID], rec.TokenHash)
+}
+
+func (s *InMemoryRefreshTokenStore) revokeFamily(familyID string, at time.Time) {
+	for _, h := range s.families[familyID] {
+		if rec := s.records[h]; rec.RevokedAt == nil {
+			rec.RevokedAt = &at
+		}
+	}
+}
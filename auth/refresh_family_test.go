@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRefreshSecret = "test-secret-32-chars-minimum-123456"
+
+func newTestRefreshService(t *testing.T) (*RefreshTokenService, *InMemoryRefreshTokenStore) {
+	t.Helper()
+	store := NewInMemoryRefreshTokenStore()
+	manager := NewJWTManager(testRefreshSecret, "edugo-central")
+	issuer := func(_ context.Context, refresh RefreshTokenRecord) (string, time.Time, error) {
+		return manager.GenerateTokenWithContext(refresh.UserID, "u@edugo.com", testActiveContext(), 15*time.Minute,
+			refresh.AuthOptions()...)
+	}
+	return NewRefreshTokenService(store, issuer, RefreshTokenServiceConfig{TTL: time.Hour}), store
+}
+
+func TestRefreshTokenService_IssueAndRotate(t *testing.T) {
+	svc, store := newTestRefreshService(t)
+	ctx := t.Context()
+
+	login, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, login.AccessToken)
+	assert.NotEmpty(t, login.RefreshToken)
+	assert.NotEmpty(t, login.FamilyID)
+
+	rotated, err := svc.Rotate(ctx, login.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, login.FamilyID, rotated.FamilyID)
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+
+	child, err := store.Get(ctx, HashToken(rotated.RefreshToken))
+	require.NoError(t, err)
+	assert.Equal(t, HashToken(login.RefreshToken), child.ParentHash)
+	assert.Equal(t, "user-1", child.UserID)
+
+	parent, err := store.Get(ctx, HashToken(login.RefreshToken))
+	require.NoError(t, err)
+	assert.NotNil(t, parent.RotatedAt)
+	assert.Nil(t, parent.RevokedAt)
+
+	// La cadena continúa con el hijo.
+	_, err = svc.Rotate(ctx, rotated.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestRefreshTokenService_ReuseRevokesFamily(t *testing.T) {
+	svc, store := newTestRefreshService(t)
+	ctx := t.Context()
+
+	login, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+	other, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+
+	rotated, err := svc.Rotate(ctx, login.RefreshToken)
+	require.NoError(t, err)
+
+	// El atacante presenta el token ya rotado.
+	_, err = svc.Rotate(ctx, login.RefreshToken)
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// El token legítimo más reciente también queda revocado.
+	_, err = svc.Rotate(ctx, rotated.RefreshToken)
+	require.ErrorIs(t, err, ErrRefreshTokenRevoked)
+
+	child, err := store.Get(ctx, HashToken(rotated.RefreshToken))
+	require.NoError(t, err)
+	assert.NotNil(t, child.RevokedAt)
+
+	// Las demás familias del usuario no se ven afectadas.
+	_, err = svc.Rotate(ctx, other.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestRefreshTokenService_PreservesAuthTimeAndAMR(t *testing.T) {
+	loginAt := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	clock := &fakeClock{now: loginAt}
+	svc, store := newTestRefreshService(t)
+	svc.now = clock.Now
+	manager := NewJWTManager(testRefreshSecret, "edugo-central")
+	ctx := t.Context()
+
+	login, err := svc.Issue(ctx, "user-1", AMRPassword)
+	require.NoError(t, err)
+
+	clock.Advance(5 * time.Minute)
+	rotated, err := svc.Rotate(ctx, login.RefreshToken)
+	require.NoError(t, err)
+
+	child, err := store.Get(ctx, HashToken(rotated.RefreshToken))
+	require.NoError(t, err)
+	assert.True(t, loginAt.Equal(child.AuthTime))
+	assert.Equal(t, []string{AMRPassword}, child.AMR)
+
+	claims, err := manager.ValidateToken(rotated.AccessToken)
+	require.NoError(t, err)
+	require.NotNil(t, claims.AuthTime)
+	assert.True(t, loginAt.Equal(claims.AuthTime.Time), "auth_time no avanza con el refresh")
+	assert.Equal(t, []string{AMRPassword}, claims.AMR)
+	assert.True(t, claims.AuthFresh(15*time.Minute, time.Now()))
+	assert.False(t, claims.AuthFresh(5*time.Minute, time.Now()))
+}
+
+func TestRefreshTokenService_PreservesMFA(t *testing.T) {
+	mfaAt := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	clock := &fakeClock{now: mfaAt}
+	svc, store := newTestRefreshService(t)
+	svc.now = clock.Now
+	manager := NewJWTManager(testRefreshSecret, "edugo-central")
+	ctx := t.Context()
+
+	login, err := svc.IssueWithMFA(ctx, "user-1", mfaAt, AMRPassword, AMROTP)
+	require.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	rotated, err := svc.Rotate(ctx, login.RefreshToken)
+	require.NoError(t, err)
+
+	child, err := store.Get(ctx, HashToken(rotated.RefreshToken))
+	require.NoError(t, err)
+	assert.True(t, mfaAt.Equal(child.MFAAt))
+	assert.Equal(t, []string{AMRPassword, AMROTP, AMRMultiFactor}, child.AMR)
+
+	claims, err := manager.ValidateToken(rotated.AccessToken)
+	require.NoError(t, err)
+	require.NotNil(t, claims.MFAAt)
+	assert.True(t, mfaAt.Equal(claims.MFAAt.Time), "mfa_at no avanza con el refresh")
+	assert.True(t, claims.MFAFresh(15*time.Minute, time.Now()))
+
+	// Sin segundo factor la familia no tiene MFAAt.
+	plain, err := svc.Issue(ctx, "user-1", AMRPassword)
+	require.NoError(t, err)
+	claims, err = manager.ValidateToken(plain.AccessToken)
+	require.NoError(t, err)
+	assert.Nil(t, claims.MFAAt)
+}
+
+func TestRefreshTokenService_ConcurrentRotateSingleWinner(t *testing.T) {
+	svc, _ := newTestRefreshService(t)
+	ctx := t.Context()
+
+	login, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	results := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = svc.Rotate(ctx, login.RefreshToken)
+		}()
+	}
+	wg.Wait()
+
+	var ok int
+	for _, err := range results {
+		if err == nil {
+			ok++
+			continue
+		}
+		// Los perdedores ven la reutilización o la familia ya revocada.
+		assert.True(t, errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrRefreshTokenRevoked), err)
+	}
+	assert.Equal(t, 1, ok)
+}
+
+func TestRefreshTokenService_Errors(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	svc, _ := newTestRefreshService(t)
+	svc.now = clock.Now
+	ctx := t.Context()
+
+	_, err := svc.Rotate(ctx, "unknown")
+	require.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	_, err = svc.Issue(ctx, "")
+	require.Error(t, err)
+
+	login, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+	clock.Advance(time.Hour)
+	_, err = svc.Rotate(ctx, login.RefreshToken)
+	require.ErrorIs(t, err, ErrRefreshTokenExpired)
+
+	issuerErr := errors.New("user disabled")
+	failing := NewRefreshTokenService(NewInMemoryRefreshTokenStore(),
+		func(context.Context, RefreshTokenRecord) (string, time.Time, error) {
+			return "", time.Time{}, issuerErr
+		},
+		RefreshTokenServiceConfig{})
+	_, err = failing.Issue(ctx, "user-1")
+	require.ErrorIs(t, err, issuerErr)
+}
+
+func TestRefreshTokenService_RevokeUser(t *testing.T) {
+	svc, _ := newTestRefreshService(t)
+	ctx := t.Context()
+
+	a, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+	b, err := svc.Issue(ctx, "user-1")
+	require.NoError(t, err)
+	c, err := svc.Issue(ctx, "user-2")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RevokeUser(ctx, "user-1"))
+
+	_, err = svc.Rotate(ctx, a.RefreshToken)
+	require.ErrorIs(t, err, ErrRefreshTokenRevoked)
+	_, err = svc.Rotate(ctx, b.RefreshToken)
+	require.ErrorIs(t, err, ErrRefreshTokenRevoked)
+	_, err = svc.Rotate(ctx, c.RefreshToken)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.RevokeFamily(ctx, c.FamilyID))
+}
+
+func TestInMemoryRefreshTokenStore_DeleteExpired(t *testing.T) {
+	store := NewInMemoryRefreshTokenStore()
+	ctx := t.Context()
+	now := time.Now()
+
+	require.NoError(t, store.Create(ctx, RefreshTokenRecord{TokenHash: "old", FamilyID: "f1", UserID: "u", ExpiresAt: now.Add(-time.Minute)}))
+	require.NoError(t, store.Create(ctx, RefreshTokenRecord{TokenHash: "new", FamilyID: "f2", UserID: "u", ExpiresAt: now.Add(time.Hour)}))
+
+	deleted, err := store.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = store.Get(ctx, "old")
+	require.ErrorIs(t, err, ErrRefreshTokenNotFound)
+	_, err = store.Get(ctx, "new")
+	require.NoError(t, err)
+}
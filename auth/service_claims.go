@@ -43,7 +43,8 @@ func (c *ServiceClaims) HasScope(scope string) bool {
 	return false
 }
 
-// ServiceJWTManager genera y valida service JWTs (HS256). Es independiente de
+// ServiceJWTManager genera y valida service JWTs (HS256, o asimétricos con
+// NewServiceJWTManagerWithKeySet). Es independiente de
 // JWTManager (JWT de usuario): usa su PROPIO secret (`SERVICE_JWT_SECRET`,
 // distinto del secret de usuarios) y valida `aud` además de `iss`, evitando
 // que un secret comprometido de un plano afecte al otro (D14).
@@ -51,6 +52,7 @@ type ServiceJWTManager struct {
 	issuer    string
 	audience  string
 	secretKey []byte
+	keys      KeyResolver
 }
 
 // NewServiceJWTManager crea un ServiceJWTManager.
@@ -67,6 +69,30 @@ func NewServiceJWTManager(secretKey, issuer, audience string) *ServiceJWTManager
 	}
 }
 
+// NewServiceJWTManagerWithKeySet crea un ServiceJWTManager con firma
+// asimétrica: firma con la clave de firma del KeySet e incluye su `kid`; valida
+// con la clave que indique el `kid` del token. Los servicios que solo validan
+// usan un KeySet sin clave de firma (solo claves públicas), de modo que no
+// pueden emitir tokens. Con un KeySet nil GenerateServiceToken retorna
+// ErrNoKeys y todo token se rechaza.
+func NewServiceJWTManagerWithKeySet(keys *KeySet, issuer, audience string) *ServiceJWTManager {
+	return &ServiceJWTManager{
+		keys:     keySetResolver(keys),
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// NewServiceJWTManagerWithResolver crea un ServiceJWTManager solo de
+// validación con claves obtenidas de un KeyResolver (p. ej. un JWKSClient).
+func NewServiceJWTManagerWithResolver(resolver KeyResolver, issuer, audience string) *ServiceJWTManager {
+	return &ServiceJWTManager{
+		keys:     orNoKeys(resolver),
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
 // GenerateServiceToken firma un service JWT para un cliente M2M.
 //
 // Lo usan los callers (ej. edugo-worker, edugo-api-learning) para obtener un
@@ -107,8 +133,15 @@ func (m *ServiceJWTManager) GenerateServiceToken(
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(m.secretKey)
+	var (
+		signedToken string
+		err         error
+	)
+	if m.keys != nil {
+		signedToken, err = signWith(m.keys, claims)
+	} else {
+		signedToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secretKey)
+	}
 	if err != nil {
 		return "", time.Time{}, errors.NewInternalError("no se pudo firmar el service JWT", err)
 	}
@@ -116,14 +149,14 @@ func (m *ServiceJWTManager) GenerateServiceToken(
 	return signedToken, expiresAt, nil
 }
 
-// ParseServiceToken parsea y valida la firma, el método HS256, el issuer, la
-// audience y la expiración del token, retornando los claims SIN verificar el
-// `token_use`. Útil cuando el caller quiere inspeccionar antes de decidir.
+// ParseServiceToken parsea y valida la firma, el método (HS256 o los del
+// KeySet), el issuer, la audience y la expiración del token, retornando los
+// claims SIN verificar el `token_use`. Útil cuando el caller quiere inspeccionar antes de decidir.
 func (m *ServiceJWTManager) ParseServiceToken(tokenString string) (*ServiceClaims, error) {
 	parser := jwt.NewParser(
 		jwt.WithIssuer(m.issuer),
 		jwt.WithAudience(m.audience),
-		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithValidMethods(m.validMethods()),
 		// SH-1: exige claim `exp`. Un service token sin expiración (aunque
 		// esté bien firmado) se rechaza: no aceptamos credenciales M2M eternas
 		// aunque GenerateServiceToken siempre fije `exp`, la validación no debe
@@ -135,12 +168,7 @@ func (m *ServiceJWTManager) ParseServiceToken(tokenString string) (*ServiceClaim
 		jwt.WithLeeway(30*time.Second),
 	)
 
-	token, err := parser.ParseWithClaims(tokenString, &ServiceClaims{}, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.secretKey, nil
-	})
+	token, err := parser.ParseWithClaims(tokenString, &ServiceClaims{}, m.keyFunc)
 
 	if err != nil {
 		if stdErrors.Is(err, jwt.ErrTokenExpired) {
@@ -161,6 +189,23 @@ func (m *ServiceJWTManager) ParseServiceToken(tokenString string) (*ServiceClaim
 	return claims, nil
 }
 
+func (m *ServiceJWTManager) validMethods() []string {
+	if m.keys != nil {
+		return validMethods(m.keys)
+	}
+	return []string{jwt.SigningMethodHS256.Alg()}
+}
+
+func (m *ServiceJWTManager) keyFunc(token *jwt.Token) (any, error) {
+	if m.keys != nil {
+		return resolverKeyFunc(m.keys)(token)
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return m.secretKey, nil
+}
+
 // ValidateServiceToken valida un service JWT completo: firma + iss + aud + exp
 // (vía ParseServiceToken) y además exige `token_use == "service"`. Esto evita
 // que un JWT de usuario (token_use vacío o "refresh") se acepte en rutas de
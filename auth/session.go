@@ -0,0 +1,408 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Defaults de SessionRegistryConfig.
+const (
+	DefaultSessionTTL           = DefaultRefreshTokenTTL
+	DefaultSessionTouchInterval = time.Minute
+)
+
+// maxTouchEntries acota el registro local de la última actualización de
+// LastSeenAt por sesión.
+const maxTouchEntries = 10000
+
+var (
+	// ErrSessionNotFound indica que la sesión no existe, expiró o es de otro
+	// usuario.
+	ErrSessionNotFound = stdErrors.New("auth: sesión no encontrada")
+	// ErrSessionJTIRequired indica que los claims no traen jti.
+	ErrSessionJTIRequired = stdErrors.New("auth: la sesión requiere el jti del access token")
+)
+
+// SessionToken es un access token emitido dentro de una sesión.
+type SessionToken struct {
+	JTI       string    `json:"jti"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// DeviceInfo describe el dispositivo desde el que se inició una sesión.
+type DeviceInfo struct {
+	UserAgent string `json:"user_agent,omitempty"`
+	// Name es un nombre legible opcional ("iPhone de Ana").
+	Name string `json:"name,omitempty"`
+	IP   string `json:"ip,omitempty"`
+}
+
+// Session es una sesión activa: un login en un dispositivo y los access
+// tokens emitidos en ella. ID es el jti del access token vigente; cambia en
+// cada rotación del refresh token.
+type Session struct {
+	ID       string     `json:"id"`
+	UserID   string     `json:"user_id"`
+	FamilyID string     `json:"family_id,omitempty"` // familia de refresh tokens de la sesión
+	Device   DeviceInfo `json:"device"`
+	// LastIP es la IP de la última request vista (Device.IP es la del login).
+	LastIP     string    `json:"last_ip,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Tokens son los access tokens de la sesión que aún no vencen, incluido
+	// el vigente. Revocar la sesión los agrega todos a la blacklist.
+	Tokens []SessionToken `json:"tokens"`
+}
+
+// SessionStore persiste las sesiones. Las sesiones vencidas (ExpiresAt) no
+// se retornan.
+type SessionStore interface {
+	// Save crea o reemplaza la sesión s.ID.
+	Save(ctx context.Context, s Session) error
+	// Replace guarda next y borra oldID de forma atómica (rotación). Retorna
+	// ErrSessionNotFound si oldID no existe.
+	Replace(ctx context.Context, oldID string, next Session) error
+	// Get retorna la sesión, o ErrSessionNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+	// ListByUser retorna las sesiones del usuario, en cualquier orden.
+	ListByUser(ctx context.Context, userID string) ([]Session, error)
+	// Touch actualiza LastSeenAt y LastIP. Ignora sesiones inexistentes.
+	Touch(ctx context.Context, id string, at time.Time, ip string) error
+	// Delete borra las sesiones dadas. Ignora las inexistentes.
+	Delete(ctx context.Context, ids ...string) error
+}
+
+// SessionFamilyRevoker revoca la familia de refresh tokens de una sesión
+// revocada. Lo implementa RefreshTokenService.
+type SessionFamilyRevoker interface {
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+// SessionRegistryConfig configura un SessionRegistry.
+type SessionRegistryConfig struct {
+	// TTL es cuánto vive una sesión sin rotar (default DefaultSessionTTL,
+	// la vigencia del refresh token). Cada rotación la extiende.
+	TTL time.Duration
+	// TouchInterval es la frecuencia mínima con que Touch escribe LastSeenAt
+	// en el store (default DefaultSessionTouchInterval).
+	TouchInterval time.Duration
+	// Families revoca la familia de refresh tokens al revocar la sesión
+	// (opcional). Sin él, la sesión revocada puede seguir refrescando.
+	Families SessionFamilyRevoker
+}
+
+// SessionRegistry registra las sesiones de los usuarios y las revoca. La
+// revocación agrega los jti de la sesión a la TokenBlacklist (que los
+// middlewares de auth ya consultan) y revoca su familia de refresh tokens.
+// Es seguro para uso concurrente.
+type SessionRegistry struct {
+	store     SessionStore
+	blacklist TokenBlacklist
+	cfg       SessionRegistryConfig
+	now       func() time.Time // reemplazable en tests
+
+	mu      sync.Mutex
+	touched map[string]time.Time // id -> última escritura de LastSeenAt
+}
+
+// NewSessionRegistry crea un SessionRegistry.
+func NewSessionRegistry(store SessionStore, blacklist TokenBlacklist, cfg SessionRegistryConfig) *SessionRegistry {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultSessionTTL
+	}
+	if cfg.TouchInterval <= 0 {
+		cfg.TouchInterval = DefaultSessionTouchInterval
+	}
+	return &SessionRegistry{
+		store:     store,
+		blacklist: blacklist,
+		cfg:       cfg,
+		now:       time.Now,
+		touched:   make(map[string]time.Time),
+	}
+}
+
+// Record registra la sesión de un login: claims son los del access token
+// emitido y familyID la familia de su refresh token (TokenPair.FamilyID).
+func (r *SessionRegistry) Record(ctx context.Context, claims *Claims, device DeviceInfo, familyID string) (*Session, error) {
+	token, err := sessionToken(claims)
+	if err != nil {
+		return nil, err
+	}
+	now := r.now()
+	s := Session{
+		ID:         token.JTI,
+		UserID:     claims.UserID,
+		FamilyID:   familyID,
+		Device:     device,
+		LastIP:     device.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(r.cfg.TTL),
+		Tokens:     []SessionToken{token},
+	}
+	if err := r.store.Save(ctx, s); err != nil {
+		return nil, fmt.Errorf("auth: guardando sesión: %w", err)
+	}
+	return &s, nil
+}
+
+// Rotate mueve la sesión oldJTI al access token nuevo de claims, emitido al
+// rotar el refresh token. Conserva el dispositivo y los tokens anteriores
+// que aún no vencen, para poder revocarlos con la sesión.
+func (r *SessionRegistry) Rotate(ctx context.Context, oldJTI string, claims *Claims, ip string) (*Session, error) {
+	token, err := sessionToken(claims)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := r.store.Get(ctx, oldJTI)
+	if err != nil {
+		return nil, err
+	}
+	if prev.UserID != claims.UserID {
+		return nil, ErrSessionNotFound
+	}
+
+	now := r.now()
+	next := *prev
+	next.ID = token.JTI
+	next.LastSeenAt = now
+	next.ExpiresAt = now.Add(r.cfg.TTL)
+	if ip != "" {
+		next.LastIP = ip
+	}
+	next.Tokens = slices.DeleteFunc(slices.Clone(prev.Tokens), func(t SessionToken) bool {
+		return !t.ExpiresAt.After(now)
+	})
+	next.Tokens = append(next.Tokens, token)
+
+	if err := r.store.Replace(ctx, oldJTI, next); err != nil {
+		return nil, err
+	}
+	r.forgetTouch(oldJTI)
+	return &next, nil
+}
+
+// Touch actualiza LastSeenAt de la sesión del token, como mucho una vez por
+// TouchInterval por sesión e instancia.
+func (r *SessionRegistry) Touch(ctx context.Context, claims *Claims, ip string) error {
+	if claims == nil || claims.ID == "" {
+		return nil
+	}
+	now := r.now()
+	r.mu.Lock()
+	last, ok := r.touched[claims.ID]
+	if ok && now.Sub(last) < r.cfg.TouchInterval {
+		r.mu.Unlock()
+		return nil
+	}
+	if len(r.touched) >= maxTouchEntries {
+		clear(r.touched)
+	}
+	r.touched[claims.ID] = now
+	r.mu.Unlock()
+
+	if err := r.store.Touch(ctx, claims.ID, now, ip); err != nil {
+		return fmt.Errorf("auth: actualizando sesión: %w", err)
+	}
+	return nil
+}
+
+// List retorna las sesiones activas del usuario, la más reciente primero.
+func (r *SessionRegistry) List(ctx context.Context, userID string) ([]Session, error) {
+	sessions, err := r.store.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("auth: listando sesiones: %w", err)
+	}
+	slices.SortFunc(sessions, func(a, b Session) int {
+		return b.LastSeenAt.Compare(a.LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// Revoke revoca la sesión sessionID del usuario (cerrar sesión en un
+// dispositivo). Retorna ErrSessionNotFound si no existe o es de otro
+// usuario.
+func (r *SessionRegistry) Revoke(ctx context.Context, userID, sessionID string) error {
+	s, err := r.store.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if s.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return r.revoke(ctx, *s)
+}
+
+// RevokeOthers revoca todas las sesiones del usuario salvo la de currentID
+// (el jti de la request) y retorna cuántas revocó. currentID puede ser un
+// access token anterior de la sesión que aún no vence.
+func (r *SessionRegistry) RevokeOthers(ctx context.Context, userID, currentID string) (int, error) {
+	sessions, err := r.store.ListByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("auth: listando sesiones: %w", err)
+	}
+	revoked := 0
+	for _, s := range sessions {
+		if s.hasToken(currentID) {
+			continue
+		}
+		if err := r.revoke(ctx, s); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// revoke agrega los tokens de la sesión a la blacklist, revoca la familia y
+// borra la sesión. Si la blacklist implementa ContextTokenRevoker y falla,
+// no sigue: la sesión queda listada y se puede reintentar.
+func (r *SessionRegistry) revoke(ctx context.Context, s Session) error {
+	for _, t := range s.Tokens {
+		if err := revokeToken(ctx, r.blacklist, t.JTI, t.ExpiresAt); err != nil {
+			return fmt.Errorf("auth: revocando token de la sesión: %w", err)
+		}
+	}
+	if r.cfg.Families != nil && s.FamilyID != "" {
+		if err := r.cfg.Families.RevokeFamily(ctx, s.FamilyID); err != nil {
+			return fmt.Errorf("auth: revocando familia de la sesión: %w", err)
+		}
+	}
+	if err := r.store.Delete(ctx, s.ID); err != nil {
+		return fmt.Errorf("auth: borrando sesión: %w", err)
+	}
+	r.forgetTouch(s.ID)
+	return nil
+}
+
+// hasToken reporta si jti es el ID de la sesión o uno de sus tokens.
+func (s Session) hasToken(jti string) bool {
+	return s.ID == jti || slices.ContainsFunc(s.Tokens, func(t SessionToken) bool { return t.JTI == jti })
+}
+
+func (r *SessionRegistry) forgetTouch(id string) {
+	r.mu.Lock()
+	delete(r.touched, id)
+	r.mu.Unlock()
+}
+
+func sessionToken(claims *Claims) (SessionToken, error) {
+	if claims == nil || claims.ID == "" {
+		return SessionToken{}, ErrSessionJTIRequired
+	}
+	t := SessionToken{JTI: claims.ID}
+	if claims.ExpiresAt != nil {
+		t.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return t, nil
+}
+
+// InMemorySessionStore implementa SessionStore en memoria, para tests y
+// despliegues de una sola instancia. Es seguro para uso concurrente.
+type InMemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+	now      func() time.Time
+}
+
+// NewInMemorySessionStore crea un InMemorySessionStore.
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: make(map[string]Session), now: time.Now}
+}
+
+// Save implementa SessionStore.
+func (s *InMemorySessionStore) Save(_ context.Context, session Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(session)
+	return nil
+}
+
+// Replace implementa SessionStore.
+func (s *InMemorySessionStore) Replace(_ context.Context, oldID string, next Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.live(oldID); !ok {
+		return ErrSessionNotFound
+	}
+	delete(s.sessions, oldID)
+	s.put(next)
+	return nil
+}
+
+// Get implementa SessionStore.
+func (s *InMemorySessionStore) Get(_ context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.live(id)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	session.Tokens = slices.Clone(session.Tokens)
+	return &session, nil
+}
+
+// ListByUser implementa SessionStore.
+func (s *InMemorySessionStore) ListByUser(_ context.Context, userID string) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var out []Session
+	for id, session := range s.sessions {
+		if !session.ExpiresAt.After(now) {
+			delete(s.sessions, id)
+			continue
+		}
+		if session.UserID == userID {
+			session.Tokens = slices.Clone(session.Tokens)
+			out = append(out, session)
+		}
+	}
+	return out, nil
+}
+
+// Touch implementa SessionStore.
+func (s *InMemorySessionStore) Touch(_ context.Context, id string, at time.Time, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.live(id)
+	if !ok {
+		return nil
+	}
+	session.LastSeenAt = at
+	if ip != "" {
+		session.LastIP = ip
+	}
+	s.sessions[id] = session
+	return nil
+}
+
+// Delete implementa SessionStore.
+func (s *InMemorySessionStore) Delete(_ context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.sessions, id)
+	}
+	return nil
+}
+
+func (s *InMemorySessionStore) put(session Session) {
+	session.Tokens = slices.Clone(session.Tokens)
+	s.sessions[session.ID] = session
+}
+
+// live retorna la sesión si existe y no venció. Requiere s.mu tomado.
+func (s *InMemorySessionStore) live(id string) (Session, bool) {
+	session, ok := s.sessions[id]
+	if !ok || !session.ExpiresAt.After(s.now()) {
+		return Session{}, false
+	}
+	return session, true
+}
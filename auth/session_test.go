@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sessionClaims(userID, jti string, expiresAt time.Time) *Claims {
+	return &Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+}
+
+type familyRevokerSpy struct{ revoked []string }
+
+func (f *familyRevokerSpy) RevokeFamily(_ context.Context, familyID string) error {
+	f.revoked = append(f.revoked, familyID)
+	return nil
+}
+
+func newTestSessionRegistry(t *testing.T, clock *fakeClock) (*SessionRegistry, *InMemoryBlacklist, *familyRevokerSpy) {
+	t.Helper()
+	bl := NewInMemoryBlacklist(t.Context())
+	families := &familyRevokerSpy{}
+	r := NewSessionRegistry(NewInMemorySessionStore(), bl, SessionRegistryConfig{
+		TouchInterval: time.Minute,
+		Families:      families,
+	})
+	r.now = clock.Now
+	return r, bl, families
+}
+
+func TestSessionRegistry_RecordListAndTouch(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	r, _, _ := newTestSessionRegistry(t, clock)
+	ctx := context.Background()
+
+	_, err := r.Record(ctx, sessionClaims("user-1", "jti-laptop", clock.Now().Add(15*time.Minute)),
+		DeviceInfo{UserAgent: "Firefox", IP: "10.0.0.1"}, "fam-1")
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	_, err = r.Record(ctx, sessionClaims("user-1", "jti-phone", clock.Now().Add(15*time.Minute)),
+		DeviceInfo{UserAgent: "EduGo iOS", Name: "iPhone", IP: "10.0.0.2"}, "fam-2")
+	require.NoError(t, err)
+	_, err = r.Record(ctx, sessionClaims("user-2", "jti-other", clock.Now().Add(15*time.Minute)), DeviceInfo{}, "fam-3")
+	require.NoError(t, err)
+
+	sessions, err := r.List(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "jti-phone", sessions[0].ID, "la más reciente primero")
+	assert.Equal(t, "iPhone", sessions[0].Device.Name)
+
+	// Touch respeta TouchInterval.
+	clock.Advance(10 * time.Second)
+	require.NoError(t, r.Touch(ctx, sessionClaims("user-1", "jti-laptop", clock.Now()), "10.0.0.9"))
+	clock.Advance(10 * time.Second)
+	require.NoError(t, r.Touch(ctx, sessionClaims("user-1", "jti-laptop", clock.Now()), "10.0.0.10"))
+
+	sessions, err = r.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "jti-laptop", sessions[0].ID)
+	assert.Equal(t, "10.0.0.9", sessions[0].LastIP)
+	assert.Equal(t, "10.0.0.1", sessions[0].Device.IP)
+
+	_, err = r.Record(ctx, &Claims{UserID: "user-1"}, DeviceInfo{}, "")
+	assert.ErrorIs(t, err, ErrSessionJTIRequired)
+}
+
+func TestSessionRegistry_RotateKeepsLiveTokens(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	r, bl, families := newTestSessionRegistry(t, clock)
+	ctx := context.Background()
+
+	_, err := r.Record(ctx, sessionClaims("user-1", "jti-1", clock.Now().Add(15*time.Minute)), DeviceInfo{UserAgent: "Firefox"}, "fam-1")
+	require.NoError(t, err)
+
+	clock.Advance(10 * time.Minute)
+	s, err := r.Rotate(ctx, "jti-1", sessionClaims("user-1", "jti-2", clock.Now().Add(15*time.Minute)), "10.0.0.5")
+	require.NoError(t, err)
+	assert.Equal(t, "jti-2", s.ID)
+	assert.Equal(t, "Firefox", s.Device.UserAgent)
+	assert.Len(t, s.Tokens, 2, "jti-1 aún no vence")
+
+	_, err = r.Rotate(ctx, "jti-1", sessionClaims("user-1", "jti-3", clock.Now().Add(15*time.Minute)), "")
+	assert.ErrorIs(t, err, ErrSessionNotFound, "la sesión ya no está bajo el jti viejo")
+
+	// Otro usuario no puede rotar ni revocar la sesión.
+	_, err = r.Rotate(ctx, "jti-2", sessionClaims("user-2", "jti-x", clock.Now().Add(15*time.Minute)), "")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.ErrorIs(t, r.Revoke(ctx, "user-2", "jti-2"), ErrSessionNotFound)
+
+	// Revocar la sesión revoca ambos access tokens y la familia.
+	require.NoError(t, r.Revoke(ctx, "user-1", "jti-2"))
+	assert.True(t, bl.IsRevoked("jti-1"))
+	assert.True(t, bl.IsRevoked("jti-2"))
+	assert.Equal(t, []string{"fam-1"}, families.revoked)
+
+	sessions, err := r.List(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestSessionRegistry_RevokeOthers(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	r, bl, families := newTestSessionRegistry(t, clock)
+	ctx := context.Background()
+
+	for i, jti := range []string{"jti-a", "jti-b", "jti-c"} {
+		_, err := r.Record(ctx, sessionClaims("user-1", jti, clock.Now().Add(15*time.Minute)), DeviceInfo{}, "fam-"+string(rune('a'+i)))
+		require.NoError(t, err)
+	}
+
+	n, err := r.RevokeOthers(ctx, "user-1", "jti-b")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, bl.IsRevoked("jti-a"))
+	assert.False(t, bl.IsRevoked("jti-b"))
+	assert.True(t, bl.IsRevoked("jti-c"))
+	assert.ElementsMatch(t, []string{"fam-a", "fam-c"}, families.revoked)
+
+	sessions, err := r.List(ctx, "user-1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "jti-b", sessions[0].ID)
+
+	// Tras rotar, el access token anterior (aún vigente) sigue identificando
+	// la sesión de la request.
+	_, err = r.Rotate(ctx, "jti-b", sessionClaims("user-1", "jti-b2", clock.Now().Add(15*time.Minute)), "")
+	require.NoError(t, err)
+	n, err = r.RevokeOthers(ctx, "user-1", "jti-b")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.False(t, bl.IsRevoked("jti-b2"))
+}
+
+// failingBlacklist simula una blacklist con almacenamiento caído.
+type failingBlacklist struct{ NoOpBlacklist }
+
+func (*failingBlacklist) RevokeContext(context.Context, string, time.Time) error {
+	return errors.New("redis caído")
+}
+
+func TestSessionRegistry_RevokeStopsWhenBlacklistFails(t *testing.T) {
+	now := time.Now()
+	families := &familyRevokerSpy{}
+	r := NewSessionRegistry(NewInMemorySessionStore(), &failingBlacklist{}, SessionRegistryConfig{Families: families})
+	ctx := context.Background()
+
+	_, err := r.Record(ctx, sessionClaims("user-1", "jti-1", now.Add(15*time.Minute)), DeviceInfo{}, "fam-1")
+	require.NoError(t, err)
+
+	require.Error(t, r.Revoke(ctx, "user-1", "jti-1"))
+	assert.Empty(t, families.revoked, "no revoca la familia si la blacklist falló")
+	_, err = r.store.Get(ctx, "jti-1")
+	assert.NoError(t, err, "la sesión sigue listada para reintentar")
+}
+
+func TestInMemorySessionStore_ExpiredSessions(t *testing.T) {
+	store := NewInMemorySessionStore()
+	ctx := context.Background()
+	require.NoError(t, store.Save(ctx, Session{ID: "old", UserID: "u1", ExpiresAt: time.Now().Add(-time.Second)}))
+	require.NoError(t, store.Save(ctx, Session{ID: "new", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+
+	_, err := store.Get(ctx, "old")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	sessions, err := store.ListByUser(ctx, "u1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "new", sessions[0].ID)
+}
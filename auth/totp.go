@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // G505: HMAC-SHA1 es el algoritmo por defecto de RFC 6238 y de las apps autenticadoras
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	stdErrors "errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Valores por defecto de TOTPConfig (los que entienden todas las apps
+// autenticadoras).
+const (
+	DefaultTOTPDigits = 6
+	DefaultTOTPPeriod = 30 * time.Second
+	DefaultTOTPSkew   = 1
+	// totpSecretSize es el largo del secreto en bytes (160 bits, RFC 4226 §4).
+	totpSecretSize = 20
+)
+
+// Algoritmos HMAC soportados por TOTP.
+const (
+	TOTPAlgorithmSHA1   = "SHA1"
+	TOTPAlgorithmSHA256 = "SHA256"
+	TOTPAlgorithmSHA512 = "SHA512"
+)
+
+var (
+	// ErrTOTPInvalid indica un código TOTP incorrecto o fuera de la ventana.
+	ErrTOTPInvalid = stdErrors.New("auth: código TOTP inválido")
+	// ErrTOTPReplayed indica un código TOTP ya usado (mismo paso o anterior).
+	ErrTOTPReplayed = stdErrors.New("auth: código TOTP ya utilizado")
+	// ErrTOTPSecretInvalid indica un secreto que no es base32 válido.
+	ErrTOTPSecretInvalid = stdErrors.New("auth: secreto TOTP inválido")
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPConfig configura un TOTP.
+type TOTPConfig struct {
+	// Issuer es el nombre que muestra la app autenticadora (p. ej. "EduGo").
+	Issuer string
+	// Digits es el largo del código: 6 (default) u 8.
+	Digits int
+	// Period es la duración de cada paso (default 30s).
+	Period time.Duration
+	// Skew es la cantidad de pasos aceptados antes y después del actual
+	// para tolerar el desfase de reloj del dispositivo (default 1 → ±30s).
+	// Un valor negativo desactiva la tolerancia.
+	Skew int
+	// Algorithm es el HMAC: SHA1 (default), SHA256 o SHA512. Varias apps
+	// ignoran el parámetro y siempre usan SHA1.
+	Algorithm string
+}
+
+// TOTP genera y verifica códigos de un solo uso basados en tiempo (RFC 6238).
+type TOTP struct {
+	cfg TOTPConfig
+}
+
+// NewTOTP crea un TOTP aplicando los defaults de TOTPConfig.
+func NewTOTP(cfg TOTPConfig) (*TOTP, error) {
+	if cfg.Digits == 0 {
+		cfg.Digits = DefaultTOTPDigits
+	}
+	if cfg.Digits != 6 && cfg.Digits != 8 {
+		return nil, fmt.Errorf("auth: TOTP digits debe ser 6 u 8, recibido %d", cfg.Digits)
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = DefaultTOTPPeriod
+	}
+	if cfg.Period%time.Second != 0 {
+		return nil, fmt.Errorf("auth: TOTP period debe ser un número entero de segundos")
+	}
+	switch {
+	case cfg.Skew == 0:
+		cfg.Skew = DefaultTOTPSkew
+	case cfg.Skew < 0:
+		cfg.Skew = 0
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = TOTPAlgorithmSHA1
+	}
+	if totpHash(cfg.Algorithm) == nil {
+		return nil, fmt.Errorf("auth: algoritmo TOTP no soportado: %q", cfg.Algorithm)
+	}
+	return &TOTP{cfg: cfg}, nil
+}
+
+// GenerateTOTPSecret genera un secreto aleatorio de 160 bits en base32 sin
+// padding, listo para ProvisioningURI. Debe guardarse cifrado (p. ej. con
+// crypto/envelope): a diferencia de un password no se puede guardar hasheado.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("auth: generando secreto TOTP: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// Step retorna el número de paso (contador T de RFC 6238) para el instante t.
+func (o *TOTP) Step(t time.Time) int64 {
+	return t.Unix() / int64(o.cfg.Period/time.Second)
+}
+
+// Code retorna el código del instante t.
+func (o *TOTP) Code(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return o.code(key, o.Step(t)), nil
+}
+
+// Verify valida el código contra los pasos de la ventana [actual-Skew,
+// actual+Skew] y retorna el paso que coincidió, que el llamador debe
+// persistir junto al secreto. lastUsedStep es el último paso aceptado para
+// este usuario (0 si nunca): un código de ese paso o de uno anterior se
+// rechaza con ErrTOTPReplayed, de modo que un código interceptado no puede
+// reutilizarse dentro de su ventana (RFC 6238 §5.2).
+func (o *TOTP) Verify(secret, code string, lastUsedStep int64, now time.Time) (int64, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != o.cfg.Digits {
+		return 0, ErrTOTPInvalid
+	}
+	current := o.Step(now)
+	replayed := false
+	for delta := -o.cfg.Skew; delta <= o.cfg.Skew; delta++ {
+		step := current + int64(delta)
+		if subtle.ConstantTimeCompare([]byte(o.code(key, step)), []byte(code)) != 1 {
+			continue
+		}
+		if step <= lastUsedStep {
+			replayed = true
+			continue
+		}
+		return step, nil
+	}
+	if replayed {
+		return 0, ErrTOTPReplayed
+	}
+	return 0, ErrTOTPInvalid
+}
+
+// ProvisioningURI retorna el URI otpauth:// (formato Key URI de Google
+// Authenticator) para mostrar como QR al enrolar. accountName suele ser el
+// email del usuario.
+func (o *TOTP) ProvisioningURI(secret, accountName string) string {
+	label := url.PathEscape(accountName)
+	if o.cfg.Issuer != "" {
+		label = url.PathEscape(o.cfg.Issuer) + ":" + label
+	}
+	q := url.Values{}
+	q.Set("secret", strings.ToUpper(strings.TrimRight(secret, "=")))
+	if o.cfg.Issuer != "" {
+		q.Set("issuer", o.cfg.Issuer)
+	}
+	q.Set("algorithm", o.cfg.Algorithm)
+	q.Set("digits", strconv.Itoa(o.cfg.Digits))
+	q.Set("period", strconv.Itoa(int(o.cfg.Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// code calcula HOTP(key, step) truncado a Digits (RFC 4226 §5.3).
+func (o *TOTP) code(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step)) //nolint:gosec // G115: step es siempre >= 0 para tiempos posteriores a 1970
+	mac := hmac.New(totpHash(o.cfg.Algorithm), key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1_000_000)
+	if o.cfg.Digits == 8 {
+		mod = 100_000_000
+	}
+	return fmt.Sprintf("%0*d", o.cfg.Digits, value%mod)
+}
+
+func totpHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case TOTPAlgorithmSHA1:
+		return sha1.New
+	case TOTPAlgorithmSHA256:
+		return sha256.New
+	case TOTPAlgorithmSHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+	key, err := totpEncoding.DecodeString(normalized)
+	if err != nil || len(key) == 0 {
+		return nil, ErrTOTPSecretInvalid
+	}
+	return key, nil
+}
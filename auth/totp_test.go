@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Secretos ASCII de los vectores de prueba de RFC 6238, apéndice B.
+var (
+	rfcSecretSHA1   = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	rfcSecretSHA256 = base32.StdEncoding.EncodeToString([]byte("12345678901234567890123456789012"))
+	rfcSecretSHA512 = base32.StdEncoding.EncodeToString([]byte("1234567890123456789012345678901234567890123456789012345678901234"))
+)
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+	cases := []struct {
+		algorithm string
+		secret    string
+		pick      func(i int) string
+	}{
+		{TOTPAlgorithmSHA1, rfcSecretSHA1, func(i int) string { return tests[i].sha1 }},
+		{TOTPAlgorithmSHA256, rfcSecretSHA256, func(i int) string { return tests[i].sha256 }},
+		{TOTPAlgorithmSHA512, rfcSecretSHA512, func(i int) string { return tests[i].sha512 }},
+	}
+	for _, c := range cases {
+		otp, err := NewTOTP(TOTPConfig{Digits: 8, Algorithm: c.algorithm})
+		require.NoError(t, err)
+		for i, tt := range tests {
+			code, err := otp.Code(c.secret, time.Unix(tt.unix, 0))
+			require.NoError(t, err)
+			assert.Equal(t, c.pick(i), code, "%s @ %d", c.algorithm, tt.unix)
+		}
+	}
+}
+
+func TestTOTP_VerifyWindowAndReplay(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	otp, err := NewTOTP(TOTPConfig{Issuer: "EduGo"})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 5, 4, 10, 0, 15, 0, time.UTC)
+	code, err := otp.Code(secret, now)
+	require.NoError(t, err)
+	assert.Len(t, code, 6)
+
+	step, err := otp.Verify(secret, code, 0, now)
+	require.NoError(t, err)
+	assert.Equal(t, otp.Step(now), step)
+
+	// El mismo código no se acepta dos veces.
+	_, err = otp.Verify(secret, code, step, now)
+	require.ErrorIs(t, err, ErrTOTPReplayed)
+
+	// Desfase de reloj de un paso: aceptado.
+	prev, err := otp.Code(secret, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	got, err := otp.Verify(secret, prev, 0, now)
+	require.NoError(t, err)
+	assert.Equal(t, step-1, got)
+
+	// Un código anterior al último usado es también replay.
+	_, err = otp.Verify(secret, prev, step, now)
+	require.ErrorIs(t, err, ErrTOTPReplayed)
+
+	// Dos pasos de desfase: fuera de la ventana.
+	old, err := otp.Code(secret, now.Add(-90*time.Second))
+	require.NoError(t, err)
+	_, err = otp.Verify(secret, old, 0, now)
+	require.ErrorIs(t, err, ErrTOTPInvalid)
+
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		_, err = otp.Verify(secret, bad, 0, now)
+		require.ErrorIs(t, err, ErrTOTPInvalid, bad)
+	}
+	_, err = otp.Verify("no es base32!", code, 0, now)
+	require.ErrorIs(t, err, ErrTOTPSecretInvalid)
+}
+
+func TestTOTP_NoSkew(t *testing.T) {
+	otp, err := NewTOTP(TOTPConfig{Skew: -1})
+	require.NoError(t, err)
+	now := time.Unix(1_800_000_000, 0)
+	prev, err := otp.Code(rfcSecretSHA1, now.Add(-30*time.Second))
+	require.NoError(t, err)
+	_, err = otp.Verify(rfcSecretSHA1, prev, 0, now)
+	require.ErrorIs(t, err, ErrTOTPInvalid)
+}
+
+func TestNewTOTP_InvalidConfig(t *testing.T) {
+	_, err := NewTOTP(TOTPConfig{Digits: 7})
+	require.Error(t, err)
+	_, err = NewTOTP(TOTPConfig{Period: 1500 * time.Millisecond})
+	require.Error(t, err)
+	_, err = NewTOTP(TOTPConfig{Algorithm: "MD5"})
+	require.Error(t, err)
+}
+
+func TestTOTP_ProvisioningURI(t *testing.T) {
+	otp, err := NewTOTP(TOTPConfig{Issuer: "EduGo Admin"})
+	require.NoError(t, err)
+
+	raw := otp.ProvisioningURI("jbswy3dpehpk3pxp", "ana@edugo.com")
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/EduGo Admin:ana@edugo.com", u.Path)
+	assert.True(t, strings.HasPrefix(raw, "otpauth://totp/EduGo%20Admin:ana@edugo.com?"))
+
+	q := u.Query()
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", q.Get("secret"))
+	assert.Equal(t, "EduGo Admin", q.Get("issuer"))
+	assert.Equal(t, "SHA1", q.Get("algorithm"))
+	assert.Equal(t, "6", q.Get("digits"))
+	assert.Equal(t, "30", q.Get("period"))
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	a, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	b, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, a, 32) // 20 bytes en base32 sin padding
+	assert.NotEqual(t, a, b)
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(0)
+	require.NoError(t, err)
+	require.Len(t, codes, DefaultRecoveryCodeCount)
+	require.Len(t, hashes, DefaultRecoveryCodeCount)
+	for i, c := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, c)
+		assert.Equal(t, HashRecoveryCode(c), hashes[i])
+		assert.NotContains(t, hashes[i], c)
+	}
+
+	// El usuario puede tipearlo en mayúsculas y sin guion.
+	typed := strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))
+	remaining, ok := ConsumeRecoveryCode(typed, hashes)
+	require.True(t, ok)
+	assert.Len(t, remaining, DefaultRecoveryCodeCount-1)
+	assert.NotContains(t, remaining, hashes[3])
+
+	// De un solo uso.
+	_, ok = ConsumeRecoveryCode(codes[3], remaining)
+	assert.False(t, ok)
+
+	unchanged, ok := ConsumeRecoveryCode("zzzzz-zzzzz", remaining)
+	assert.False(t, ok)
+	assert.Equal(t, remaining, unchanged)
+}
+
+func TestTokenOptions_AMRAuthTimeAndMFA(t *testing.T) {
+	manager := NewJWTManager("test-secret-32-chars-minimum-123456", "edugo-central")
+	mfaAt := time.Now().Add(-2 * time.Minute).Truncate(time.Second)
+	authAt := mfaAt.Add(-time.Minute)
+
+	token, _, err := manager.GenerateTokenWithContext("user-1", "u@edugo.com", testActiveContext(), time.Hour,
+		WithAMR(AMRPassword, AMROTP), WithAuthTime(authAt), WithMFA(mfaAt))
+	require.NoError(t, err)
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{AMRPassword, AMROTP, AMRMultiFactor}, claims.AMR)
+	assert.True(t, claims.HasAMR(AMROTP))
+	require.NotNil(t, claims.MFAAt)
+	assert.True(t, mfaAt.Equal(claims.MFAAt.Time))
+	assert.True(t, claims.MFAFresh(5*time.Minute, time.Now()))
+	assert.False(t, claims.MFAFresh(time.Minute, time.Now()))
+	assert.True(t, claims.MFAFresh(0, time.Now()))
+	require.NotNil(t, claims.AuthTime)
+	assert.True(t, authAt.Equal(claims.AuthTime.Time))
+	assert.True(t, claims.AuthFresh(5*time.Minute, time.Now()))
+	assert.False(t, claims.AuthFresh(2*time.Minute, time.Now()))
+
+	// El refresh preserva los claims de autenticación.
+	refresh, _, err := manager.GenerateMinimalToken("user-1", "u@edugo.com", "", "", "", "", "", time.Hour, claims.AuthOptions()...)
+	require.NoError(t, err)
+	refreshClaims, err := manager.ValidateMinimalToken(refresh)
+	require.NoError(t, err)
+	assert.Equal(t, claims.AMR, refreshClaims.AMR)
+	assert.True(t, mfaAt.Equal(refreshClaims.MFAAt.Time))
+	assert.True(t, authAt.Equal(refreshClaims.AuthTime.Time))
+
+	// Sin opciones no hay claims de autenticación.
+	plain, _, err := manager.GenerateTokenWithContext("user-1", "u@edugo.com", testActiveContext(), time.Hour)
+	require.NoError(t, err)
+	plainClaims, err := manager.ValidateToken(plain)
+	require.NoError(t, err)
+	assert.Empty(t, plainClaims.AMR)
+	assert.False(t, plainClaims.MFAFresh(0, time.Now()))
+	assert.False(t, plainClaims.AuthFresh(0, time.Now()))
+	assert.Empty(t, plainClaims.AuthOptions())
+}
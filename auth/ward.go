@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWardSubjectMissing indica un token en ActorModeWard sin
+	// SubjectStudentID.
+	ErrWardSubjectMissing = stdErrors.New("auth: token de representante sin acudido")
+	// ErrWardNotLinked indica que el usuario no es representante del
+	// acudido del token.
+	ErrWardNotLinked = stdErrors.New("auth: el usuario no es representante del acudido")
+)
+
+// WardResolver confirma el vínculo representante → acudido (ADR 0026). El
+// JWT solo transporta SubjectStudentID/ActorMode; quién está autorizado a
+// ver a quién vive en la BD de identity.
+type WardResolver interface {
+	IsGuardianOf(ctx context.Context, guardianID, studentID string) (bool, error)
+}
+
+// WardResolverFunc adapta una función a WardResolver.
+type WardResolverFunc func(ctx context.Context, guardianID, studentID string) (bool, error)
+
+// IsGuardianOf implementa WardResolver.
+func (f WardResolverFunc) IsGuardianOf(ctx context.Context, guardianID, studentID string) (bool, error) {
+	return f(ctx, guardianID, studentID)
+}
+
+// WardSubjectID retorna el acudido del contexto activo (o, sin él, el del
+// snapshot en la raíz del token) cuando el modo de actor es ActorModeWard;
+// vacío en contexto propio.
+func (c *Claims) WardSubjectID() string {
+	if ac := c.ActiveContext; ac != nil {
+		if ac.ActorMode == ActorModeWard {
+			return ac.SubjectStudentID
+		}
+		return ""
+	}
+	if c.ActorMode == ActorModeWard {
+		return c.SubjectStudentID
+	}
+	return ""
+}
+
+// IsWard reporta si el token actúa en modo representante.
+func (c *Claims) IsWard() bool {
+	if c.ActiveContext != nil {
+		return c.ActiveContext.ActorMode == ActorModeWard
+	}
+	return c.ActorMode == ActorModeWard
+}
+
+// ValidateWard verifica con resolver que el usuario del token sea
+// representante del acudido. Los tokens en contexto propio pasan sin
+// consultar. Retorna ErrWardSubjectMissing, ErrWardNotLinked o el error del
+// resolver envuelto.
+func ValidateWard(ctx context.Context, resolver WardResolver, claims *Claims) error {
+	if !claims.IsWard() {
+		return nil
+	}
+	studentID := claims.WardSubjectID()
+	if studentID == "" {
+		return ErrWardSubjectMissing
+	}
+	linked, err := resolver.IsGuardianOf(ctx, claims.UserID, studentID)
+	if err != nil {
+		return fmt.Errorf("auth: validar representante: %w", err)
+	}
+	if !linked {
+		return ErrWardNotLinked
+	}
+	return nil
+}
+
+// Defaults de CachedWardResolverConfig.
+const (
+	DefaultWardCacheTTL         = 5 * time.Minute
+	DefaultWardCacheNegativeTTL = 30 * time.Second
+	DefaultWardCacheMaxEntries  = 10000
+)
+
+// CachedWardResolverConfig configura un CachedWardResolver.
+type CachedWardResolverConfig struct {
+	// TTL de un vínculo confirmado (default DefaultWardCacheTTL). Es el
+	// tiempo máximo que un vínculo revocado sigue aceptándose si no se
+	// llama a Invalidate.
+	TTL time.Duration
+	// NegativeTTL de un vínculo inexistente (default
+	// DefaultWardCacheNegativeTTL): corto, para que un vínculo recién creado
+	// se vea pronto.
+	NegativeTTL time.Duration
+	// MaxEntries acota la caché (default DefaultWardCacheMaxEntries). Al
+	// llenarse se descartan las entradas vencidas y, si no alcanza, la
+	// respuesta nueva no se cachea.
+	MaxEntries int
+}
+
+// CachedWardResolver envuelve un WardResolver con una caché en memoria de
+// las respuestas, para no consultar identity en cada request de un
+// representante. Los errores no se cachean. Es seguro para uso concurrente.
+type CachedWardResolver struct {
+	next    WardResolver
+	cfg     CachedWardResolverConfig
+	now     func() time.Time // reemplazable en tests
+	mu      sync.Mutex
+	entries map[wardKey]wardEntry
+	// loads cuenta las consultas a next en curso por par; Invalidate sube
+	// su generación para que no se cachee una respuesta previa.
+	loads map[wardKey]*wardLoad
+}
+
+type wardKey struct{ guardianID, studentID string }
+
+type wardLoad struct {
+	callers int
+	gen     uint64
+}
+
+type wardEntry struct {
+	linked    bool
+	expiresAt time.Time
+}
+
+// NewCachedWardResolver crea un CachedWardResolver sobre next.
+func NewCachedWardResolver(next WardResolver, cfg CachedWardResolverConfig) *CachedWardResolver {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultWardCacheTTL
+	}
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = DefaultWardCacheNegativeTTL
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultWardCacheMaxEntries
+	}
+	return &CachedWardResolver{
+		next:    next,
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[wardKey]wardEntry),
+		loads:   make(map[wardKey]*wardLoad),
+	}
+}
+
+// IsGuardianOf implementa WardResolver.
+func (r *CachedWardResolver) IsGuardianOf(ctx context.Context, guardianID, studentID string) (bool, error) {
+	key := wardKey{guardianID, studentID}
+	now := r.now()
+
+	r.mu.Lock()
+	e, ok := r.entries[key]
+	if ok && now.Before(e.expiresAt) {
+		r.mu.Unlock()
+		return e.linked, nil
+	}
+	load := r.loads[key]
+	if load == nil {
+		load = &wardLoad{}
+		r.loads[key] = load
+	}
+	load.callers++
+	gen := load.gen
+	r.mu.Unlock()
+
+	linked, err := r.next.IsGuardianOf(ctx, guardianID, studentID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	load.callers--
+	if load.callers == 0 {
+		delete(r.loads, key)
+	}
+	if err != nil {
+		return false, err
+	}
+	if load.gen != gen {
+		// Invalidate llegó durante la consulta: la respuesta puede ser previa
+		// al cambio del vínculo.
+		return linked, nil
+	}
+	ttl := r.cfg.TTL
+	if !linked {
+		ttl = r.cfg.NegativeTTL
+	}
+	if len(r.entries) >= r.cfg.MaxEntries {
+		for k, e := range r.entries {
+			if !now.Before(e.expiresAt) {
+				delete(r.entries, k)
+			}
+		}
+	}
+	if len(r.entries) < r.cfg.MaxEntries {
+		r.entries[key] = wardEntry{linked: linked, expiresAt: now.Add(ttl)}
+	}
+	return linked, nil
+}
+
+// Invalidate descarta la respuesta cacheada para el par; llamarlo al crear
+// o revocar un vínculo.
+func (r *CachedWardResolver) Invalidate(guardianID, studentID string) {
+	key := wardKey{guardianID, studentID}
+	r.mu.Lock()
+	delete(r.entries, key)
+	if load := r.loads[key]; load != nil {
+		load.gen++
+	}
+	r.mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func wardClaims(guardianID, studentID string) *Claims {
+	return &Claims{
+		UserID: guardianID,
+		ActiveContext: &UserContext{
+			RoleID:           "role-guardian",
+			SubjectStudentID: studentID,
+			ActorMode:        ActorModeWard,
+		},
+	}
+}
+
+func TestValidateWard(t *testing.T) {
+	links := map[[2]string]bool{{"guardian-1", "student-1"}: true}
+	errDB := stdErrors.New("db down")
+	resolver := WardResolverFunc(func(_ context.Context, guardianID, studentID string) (bool, error) {
+		if studentID == "student-err" {
+			return false, errDB
+		}
+		return links[[2]string{guardianID, studentID}], nil
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		claims  *Claims
+		wantErr error
+	}{
+		{"contexto propio no consulta", &Claims{UserID: "u1", ActiveContext: &UserContext{}}, nil},
+		{"vínculo válido", wardClaims("guardian-1", "student-1"), nil},
+		{"vínculo inexistente", wardClaims("guardian-1", "student-2"), ErrWardNotLinked},
+		{"sin acudido", wardClaims("guardian-1", ""), ErrWardSubjectMissing},
+		{"error del resolver", wardClaims("guardian-1", "student-err"), errDB},
+		{
+			"snapshot de refresh",
+			&Claims{UserID: "guardian-1", SubjectStudentID: "student-2", ActorMode: ActorModeWard},
+			ErrWardNotLinked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWard(ctx, resolver, tt.claims)
+			if !stdErrors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateWard() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCachedWardResolver(t *testing.T) {
+	calls := 0
+	linked := true
+	now := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	r := NewCachedWardResolver(WardResolverFunc(func(context.Context, string, string) (bool, error) {
+		calls++
+		return linked, nil
+	}), CachedWardResolverConfig{
+		TTL:         time.Minute,
+		NegativeTTL: 10 * time.Second,
+	})
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 3 {
+		if ok, err := r.IsGuardianOf(ctx, "g1", "s1"); err != nil || !ok {
+			t.Fatalf("IsGuardianOf = %v, %v", ok, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (cacheado)", calls)
+	}
+
+	// Revocado en la fuente: sigue cacheado hasta el TTL o Invalidate.
+	linked = false
+	r.Invalidate("g1", "s1")
+	if ok, _ := r.IsGuardianOf(ctx, "g1", "s1"); ok {
+		t.Fatal("tras Invalidate debe consultar de nuevo")
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+
+	// La respuesta negativa usa NegativeTTL.
+	linked = true
+	now = now.Add(5 * time.Second)
+	if ok, _ := r.IsGuardianOf(ctx, "g1", "s1"); ok {
+		t.Fatal("la respuesta negativa debe seguir cacheada")
+	}
+	now = now.Add(10 * time.Second)
+	if ok, _ := r.IsGuardianOf(ctx, "g1", "s1"); !ok {
+		t.Fatal("vencido el NegativeTTL debe consultar de nuevo")
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestCachedWardResolver_ErrorsNotCached(t *testing.T) {
+	calls := 0
+	r := NewCachedWardResolver(WardResolverFunc(func(context.Context, string, string) (bool, error) {
+		calls++
+		return false, stdErrors.New("timeout")
+	}), CachedWardResolverConfig{})
+	for range 2 {
+		if _, err := r.IsGuardianOf(context.Background(), "g1", "s1"); err == nil {
+			t.Fatal("esperaba error")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 (los errores no se cachean)", calls)
+	}
+}
+
+func TestCachedWardResolver_InvalidateDuringLoad(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := NewCachedWardResolver(WardResolverFunc(func(context.Context, string, string) (bool, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release // identity responde con el vínculo aún vigente
+		}
+		return true, nil
+	}), CachedWardResolverConfig{TTL: time.Hour})
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if ok, err := r.IsGuardianOf(ctx, "g1", "s1"); err != nil || !ok {
+			t.Errorf("IsGuardianOf = %v, %v", ok, err)
+		}
+	}()
+	<-started
+	r.Invalidate("g1", "s1") // el vínculo se revoca mientras next está en vuelo
+	close(release)
+	<-done
+
+	if _, err := r.IsGuardianOf(ctx, "g1", "s1"); err != nil {
+		t.Fatalf("IsGuardianOf: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("calls = %d, want 2 (la respuesta previa a Invalidate no se cachea)", n)
+	}
+	if len(r.loads) != 0 {
+		t.Fatalf("loads = %d, want 0", len(r.loads))
+	}
+}
@@ -0,0 +1,278 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Default values for TokenBlacklistConfig.
+const (
+	DefaultBlacklistKeyPrefix        = "auth:revoked:"
+	DefaultBlacklistChannel          = "auth:revoked:events"
+	DefaultBlacklistLocalCacheSize   = 10000
+	DefaultBlacklistLocalTTL         = 30 * time.Second
+	DefaultBlacklistOperationTimeout = 500 * time.Millisecond
+)
+
+// revokeAllBeforeScript keeps the latest cutoff and the longest expiration
+// for a user, so concurrent or out-of-order bulk revocations never shrink it.
+var revokeAllBeforeScript = goredis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local pttl = redis.call('PTTL', KEYS[1])
+local cutoff = math.max(cur, tonumber(ARGV[1]))
+local ttl = math.max(pttl, tonumber(ARGV[2]))
+redis.call('SET', KEYS[1], cutoff, 'PX', ttl)
+return cutoff
+`)
+
+// TokenBlacklistConfig configures a TokenBlacklist.
+type TokenBlacklistConfig struct {
+	// KeyPrefix namespaces the revocation keys (default "auth:revoked:").
+	KeyPrefix string
+	// Channel is the pub/sub channel used to invalidate the local caches of
+	// every instance (default "auth:revoked:events").
+	Channel string
+	// LocalCacheSize bounds the local LRU front cache (default 10000 entries).
+	LocalCacheSize int
+	// LocalTTL is how long "not revoked" answers and user cutoffs are cached
+	// locally (default 30s). It bounds staleness if an invalidation message is
+	// lost, e.g. while the subscription reconnects.
+	LocalTTL time.Duration
+	// OperationTimeout bounds each Redis call made by the TokenBlacklist
+	// methods without context (default 500ms).
+	OperationTimeout time.Duration
+	// FailClosed makes lookups report tokens as revoked when Redis fails.
+	// By default they fail open (not revoked) to keep the service available.
+	FailClosed bool
+	// OnError receives Redis errors that the auth.TokenBlacklist interface
+	// cannot return (optional).
+	OnError func(err error)
+}
+
+// TokenBlacklist is a distributed token blacklist backed by Redis. It
+// satisfies auth.TokenBlacklist and auth.UserTokenRevoker, so a logout on
+// one instance revokes the token on all of them.
+//
+// Lookups are served from a local LRU front cache. Revoked JTIs are cached
+// until the token expires; "not revoked" answers and user cutoffs are cached
+// for LocalTTL and invalidated through Redis pub/sub when any instance
+// revokes. Keys expire in Redis together with the tokens they revoke.
+type TokenBlacklist struct {
+	client   *goredis.Client
+	cfg      TokenBlacklistConfig
+	local    *lru[int64]
+	listener *channelListener
+	now      func() time.Time // replaceable in tests
+}
+
+// blacklistEvent is the pub/sub invalidation message.
+type blacklistEvent struct {
+	Kind  string `json:"kind"` // "jti" or "user"
+	ID    string `json:"id"`
+	Until int64  `json:"until,omitempty"` // jti: token expiration (unix seconds)
+	Since int64  `json:"since,omitempty"` // user: cutoff (unix seconds)
+}
+
+const (
+	blacklistKindJTI  = "jti"
+	blacklistKindUser = "user"
+)
+
+// NewTokenBlacklist creates a TokenBlacklist and subscribes to the
+// invalidation channel. The subscription stops when ctx is cancelled.
+func NewTokenBlacklist(ctx context.Context, client *goredis.Client, cfg TokenBlacklistConfig) (*TokenBlacklist, error) {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = DefaultBlacklistKeyPrefix
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = DefaultBlacklistChannel
+	}
+	if cfg.LocalCacheSize <= 0 {
+		cfg.LocalCacheSize = DefaultBlacklistLocalCacheSize
+	}
+	if cfg.LocalTTL <= 0 {
+		cfg.LocalTTL = DefaultBlacklistLocalTTL
+	}
+	if cfg.OperationTimeout <= 0 {
+		cfg.OperationTimeout = DefaultBlacklistOperationTimeout
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+
+	b := &TokenBlacklist{
+		client: client,
+		cfg:    cfg,
+		local:  newLRU[int64](cfg.LocalCacheSize),
+		now:    time.Now,
+	}
+	listener, err := listenChannel(ctx, client, cfg.Channel, b.apply)
+	if err != nil {
+		return nil, fmt.Errorf("subscribing to blacklist channel: %w", err)
+	}
+	b.listener = listener
+	return b, nil
+}
+
+// Revoke adds a token's JTI to the blacklist until its expiration time.
+// Errors are reported to OnError; use RevokeContext to handle them.
+func (b *TokenBlacklist) Revoke(jti string, expiresAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.OperationTimeout)
+	defer cancel()
+	if err := b.RevokeContext(ctx, jti, expiresAt); err != nil {
+		b.cfg.OnError(err)
+	}
+}
+
+// RevokeContext adds a token's JTI to the blacklist until expiresAt and
+// notifies the other instances. Already expired tokens are ignored.
+func (b *TokenBlacklist) RevokeContext(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(b.now())
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	until := expiresAt.Unix()
+	if err := b.client.Set(ctx, b.jtiKey(jti), until, ttl).Err(); err != nil {
+		return fmt.Errorf("revoking token: %w", err)
+	}
+	b.local.Set(blacklistKindJTI+":"+jti, 1, expiresAt)
+	return b.publish(ctx, blacklistEvent{Kind: blacklistKindJTI, ID: jti, Until: until})
+}
+
+// IsRevoked returns true if the token's JTI has been revoked.
+func (b *TokenBlacklist) IsRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+	now := b.now()
+	localKey := blacklistKindJTI + ":" + jti
+	if v, ok := b.local.Get(localKey, now); ok {
+		return v == 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.OperationTimeout)
+	defer cancel()
+	until, err := b.client.Get(ctx, b.jtiKey(jti)).Int64()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		b.local.Set(localKey, 0, now.Add(b.cfg.LocalTTL))
+		return false
+	case err != nil:
+		b.cfg.OnError(fmt.Errorf("checking revoked token: %w", err))
+		return b.cfg.FailClosed
+	}
+	b.local.Set(localKey, 1, time.Unix(until, 0))
+	return true
+}
+
+// RevokeAllBefore revokes every token of the user issued before `before`,
+// keeping the cutoff until expiresAt. Errors are reported to OnError; use
+// RevokeAllBeforeContext to handle them.
+func (b *TokenBlacklist) RevokeAllBefore(userID string, before, expiresAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.OperationTimeout)
+	defer cancel()
+	if err := b.RevokeAllBeforeContext(ctx, userID, before, expiresAt); err != nil {
+		b.cfg.OnError(err)
+	}
+}
+
+// RevokeAllBeforeContext revokes every token of the user issued before
+// `before` and notifies the other instances. A later cutoff replaces an
+// earlier one; an earlier cutoff never moves it back.
+func (b *TokenBlacklist) RevokeAllBeforeContext(ctx context.Context, userID string, before, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(b.now())
+	if userID == "" || ttl <= 0 {
+		return nil
+	}
+	cutoff, err := revokeAllBeforeScript.Run(ctx, b.client,
+		[]string{b.userKey(userID)}, before.Unix(), max(ttl.Milliseconds(), 1)).Int64()
+	if err != nil {
+		return fmt.Errorf("revoking user tokens: %w", err)
+	}
+	b.setLocalCutoff(userID, cutoff)
+	return b.publish(ctx, blacklistEvent{Kind: blacklistKindUser, ID: userID, Since: cutoff})
+}
+
+// IsRevokedForUser returns true if a token of the user issued at issuedAt
+// falls under a bulk revocation. Comparison uses second precision, the
+// resolution of the `iat` claim.
+func (b *TokenBlacklist) IsRevokedForUser(userID string, issuedAt time.Time) bool {
+	if userID == "" {
+		return false
+	}
+	now := b.now()
+	localKey := blacklistKindUser + ":" + userID
+	cutoff, ok := b.local.Get(localKey, now)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), b.cfg.OperationTimeout)
+		defer cancel()
+		v, err := b.client.Get(ctx, b.userKey(userID)).Int64()
+		switch {
+		case errors.Is(err, goredis.Nil):
+			cutoff = 0
+		case err != nil:
+			b.cfg.OnError(fmt.Errorf("checking user revocation: %w", err))
+			return b.cfg.FailClosed
+		default:
+			cutoff = v
+		}
+		b.local.Set(localKey, cutoff, now.Add(b.cfg.LocalTTL))
+	}
+	return cutoff > 0 && issuedAt.Unix() < cutoff
+}
+
+func (b *TokenBlacklist) jtiKey(jti string) string {
+	return b.cfg.KeyPrefix + blacklistKindJTI + ":" + jti
+}
+
+func (b *TokenBlacklist) userKey(userID string) string {
+	return b.cfg.KeyPrefix + blacklistKindUser + ":" + userID
+}
+
+func (b *TokenBlacklist) setLocalCutoff(userID string, cutoff int64) {
+	localKey := blacklistKindUser + ":" + userID
+	now := b.now()
+	if cur, ok := b.local.Get(localKey, now); ok && cur > cutoff {
+		cutoff = cur
+	}
+	b.local.Set(localKey, cutoff, now.Add(b.cfg.LocalTTL))
+}
+
+// Wait blocks until the invalidation listener stops, after the ctx passed to
+// NewTokenBlacklist is cancelled.
+func (b *TokenBlacklist) Wait() {
+	b.listener.Wait()
+}
+
+func (b *TokenBlacklist) publish(ctx context.Context, event blacklistEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling blacklist event: %w", err)
+	}
+	if err := b.client.Publish(ctx, b.cfg.Channel, payload).Err(); err != nil {
+		return fmt.Errorf("publishing blacklist event: %w", err)
+	}
+	return nil
+}
+
+// apply applies an invalidation message of any instance, including this
+// one, to the local cache.
+func (b *TokenBlacklist) apply(payload string) {
+	var event blacklistEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		b.cfg.OnError(fmt.Errorf("decoding blacklist event %s: %w", strconv.Quote(payload), err))
+		return
+	}
+	switch event.Kind {
+	case blacklistKindJTI:
+		b.local.Set(blacklistKindJTI+":"+event.ID, 1, time.Unix(event.Until, 0))
+	case blacklistKindUser:
+		b.setLocalCutoff(event.ID, event.Since)
+	}
+}
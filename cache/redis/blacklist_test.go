@@ -0,0 +1,223 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestBlacklist(t *testing.T, client *goredis.Client, cfg TokenBlacklistConfig) *TokenBlacklist {
+	t.Helper()
+	bl, err := NewTokenBlacklist(t.Context(), client, cfg)
+	if err != nil {
+		t.Fatalf("NewTokenBlacklist: %v", err)
+	}
+	return bl
+}
+
+// eventually polls cond until it holds or the deadline passes.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTokenBlacklist_RevokeAndIsRevoked(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	bl := newTestBlacklist(t, client, TokenBlacklistConfig{})
+
+	if bl.IsRevoked("jti-1") {
+		t.Fatal("jti-1 should not be revoked yet")
+	}
+	bl.Revoke("jti-1", time.Now().Add(time.Hour))
+	if !bl.IsRevoked("jti-1") {
+		t.Fatal("jti-1 should be revoked")
+	}
+
+	ttl := mr.TTL(DefaultBlacklistKeyPrefix + "jti:jti-1")
+	if ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("key TTL should match token expiry, got %v", ttl)
+	}
+
+	// Expired tokens are not stored.
+	bl.Revoke("jti-old", time.Now().Add(-time.Minute))
+	if mr.Exists(DefaultBlacklistKeyPrefix + "jti:jti-old") {
+		t.Fatal("expired token should not be stored")
+	}
+}
+
+func TestTokenBlacklist_PubSubInvalidatesOtherInstances(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	a := newTestBlacklist(t, client, TokenBlacklistConfig{LocalTTL: time.Hour})
+	b := newTestBlacklist(t, client, TokenBlacklistConfig{LocalTTL: time.Hour})
+
+	// b caches the negative answer locally.
+	if b.IsRevoked("jti-1") {
+		t.Fatal("jti-1 should not be revoked yet")
+	}
+
+	a.Revoke("jti-1", time.Now().Add(time.Hour))
+	eventually(t, func() bool { return b.IsRevoked("jti-1") })
+
+	now := time.Now()
+	if b.IsRevokedForUser("user-1", now.Add(-time.Minute)) {
+		t.Fatal("user-1 should not be revoked yet")
+	}
+	a.RevokeAllBefore("user-1", now, now.Add(time.Hour))
+	eventually(t, func() bool { return b.IsRevokedForUser("user-1", now.Add(-time.Minute)) })
+}
+
+func TestTokenBlacklist_LocalCacheAvoidsRedis(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	bl := newTestBlacklist(t, client, TokenBlacklistConfig{LocalTTL: time.Hour})
+
+	bl.Revoke("jti-1", time.Now().Add(time.Hour))
+	_ = bl.IsRevoked("jti-2")
+
+	// With Redis gone, cached answers are still served.
+	mr.Close()
+	if !bl.IsRevoked("jti-1") {
+		t.Fatal("revoked jti should be served from the local cache")
+	}
+	if bl.IsRevoked("jti-2") {
+		t.Fatal("negative answer should be served from the local cache")
+	}
+}
+
+func TestTokenBlacklist_RevokeAllBefore(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	bl := newTestBlacklist(t, client, TokenBlacklistConfig{})
+	now := time.Now()
+
+	bl.RevokeAllBefore("user-1", now, now.Add(time.Hour))
+
+	if !bl.IsRevokedForUser("user-1", now.Add(-time.Minute)) {
+		t.Fatal("token issued before the cutoff should be revoked")
+	}
+	if bl.IsRevokedForUser("user-1", now.Add(time.Second)) {
+		t.Fatal("token issued after the cutoff should be valid")
+	}
+	if bl.IsRevokedForUser("user-2", now.Add(-time.Minute)) {
+		t.Fatal("other users should not be affected")
+	}
+
+	// An earlier cutoff with a shorter TTL neither moves the cutoff back nor shortens it.
+	if err := bl.RevokeAllBeforeContext(t.Context(), "user-1", now.Add(-time.Hour), now.Add(time.Minute)); err != nil {
+		t.Fatalf("RevokeAllBeforeContext: %v", err)
+	}
+	key := DefaultBlacklistKeyPrefix + "user:user-1"
+	got, err := mr.Get(key)
+	if err != nil {
+		t.Fatalf("reading cutoff: %v", err)
+	}
+	if got != formatUnix(now) {
+		t.Fatalf("cutoff moved back: got %s, want %s", got, formatUnix(now))
+	}
+	if ttl := mr.TTL(key); ttl <= 59*time.Minute {
+		t.Fatalf("cutoff TTL shortened to %v", ttl)
+	}
+}
+
+func TestTokenBlacklist_RedisErrors(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	var errs atomic.Int32
+	onError := func(error) { errs.Add(1) }
+	open := newTestBlacklist(t, client, TokenBlacklistConfig{OnError: onError, OperationTimeout: 50 * time.Millisecond})
+	closed := newTestBlacklist(t, client, TokenBlacklistConfig{OnError: onError, OperationTimeout: 50 * time.Millisecond, FailClosed: true})
+	mr.Close()
+
+	if open.IsRevoked("jti-1") {
+		t.Fatal("fail-open blacklist should report not revoked on Redis errors")
+	}
+	if !closed.IsRevoked("jti-1") {
+		t.Fatal("fail-closed blacklist should report revoked on Redis errors")
+	}
+	if !closed.IsRevokedForUser("user-1", time.Now()) {
+		t.Fatal("fail-closed blacklist should report revoked on Redis errors")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := open.RevokeContext(ctx, "jti-1", time.Now().Add(time.Hour))
+	if err == nil {
+		t.Fatal("expected RevokeContext error with Redis down")
+	}
+	open.Revoke("jti-1", time.Now().Add(time.Hour))
+	if errs.Load() < 4 {
+		t.Fatalf("expected errors reported to OnError, got %d", errs.Load())
+	}
+}
+
+func TestNewTokenBlacklist_SubscribeError(t *testing.T) {
+	_, err := NewTokenBlacklist(t.Context(), newUnreachableClient(), TokenBlacklistConfig{})
+	if err == nil {
+		t.Fatal("expected subscribe error")
+	}
+}
+
+func TestTokenBlacklist_StopsOnContextCancel(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	bl, err := NewTokenBlacklist(ctx, client, TokenBlacklistConfig{})
+	if err != nil {
+		t.Fatalf("NewTokenBlacklist: %v", err)
+	}
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		bl.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener did not stop after cancel")
+	}
+}
+
+func TestLRU_EvictionAndExpiry(t *testing.T) {
+	c := newLRU[int](2)
+	now := time.Now()
+	later := now.Add(time.Minute)
+
+	c.Set("a", 1, later)
+	c.Set("b", 2, later)
+	if _, ok := c.Get("a", now); !ok { // a becomes most recently used
+		t.Fatal("a should be cached")
+	}
+	c.Set("c", 3, later)
+	if _, ok := c.Get("b", now); ok {
+		t.Fatal("b should have been evicted")
+	}
+	if v, ok := c.Get("a", now); !ok || v != 1 {
+		t.Fatalf("a = %v, %v", v, ok)
+	}
+	if _, ok := c.Get("c", later); ok {
+		t.Fatal("c should be expired")
+	}
+	c.Delete("a")
+	if c.Len() != 0 {
+		t.Fatalf("expected empty cache, got %d entries", c.Len())
+	}
+}
+
+func formatUnix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+func newUnreachableClient() *goredis.Client {
+	return goredis.NewClient(&goredis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 20 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+}
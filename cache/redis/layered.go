@@ -0,0 +1,341 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/maphash"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Default values for LayeredCacheConfig.
+const (
+	DefaultLayeredChannel        = "cache:invalidate"
+	DefaultLayeredLocalCacheSize = 10000
+	DefaultLayeredLocalTTL       = 30 * time.Second
+	// MaxLayeredLocalTTL caps LocalTTL: it bounds how long an instance may
+	// serve a stale value if an invalidation message is lost.
+	MaxLayeredLocalTTL = 5 * time.Minute
+)
+
+// layeredGenSlots is the number of invalidation counters keys are hashed
+// into. A collision only makes Get skip a local fill.
+const layeredGenSlots = 256
+
+// LayeredCacheConfig configures a LayeredCacheService.
+type LayeredCacheConfig struct {
+	// Channel is the pub/sub channel used to invalidate the local caches of
+	// every instance (default "cache:invalidate").
+	Channel string
+	// LocalCacheSize bounds the local LRU (default 10000 entries).
+	LocalCacheSize int
+	// LocalTTL is the longest a value is kept locally (default 30s, at most
+	// MaxLayeredLocalTTL). Local entries never outlive their Redis TTL.
+	LocalTTL time.Duration
+	// OnError receives errors of the invalidation listener (optional).
+	OnError func(err error)
+}
+
+// LayeredCacheService is a CacheService with a bounded in-process LRU in
+// front of Redis, for hot keys such as screen configs and menu trees.
+//
+// Reads are served from the LRU and fall back to Redis. Set, Delete and
+// DeleteByPattern write to Redis and publish an invalidation message so
+// every other instance evicts its local copy. Like NewCacheService, Get
+// returns goredis.Nil on a miss.
+type LayeredCacheService struct {
+	redis    *redisCacheService
+	client   *goredis.Client
+	cfg      LayeredCacheConfig
+	local    *lru[[]byte]
+	origin   string
+	listener *channelListener
+	now      func() time.Time // replaceable in tests
+
+	// gens and patternGen count the invalidations, so Get does not cache
+	// locally a value read from Redis before a concurrent invalidation.
+	seed       maphash.Seed
+	gens       [layeredGenSlots]atomic.Uint64
+	patternGen atomic.Uint64
+}
+
+var _ CacheService = (*LayeredCacheService)(nil)
+
+// layeredEvent is the pub/sub invalidation message.
+type layeredEvent struct {
+	Origin  string   `json:"origin"`
+	Keys    []string `json:"keys,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+}
+
+// NewLayeredCacheService creates a LayeredCacheService and subscribes to the
+// invalidation channel. The subscription stops when ctx is cancelled.
+func NewLayeredCacheService(ctx context.Context, client *goredis.Client, cfg LayeredCacheConfig) (*LayeredCacheService, error) {
+	if cfg.Channel == "" {
+		cfg.Channel = DefaultLayeredChannel
+	}
+	if cfg.LocalCacheSize <= 0 {
+		cfg.LocalCacheSize = DefaultLayeredLocalCacheSize
+	}
+	if cfg.LocalTTL <= 0 {
+		cfg.LocalTTL = DefaultLayeredLocalTTL
+	}
+	cfg.LocalTTL = min(cfg.LocalTTL, MaxLayeredLocalTTL)
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+
+	var id [8]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("generating cache instance id: %w", err)
+	}
+
+	s := &LayeredCacheService{
+		redis:  &redisCacheService{client: client},
+		client: client,
+		cfg:    cfg,
+		local:  newLRU[[]byte](cfg.LocalCacheSize),
+		origin: hex.EncodeToString(id[:]),
+		now:    time.Now,
+		seed:   maphash.MakeSeed(),
+	}
+	listener, err := listenChannel(ctx, client, cfg.Channel, s.apply)
+	if err != nil {
+		return nil, fmt.Errorf("subscribing to cache invalidation channel: %w", err)
+	}
+	s.listener = listener
+	return s, nil
+}
+
+// Get reads the value from the local cache or, on a local miss, from Redis,
+// keeping a local copy for up to LocalTTL. The copy is not kept if the key
+// was invalidated while reading it.
+func (s *LayeredCacheService) Get(ctx context.Context, key string, dest any) error {
+	now := s.now()
+	if data, ok := s.local.Get(key, now); ok {
+		return json.Unmarshal(data, dest)
+	}
+	gen := s.generation(key)
+
+	var get *goredis.StringCmd
+	var pttl *goredis.DurationCmd
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	data, err := get.Bytes()
+	if err != nil {
+		return err
+	}
+	s.fillLocal(key, data, now.Add(s.localTTL(pttl.Val())), gen)
+	return json.Unmarshal(data, dest)
+}
+
+// Set writes the value to Redis and to the local cache, and invalidates the
+// other instances.
+func (s *LayeredCacheService) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshaling cache value: %w", err)
+	}
+	if err := s.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return err
+	}
+	s.invalidate(key)
+	s.local.Set(key, data, s.now().Add(s.localTTL(ttl)))
+	return s.publish(ctx, layeredEvent{Keys: []string{key}})
+}
+
+// Delete removes the keys from Redis and from the local cache of every
+// instance.
+func (s *LayeredCacheService) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := s.redis.Delete(ctx, keys...); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		s.invalidate(key)
+	}
+	return s.publish(ctx, layeredEvent{Keys: keys})
+}
+
+// DeleteByPattern removes the keys matching pattern from Redis and from the
+// local cache of every instance, using Redis glob syntax for both.
+func (s *LayeredCacheService) DeleteByPattern(ctx context.Context, pattern string) error {
+	if err := s.redis.DeleteByPattern(ctx, pattern); err != nil {
+		return err
+	}
+	s.deleteLocalPattern(pattern)
+	return s.publish(ctx, layeredEvent{Pattern: pattern})
+}
+
+// localTTL caps the Redis TTL of a value (<= 0 means no expiration) to
+// LocalTTL.
+func (s *LayeredCacheService) localTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return s.cfg.LocalTTL
+	}
+	return min(ttl, s.cfg.LocalTTL)
+}
+
+// generation returns the invalidation count of key, including patterns.
+func (s *LayeredCacheService) generation(key string) uint64 {
+	return s.gens[maphash.String(s.seed, key)%layeredGenSlots].Load() + s.patternGen.Load()
+}
+
+// fillLocal caches a value read from Redis unless key was invalidated since
+// generation returned gen. The second check covers an invalidation that
+// lands between the first one and the Set.
+func (s *LayeredCacheService) fillLocal(key string, data []byte, expiresAt time.Time, gen uint64) {
+	if s.generation(key) != gen {
+		return
+	}
+	s.local.Set(key, data, expiresAt)
+	if s.generation(key) != gen {
+		s.local.Delete(key)
+	}
+}
+
+// invalidate evicts the local copy of key and makes in-flight Redis reads of
+// it skip their local fill.
+func (s *LayeredCacheService) invalidate(key string) {
+	s.gens[maphash.String(s.seed, key)%layeredGenSlots].Add(1)
+	s.local.Delete(key)
+}
+
+func (s *LayeredCacheService) deleteLocalPattern(pattern string) {
+	s.patternGen.Add(1)
+	s.local.DeleteFunc(func(key string) bool { return matchGlob(pattern, key) })
+}
+
+func (s *LayeredCacheService) publish(ctx context.Context, event layeredEvent) error {
+	event.Origin = s.origin
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshaling cache invalidation: %w", err)
+	}
+	if err := s.client.Publish(ctx, s.cfg.Channel, payload).Err(); err != nil {
+		return fmt.Errorf("publishing cache invalidation: %w", err)
+	}
+	return nil
+}
+
+// Wait blocks until the invalidation listener stops, after the ctx passed to
+// NewLayeredCacheService is cancelled.
+func (s *LayeredCacheService) Wait() {
+	s.listener.Wait()
+}
+
+// apply applies an invalidation message of another instance to the local
+// cache.
+func (s *LayeredCacheService) apply(payload string) {
+	var event layeredEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		s.cfg.OnError(fmt.Errorf("decoding cache invalidation %s: %w", strconv.Quote(payload), err))
+		return
+	}
+	if event.Origin == s.origin {
+		return
+	}
+	for _, key := range event.Keys {
+		s.invalidate(key)
+	}
+	if event.Pattern != "" {
+		s.deleteLocalPattern(event.Pattern)
+	}
+}
+
+// matchGlob reports whether key matches a Redis glob pattern: `*`, `?`,
+// `[abc]`, `[^a]`, `[a-z]` and `\` escapes.
+func matchGlob(pattern, key string) bool {
+	// Backtracking point of the last `*`.
+	starP, starK := -1, 0
+	p, k := 0, 0
+	for k < len(key) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starP, starK = p, k
+				p++
+				continue
+			case '?':
+				p++
+				k++
+				continue
+			case '[':
+				if next, ok := matchClass(pattern, p, key[k]); ok {
+					p = next
+					k++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == key[k] {
+					p += 2
+					k++
+					continue
+				}
+			default:
+				if pattern[p] == key[k] {
+					p++
+					k++
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starK++
+		p, k = starP+1, starK
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass matches c against the `[...]` class starting at pattern[p] and
+// returns the index after the class.
+func matchClass(pattern string, p int, c byte) (int, bool) {
+	p++
+	negate := p < len(pattern) && pattern[p] == '^'
+	if negate {
+		p++
+	}
+	matched := false
+	for ; p < len(pattern) && pattern[p] != ']'; p++ {
+		lo := pattern[p]
+		if lo == '\\' && p+1 < len(pattern) {
+			p++
+			lo = pattern[p]
+		}
+		hi := lo
+		if p+2 < len(pattern) && pattern[p+1] == '-' && pattern[p+2] != ']' {
+			hi = pattern[p+2]
+			p += 2
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	if p >= len(pattern) {
+		// Unterminated class: Redis treats it as the rest of the pattern.
+		return p, matched != negate
+	}
+	return p + 1, matched != negate
+}
@@ -0,0 +1,270 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+func newTestLayered(t *testing.T, client *goredis.Client, cfg LayeredCacheConfig) *LayeredCacheService {
+	t.Helper()
+	s, err := NewLayeredCacheService(t.Context(), client, cfg)
+	if err != nil {
+		t.Fatalf("NewLayeredCacheService: %v", err)
+	}
+	return s
+}
+
+func localHas(s *LayeredCacheService, key string) bool {
+	_, ok := s.local.Get(key, s.now())
+	return ok
+}
+
+func TestLayeredCache_ServesFromLocal(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	s := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	ctx := context.Background()
+
+	mustSet(ctx, t, s, "screen:home", map[string]string{"title": "Inicio"}, time.Hour)
+
+	// With Redis gone the value is still served locally.
+	mr.Close()
+	var got map[string]string
+	if err := s.Get(ctx, "screen:home", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got["title"] != "Inicio" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestLayeredCache_MissAndRedisFill(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	s := newTestLayered(t, client, LayeredCacheConfig{})
+	ctx := context.Background()
+
+	var v string
+	if err := s.Get(ctx, "missing", &v); !errors.Is(err, goredis.Nil) {
+		t.Fatalf("miss should return goredis.Nil, got %v", err)
+	}
+
+	// A value written by another process is read from Redis and kept locally.
+	if err := mr.Set("menu:tree", `"raiz"`); err != nil {
+		t.Fatalf("seeding redis: %v", err)
+	}
+	if err := s.Get(ctx, "menu:tree", &v); err != nil || v != "raiz" {
+		t.Fatalf("Get = (%q, %v)", v, err)
+	}
+	if !localHas(s, "menu:tree") {
+		t.Fatal("value read from Redis should be cached locally")
+	}
+}
+
+func TestLayeredCache_LocalTTLIsCapped(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	clock := &fakeClock{now: time.Now()}
+	s := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: 24 * time.Hour})
+	s.now = clock.Now
+	if s.cfg.LocalTTL != MaxLayeredLocalTTL {
+		t.Fatalf("LocalTTL = %v, want it capped to %v", s.cfg.LocalTTL, MaxLayeredLocalTTL)
+	}
+	ctx := context.Background()
+
+	// No Redis TTL: kept locally for LocalTTL.
+	mustSet(ctx, t, s, "a", 1, 0)
+	clock.Advance(MaxLayeredLocalTTL - time.Second)
+	if !localHas(s, "a") {
+		t.Fatal("entry should be cached locally until LocalTTL")
+	}
+	clock.Advance(time.Second)
+	if localHas(s, "a") {
+		t.Fatal("entry should expire locally after LocalTTL")
+	}
+
+	// A shorter Redis TTL bounds the local copy, also when filled by Get.
+	if err := mr.Set("b", "2"); err != nil {
+		t.Fatalf("seeding redis: %v", err)
+	}
+	mr.SetTTL("b", 10*time.Second)
+	var v int
+	if err := s.Get(ctx, "b", &v); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	clock.Advance(10 * time.Second)
+	if localHas(s, "b") {
+		t.Fatal("local copy should not outlive the Redis TTL")
+	}
+}
+
+func TestLayeredCache_PubSubInvalidation(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	a := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	b := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	ctx := context.Background()
+
+	mustSet(ctx, t, a, "screen:home", "v1", time.Hour)
+	var v string
+	if err := b.Get(ctx, "screen:home", &v); err != nil || v != "v1" {
+		t.Fatalf("Get = (%q, %v)", v, err)
+	}
+
+	// A write on a evicts b's local copy; b then reads the new value.
+	mustSet(ctx, t, a, "screen:home", "v2", time.Hour)
+	eventually(t, func() bool { return !localHas(b, "screen:home") })
+	if err := b.Get(ctx, "screen:home", &v); err != nil || v != "v2" {
+		t.Fatalf("Get = (%q, %v), want v2", v, err)
+	}
+	// The writer keeps its own copy.
+	if !localHas(a, "screen:home") {
+		t.Fatal("writer should keep its local copy")
+	}
+
+	if err := a.Delete(ctx, "screen:home"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	eventually(t, func() bool { return !localHas(b, "screen:home") })
+	if err := b.Get(ctx, "screen:home", &v); !errors.Is(err, goredis.Nil) {
+		t.Fatalf("deleted key should miss, got %v", err)
+	}
+}
+
+func TestLayeredCache_DeleteByPatternInvalidatesLocalKeys(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	a := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	ctx := context.Background()
+
+	keys := []string{"menu:school-1:es", "menu:school-1:en", "menu:school-2:es"}
+	for _, key := range keys {
+		mustSet(ctx, t, a, key, key, time.Hour)
+	}
+	// b subscribes after the writes, so their invalidations cannot race
+	// with its reads.
+	b := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	for _, key := range keys {
+		var v string
+		if err := b.Get(ctx, key, &v); err != nil {
+			t.Fatalf("Get %s: %v", key, err)
+		}
+	}
+
+	if err := a.DeleteByPattern(ctx, "menu:school-1:*"); err != nil {
+		t.Fatalf("DeleteByPattern: %v", err)
+	}
+	for _, s := range []*LayeredCacheService{a, b} {
+		eventually(t, func() bool {
+			return !localHas(s, "menu:school-1:es") && !localHas(s, "menu:school-1:en")
+		})
+		if !localHas(s, "menu:school-2:es") {
+			t.Fatal("keys outside the pattern should stay cached")
+		}
+	}
+	if mr.Exists("menu:school-1:es") || !mr.Exists("menu:school-2:es") {
+		t.Fatal("DeleteByPattern should only remove matching Redis keys")
+	}
+}
+
+func TestLayeredCache_AsTypedCacheBackend(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	s := newTestLayered(t, client, LayeredCacheConfig{})
+	c := NewCache(NewCacheServiceBackend[screenConfig](s), CacheConfig{})
+
+	loads := 0
+	for range 2 {
+		got, err := c.GetOrLoad(context.Background(), "home", time.Minute, func(context.Context) (screenConfig, error) {
+			loads++
+			return screenConfig{Key: "home"}, nil
+		})
+		if err != nil || got.Key != "home" {
+			t.Fatalf("GetOrLoad = (%+v, %v)", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loader called %d times, want 1", loads)
+	}
+}
+
+func TestLayeredCache_InvalidationDuringReadSkipsLocalFill(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	s := newTestLayered(t, client, LayeredCacheConfig{LocalTTL: time.Hour})
+	expires := time.Now().Add(time.Hour)
+
+	// An invalidation from another instance arrives while "a" is read from
+	// Redis: the value read may be stale and must not be cached.
+	gen := s.generation("a")
+	s.apply(`{"origin":"other","keys":["a"]}`)
+	s.fillLocal("a", []byte(`"old"`), expires, gen)
+	if localHas(s, "a") {
+		t.Fatal("value read before a key invalidation should not be cached")
+	}
+
+	gen = s.generation("b")
+	s.apply(`{"origin":"other","pattern":"b*"}`)
+	s.fillLocal("b", []byte(`"old"`), expires, gen)
+	if localHas(s, "b") {
+		t.Fatal("value read before a pattern invalidation should not be cached")
+	}
+
+	gen = s.generation("c")
+	s.fillLocal("c", []byte(`"new"`), expires, gen)
+	if !localHas(s, "c") {
+		t.Fatal("value read without invalidations should be cached")
+	}
+}
+
+func TestNewLayeredCacheService_SubscribeError(t *testing.T) {
+	_, err := NewLayeredCacheService(t.Context(), newUnreachableClient(), LayeredCacheConfig{})
+	if err == nil {
+		t.Fatal("expected subscribe error")
+	}
+}
+
+func TestLayeredCache_StopsOnContextCancel(t *testing.T) {
+	client, _ := startMiniRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := NewLayeredCacheService(ctx, client, LayeredCacheConfig{})
+	if err != nil {
+		t.Fatalf("NewLayeredCacheService: %v", err)
+	}
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener did not stop after cancel")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"*", "", true},
+		{"menu:*", "menu:school-1:es", true},
+		{"menu:*:es", "menu:school-1:es", true},
+		{"menu:*:es", "menu:school-1:en", false},
+		{"screen:?", "screen:a", true},
+		{"screen:?", "screen:ab", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+		{"menu/*", "menu/a/b", true},
+	}
+	for _, tc := range cases {
+		if got := matchGlob(tc.pattern, tc.key); got != tc.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.key, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// DefaultLoginAttemptKeyPrefix is the default key prefix of LoginAttemptStore.
+const DefaultLoginAttemptKeyPrefix = "auth:login:"
+
+var (
+	// recordFailureScript drops the failures outside the window, adds the new
+	// one and returns how many remain. The key expires together with its
+	// latest failure. ARGV: window start (ms, exclusive), failure (ms),
+	// unique member, window (ms).
+	recordFailureScript = goredis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return redis.call('ZCARD', KEYS[1])
+`)
+
+	// failuresScript returns the number of failures in (ARGV[1], ARGV[2]]
+	// (ms) and the score of the latest one, or 0.
+	failuresScript = goredis.NewScript(`
+local count = redis.call('ZCOUNT', KEYS[1], '(' .. ARGV[1], ARGV[2])
+if count == 0 then
+  return {0, 0}
+end
+local last = redis.call('ZREVRANGEBYSCORE', KEYS[1], ARGV[2], '(' .. ARGV[1], 'WITHSCORES', 'LIMIT', 0, 1)
+return {count, last[2]}
+`)
+)
+
+// LoginAttemptStoreConfig configures a LoginAttemptStore.
+type LoginAttemptStoreConfig struct {
+	// KeyPrefix namespaces the failure and lockout keys (default "auth:login:").
+	KeyPrefix string
+}
+
+// LoginAttemptStore keeps the login failures and lockouts of
+// auth.LoginThrottle in Redis, so every instance of a service enforces the
+// same limits. It satisfies auth.LoginAttemptStore.
+//
+// Failures are kept in a sorted set per key scored by time (sliding window);
+// lockouts are plain keys that expire on their own when they end.
+type LoginAttemptStore struct {
+	client *goredis.Client
+	cfg    LoginAttemptStoreConfig
+	now    func() time.Time // replaceable in tests
+}
+
+// NewLoginAttemptStore creates a LoginAttemptStore.
+func NewLoginAttemptStore(client *goredis.Client, cfg LoginAttemptStoreConfig) *LoginAttemptStore {
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = DefaultLoginAttemptKeyPrefix
+	}
+	return &LoginAttemptStore{client: client, cfg: cfg, now: time.Now}
+}
+
+// RecordFailure records a failure at `at` and returns how many failures the
+// key has in (at-window, at], this one included.
+func (s *LoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time, window time.Duration) (int, error) {
+	member, err := failureMember(at)
+	if err != nil {
+		return 0, err
+	}
+	count, err := recordFailureScript.Run(ctx, s.client, []string{s.failuresKey(key)},
+		at.Add(-window).UnixMilli(), at.UnixMilli(), member, max(window.Milliseconds(), 1)).Int()
+	if err != nil {
+		return 0, fmt.Errorf("recording login failure: %w", err)
+	}
+	return count, nil
+}
+
+// Failures returns how many failures the key has in (at-window, at] and the
+// time of the latest one.
+func (s *LoginAttemptStore) Failures(ctx context.Context, key string, at time.Time, window time.Duration) (int, time.Time, error) {
+	res, err := failuresScript.Run(ctx, s.client, []string{s.failuresKey(key)},
+		at.Add(-window).UnixMilli(), at.UnixMilli()).Slice()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("reading login failures: %w", err)
+	}
+	count, _ := res[0].(int64) //nolint:errcheck // ZCOUNT always returns an integer
+	if count == 0 {
+		return 0, time.Time{}, nil
+	}
+	last, err := strconv.ParseInt(fmt.Sprint(res[1]), 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("decoding login failure time: %w", err)
+	}
+	return int(count), time.UnixMilli(last), nil
+}
+
+// Reset deletes the failures of the key.
+func (s *LoginAttemptStore) Reset(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.failuresKey(key)).Err(); err != nil {
+		return fmt.Errorf("resetting login failures: %w", err)
+	}
+	return nil
+}
+
+// Lock locks the key until `until`. An `until` in the past lifts the lock.
+func (s *LoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	ttl := until.Sub(s.now())
+	var err error
+	if ttl <= 0 {
+		err = s.client.Del(ctx, s.lockKey(key)).Err()
+	} else {
+		err = s.client.Set(ctx, s.lockKey(key), until.UnixMilli(), ttl).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("locking login key: %w", err)
+	}
+	return nil
+}
+
+// LockedUntil returns the end of the lock in force at `at`, or the zero time.
+func (s *LoginAttemptStore) LockedUntil(ctx context.Context, key string, at time.Time) (time.Time, error) {
+	ms, err := s.client.Get(ctx, s.lockKey(key)).Int64()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		return time.Time{}, nil
+	case err != nil:
+		return time.Time{}, fmt.Errorf("checking login lock: %w", err)
+	}
+	until := time.UnixMilli(ms)
+	if !until.After(at) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+func (s *LoginAttemptStore) failuresKey(key string) string {
+	return s.cfg.KeyPrefix + "fail:" + key
+}
+
+func (s *LoginAttemptStore) lockKey(key string) string {
+	return s.cfg.KeyPrefix + "lock:" + key
+}
+
+// failureMember builds a unique sorted set member, so failures recorded in
+// the same millisecond by different instances are all counted.
+func failureMember(at time.Time) (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generating login failure id: %w", err)
+	}
+	return strconv.FormatInt(at.UnixNano(), 10) + "-" + hex.EncodeToString(b[:]), nil
+}
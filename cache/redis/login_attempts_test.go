@@ -0,0 +1,105 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptStore_SlidingWindow(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	s := NewLoginAttemptStore(client, LoginAttemptStoreConfig{})
+	ctx := context.Background()
+	base := time.Now().Truncate(time.Millisecond)
+
+	for i, want := range []int{1, 2, 3} {
+		got, err := s.RecordFailure(ctx, "account:ana", base.Add(time.Duration(i)*time.Minute), 10*time.Minute)
+		if err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+		if got != want {
+			t.Fatalf("failure %d: count = %d, want %d", i, got, want)
+		}
+	}
+	// Two failures in the same millisecond are both counted.
+	if got, _ := s.RecordFailure(ctx, "account:ana", base.Add(2*time.Minute), 10*time.Minute); got != 4 {
+		t.Fatalf("count = %d, want 4", got)
+	}
+	if ttl := mr.TTL(DefaultLoginAttemptKeyPrefix + "fail:account:ana"); ttl != 10*time.Minute {
+		t.Fatalf("failures key TTL = %v, want the window", ttl)
+	}
+
+	count, last, err := s.Failures(ctx, "account:ana", base.Add(5*time.Minute), 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Failures: %v", err)
+	}
+	if count != 4 || !last.Equal(base.Add(2*time.Minute)) {
+		t.Fatalf("Failures = (%d, %v), want (4, %v)", count, last, base.Add(2*time.Minute))
+	}
+
+	// At base+10m the first failure leaves the window (at-window, at].
+	count, _, err = s.Failures(ctx, "account:ana", base.Add(10*time.Minute), 10*time.Minute)
+	if err != nil || count != 3 {
+		t.Fatalf("Failures = (%d, %v), want 3", count, err)
+	}
+
+	if err := s.Reset(ctx, "account:ana"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	count, last, err = s.Failures(ctx, "account:ana", base, 10*time.Minute)
+	if err != nil || count != 0 || !last.IsZero() {
+		t.Fatalf("after Reset Failures = (%d, %v, %v), want none", count, last, err)
+	}
+}
+
+func TestLoginAttemptStore_Lock(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	now := time.Now().Truncate(time.Millisecond)
+	s := NewLoginAttemptStore(client, LoginAttemptStoreConfig{KeyPrefix: "svc:login:"})
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	until, err := s.LockedUntil(ctx, "ip:10.0.0.1", now)
+	if err != nil || !until.IsZero() {
+		t.Fatalf("LockedUntil = (%v, %v), want not locked", until, err)
+	}
+
+	if err := s.Lock(ctx, "ip:10.0.0.1", now.Add(15*time.Minute)); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if ttl := mr.TTL("svc:login:lock:ip:10.0.0.1"); ttl != 15*time.Minute {
+		t.Fatalf("lock TTL = %v, want 15m", ttl)
+	}
+	until, err = s.LockedUntil(ctx, "ip:10.0.0.1", now)
+	if err != nil || !until.Equal(now.Add(15*time.Minute)) {
+		t.Fatalf("LockedUntil = (%v, %v), want %v", until, err, now.Add(15*time.Minute))
+	}
+	if until, _ = s.LockedUntil(ctx, "ip:10.0.0.1", now.Add(15*time.Minute)); !until.IsZero() {
+		t.Fatalf("lock should end at its deadline, got %v", until)
+	}
+
+	// An `until` in the past lifts the lock.
+	if err := s.Lock(ctx, "ip:10.0.0.1", time.Time{}); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if mr.Exists("svc:login:lock:ip:10.0.0.1") {
+		t.Fatal("unlock should delete the lock key")
+	}
+}
+
+func TestLoginAttemptStore_RedisError(t *testing.T) {
+	client := newUnreachableClient()
+	t.Cleanup(func() { _ = client.Close() })
+	s := NewLoginAttemptStore(client, LoginAttemptStoreConfig{})
+
+	ctx := context.Background()
+	if _, err := s.RecordFailure(ctx, "k", time.Now(), time.Minute); err == nil {
+		t.Fatal("RecordFailure should fail when Redis is down")
+	}
+	if _, _, err := s.Failures(ctx, "k", time.Now(), time.Minute); err == nil {
+		t.Fatal("Failures should fail when Redis is down")
+	}
+	if _, err := s.LockedUntil(ctx, "k", time.Now()); err == nil {
+		t.Fatal("LockedUntil should fail when Redis is down")
+	}
+}
@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// lru is a size-bounded, process-local cache with per-entry expiration.
+// It is safe for concurrent use.
+type lru[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*lruNode[V]
+	head     *lruNode[V] // most recently used
+	tail     *lruNode[V] // least recently used
+}
+
+type lruNode[V any] struct {
+	key        string
+	value      V
+	expiresAt  time.Time
+	prev, next *lruNode[V]
+}
+
+func newLRU[V any](capacity int) *lru[V] {
+	return &lru[V]{
+		capacity: capacity,
+		items:    make(map[string]*lruNode[V], capacity),
+	}
+}
+
+// Get returns the value if present and not expired at now.
+func (c *lru[V]) Get(key string, now time.Time) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	node, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	if !now.Before(node.expiresAt) {
+		c.remove(node)
+		return zero, false
+	}
+	c.moveToFront(node)
+	return node.value, true
+}
+
+// Set stores the value until expiresAt, evicting the least recently used
+// entry when the cache is full.
+func (c *lru[V]) Set(key string, value V, expiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node, ok := c.items[key]; ok {
+		node.value = value
+		node.expiresAt = expiresAt
+		c.moveToFront(node)
+		return
+	}
+	node := &lruNode[V]{key: key, value: value, expiresAt: expiresAt}
+	c.items[key] = node
+	c.pushFront(node)
+	for len(c.items) > c.capacity {
+		c.remove(c.tail)
+	}
+}
+
+// Delete removes the key.
+func (c *lru[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node, ok := c.items[key]; ok {
+		c.remove(node)
+	}
+}
+
+// DeleteFunc removes every key for which match returns true.
+func (c *lru[V]) DeleteFunc(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, node := range c.items {
+		if match(key) {
+			c.remove(node)
+		}
+	}
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (c *lru[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+func (c *lru[V]) pushFront(node *lruNode[V]) {
+	node.prev = nil
+	node.next = c.head
+	if c.head != nil {
+		c.head.prev = node
+	}
+	c.head = node
+	if c.tail == nil {
+		c.tail = node
+	}
+}
+
+func (c *lru[V]) unlink(node *lruNode[V]) {
+	if node.prev != nil {
+		node.prev.next = node.next
+	} else {
+		c.head = node.next
+	}
+	if node.next != nil {
+		node.next.prev = node.prev
+	} else {
+		c.tail = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
+
+func (c *lru[V]) moveToFront(node *lruNode[V]) {
+	if c.head == node {
+		return
+	}
+	c.unlink(node)
+	c.pushFront(node)
+}
+
+func (c *lru[V]) remove(node *lruNode[V]) {
+	c.unlink(node)
+	delete(c.items, node.key)
+}
@@ -0,0 +1,50 @@
+package redis
+
+import (
+	"context"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// channelListener delivers the messages of a pub/sub channel to a handler
+// until the subscription context is cancelled.
+type channelListener struct {
+	done chan struct{}
+}
+
+// listenChannel subscribes to channel and calls handle with the payload of
+// each message from a background goroutine. It returns once the
+// subscription is confirmed; the goroutine stops when ctx is cancelled.
+func listenChannel(ctx context.Context, client *goredis.Client, channel string, handle func(payload string)) (*channelListener, error) {
+	sub := client.Subscribe(ctx, channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close() //nolint:errcheck // cleanup; the subscribe error is the one reported
+		return nil, err
+	}
+	l := &channelListener{done: make(chan struct{})}
+	go l.run(ctx, sub, handle)
+	return l, nil
+}
+
+// Wait blocks until the listener goroutine stops.
+func (l *channelListener) Wait() {
+	<-l.done
+}
+
+func (l *channelListener) run(ctx context.Context, sub *goredis.PubSub, handle func(payload string)) {
+	defer close(l.done)
+	defer func() { _ = sub.Close() }() //nolint:errcheck // cleanup once ctx is cancelled
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			handle(msg.Payload)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultNegativeTTL is how long Cache remembers that a key does not exist.
+const DefaultNegativeTTL = 30 * time.Second
+
+// ErrNotFound is returned by a loader when the value does not exist. Cache
+// stores it as a negative result for NegativeTTL, so repeated lookups of a
+// missing key do not reach the source.
+var ErrNotFound = errors.New("cache: value not found")
+
+// CacheEntry is what a Cache stores in its Backend: the value, or a negative
+// result, plus the metadata used for early refresh.
+type CacheEntry[T any] struct {
+	Value T `json:"v"`
+	// Negative marks a cached ErrNotFound.
+	Negative bool `json:"n,omitempty"`
+	// LoadTime is how long the loader took; early refresh is more likely the
+	// more expensive the value is to recompute.
+	LoadTime time.Duration `json:"d,omitempty"`
+	// ExpiresAt is when the entry expires in the backend.
+	ExpiresAt time.Time `json:"e"`
+}
+
+// Backend stores the entries of a Cache. Get reports a miss with ok=false
+// and a nil error.
+type Backend[T any] interface {
+	Get(ctx context.Context, key string) (entry CacheEntry[T], ok bool, err error)
+	Set(ctx context.Context, key string, entry CacheEntry[T], ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// KeyPrefix is prepended to every key (optional).
+	KeyPrefix string
+	// NegativeTTL is how long ErrNotFound results are cached (default 30s).
+	// A negative value disables negative caching.
+	NegativeTTL time.Duration
+	// EarlyRefreshBeta enables probabilistic early refresh (XFetch) when > 0.
+	// A hit may trigger a background reload before the entry expires, with a
+	// probability that grows as expiration approaches and with the load time
+	// of the value. 1 is a good default; higher values refresh earlier.
+	EarlyRefreshBeta float64
+	// OnError receives backend errors and background refresh errors, which
+	// GetOrLoad does not return (optional).
+	OnError func(err error)
+}
+
+// Cache is a typed read-through cache. Concurrent misses of the same key in
+// a process share a single loader call; backend failures degrade to calling
+// the loader instead of failing the request. A load that overlaps a Set or
+// Delete of its key in the same process does not store its result, so it
+// cannot overwrite the newer value.
+type Cache[T any] struct {
+	backend    Backend[T]
+	cfg        CacheConfig
+	now        func() time.Time // replaceable in tests
+	group      singleflight.Group
+	refreshing sync.Map // keys with a background refresh running
+
+	// gens count the Sets and Deletes of each key, so load does not store
+	// a value that started loading before them.
+	seed maphash.Seed
+	gens [cacheGenSlots]atomic.Uint64
+}
+
+// cacheGenSlots is the number of generation counters of a Cache; keys that
+// share a slot only cost each other a skipped store.
+const cacheGenSlots = 256
+
+// NewCache creates a Cache over backend: NewLocalBackend for an in-process
+// cache or NewCacheServiceBackend for Redis.
+func NewCache[T any](backend Backend[T], cfg CacheConfig) *Cache[T] {
+	if cfg.NegativeTTL == 0 {
+		cfg.NegativeTTL = DefaultNegativeTTL
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(error) {}
+	}
+	return &Cache[T]{backend: backend, cfg: cfg, now: time.Now, seed: maphash.MakeSeed()}
+}
+
+// GetOrLoad returns the cached value of key or calls loader, caching its
+// result for ttl. If loader returns ErrNotFound (or wraps it) the miss is
+// cached for NegativeTTL and GetOrLoad returns ErrNotFound; other loader
+// errors are returned and not cached. With ttl <= 0 the value is loaded
+// but not cached.
+//
+// The shared loader call runs without the caller's cancellation, so a
+// caller that gives up does not fail the others waiting on it; each caller
+// stops waiting when its own ctx is done. Loaders should bound their own
+// duration.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	key = c.cfg.KeyPrefix + key
+
+	entry, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.cfg.OnError(fmt.Errorf("reading cache key %s: %w", key, err))
+	}
+	if ok {
+		if c.shouldRefresh(entry) && c.startRefresh(key) {
+			go c.refresh(context.WithoutCancel(ctx), key, ttl, loader)
+		}
+		if entry.Negative {
+			return zero, ErrNotFound
+		}
+		return entry.Value, nil
+	}
+
+	loadCtx := context.WithoutCancel(ctx)
+	ch := c.group.DoChan(key, func() (any, error) {
+		return c.load(loadCtx, key, ttl, loader)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		entry := res.Val.(CacheEntry[T]) //nolint:errcheck // load always returns a CacheEntry[T]
+		if entry.Negative {
+			return zero, ErrNotFound
+		}
+		return entry.Value, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Set stores value for ttl, replacing any cached or negative entry. With
+// ttl <= 0 the value is not stored and the key is deleted, as GetOrLoad does
+// not cache it either.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.Delete(ctx, key)
+	}
+	key = c.cfg.KeyPrefix + key
+	c.bump(key)
+	entry := CacheEntry[T]{Value: value, ExpiresAt: c.now().Add(ttl)}
+	return c.backend.Set(ctx, key, entry, ttl)
+}
+
+// Delete removes the keys.
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.cfg.KeyPrefix + key
+		c.bump(prefixed[i])
+	}
+	return c.backend.Delete(ctx, prefixed...)
+}
+
+// generation returns the count of Sets and Deletes of key.
+func (c *Cache[T]) generation(key string) uint64 {
+	return c.gens[maphash.String(c.seed, key)%cacheGenSlots].Load()
+}
+
+// bump makes the loads of key in flight skip their store.
+func (c *Cache[T]) bump(key string) {
+	c.gens[maphash.String(c.seed, key)%cacheGenSlots].Add(1)
+}
+
+// load calls loader and stores its result unless key was Set or Deleted
+// meanwhile. It runs once per key at a time.
+func (c *Cache[T]) load(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) (CacheEntry[T], error) {
+	gen := c.generation(key)
+	start := c.now()
+	value, err := callLoader(ctx, loader)
+	now := c.now()
+
+	var entry CacheEntry[T]
+	switch {
+	case err == nil:
+		entry = CacheEntry[T]{Value: value, LoadTime: now.Sub(start), ExpiresAt: now.Add(ttl)}
+	case errors.Is(err, ErrNotFound):
+		entry = CacheEntry[T]{Negative: true, ExpiresAt: now.Add(c.cfg.NegativeTTL)}
+		ttl = c.cfg.NegativeTTL
+	default:
+		return CacheEntry[T]{}, err
+	}
+	if ttl > 0 && c.generation(key) == gen {
+		if err := c.backend.Set(ctx, key, entry, ttl); err != nil {
+			c.cfg.OnError(fmt.Errorf("writing cache key %s: %w", key, err))
+		}
+		// A Set or Delete that landed during the write may have been
+		// overwritten; drop the entry so the next read loads it again.
+		if c.generation(key) != gen {
+			if err := c.backend.Delete(ctx, key); err != nil {
+				c.cfg.OnError(fmt.Errorf("deleting cache key %s: %w", key, err))
+			}
+		}
+	}
+	return entry, nil
+}
+
+// callLoader returns a loader panic as an error: singleflight would
+// re-panic it in a goroutine of its own, which cannot be recovered.
+func callLoader[T any](ctx context.Context, loader func(ctx context.Context) (T, error)) (value T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cache loader panic: %v", r)
+		}
+	}()
+	return loader(ctx)
+}
+
+// startRefresh reports whether the caller must start the background
+// refresh of key, i.e. none is running.
+func (c *Cache[T]) startRefresh(key string) bool {
+	_, running := c.refreshing.LoadOrStore(key, struct{}{})
+	return !running
+}
+
+// refresh reloads key in the background, sharing the call with any load of
+// the same key already in flight.
+func (c *Cache[T]) refresh(ctx context.Context, key string, ttl time.Duration, loader func(ctx context.Context) (T, error)) {
+	defer c.refreshing.Delete(key)
+	_, err, _ := c.group.Do(key, func() (any, error) {
+		return c.load(ctx, key, ttl, loader)
+	})
+	if err != nil {
+		c.cfg.OnError(fmt.Errorf("refreshing cache key %s: %w", key, err))
+	}
+}
+
+// shouldRefresh implements XFetch: refresh when
+// now - LoadTime*beta*ln(rand) >= ExpiresAt. Negative entries are not
+// refreshed early.
+func (c *Cache[T]) shouldRefresh(entry CacheEntry[T]) bool {
+	if c.cfg.EarlyRefreshBeta <= 0 || entry.Negative || entry.LoadTime <= 0 {
+		return false
+	}
+	gap := float64(entry.LoadTime) * c.cfg.EarlyRefreshBeta * -math.Log(rand.Float64()) //nolint:gosec // jitter, not security sensitive
+	return gap >= float64(entry.ExpiresAt.Sub(c.now()))
+}
+
+// localBackend keeps the entries in a process-local LRU.
+type localBackend[T any] struct {
+	lru *lru[CacheEntry[T]]
+}
+
+// NewLocalBackend returns an in-process Backend that keeps up to capacity
+// entries, evicting the least recently used. Values are stored as is, not
+// copied: callers must not modify what they get from the Cache.
+func NewLocalBackend[T any](capacity int) Backend[T] {
+	return &localBackend[T]{lru: newLRU[CacheEntry[T]](max(capacity, 1))}
+}
+
+func (b *localBackend[T]) Get(_ context.Context, key string) (CacheEntry[T], bool, error) {
+	entry, ok := b.lru.Get(key, time.Now())
+	return entry, ok, nil
+}
+
+func (b *localBackend[T]) Set(_ context.Context, key string, entry CacheEntry[T], ttl time.Duration) error {
+	b.lru.Set(key, entry, time.Now().Add(ttl))
+	return nil
+}
+
+func (b *localBackend[T]) Delete(_ context.Context, keys ...string) error {
+	for _, key := range keys {
+		b.lru.Delete(key)
+	}
+	return nil
+}
+
+// cacheServiceBackend stores the entries as JSON through a CacheService.
+type cacheServiceBackend[T any] struct {
+	svc CacheService
+}
+
+// NewCacheServiceBackend returns a Backend that stores the entries as JSON
+// in svc, e.g. NewCacheService(client) for Redis.
+func NewCacheServiceBackend[T any](svc CacheService) Backend[T] {
+	return &cacheServiceBackend[T]{svc: svc}
+}
+
+func (b *cacheServiceBackend[T]) Get(ctx context.Context, key string) (CacheEntry[T], bool, error) {
+	var entry CacheEntry[T]
+	err := b.svc.Get(ctx, key, &entry)
+	switch {
+	case errors.Is(err, goredis.Nil):
+		return CacheEntry[T]{}, false, nil
+	case err != nil:
+		return CacheEntry[T]{}, false, err
+	}
+	return entry, true, nil
+}
+
+func (b *cacheServiceBackend[T]) Set(ctx context.Context, key string, entry CacheEntry[T], ttl time.Duration) error {
+	return b.svc.Set(ctx, key, entry, ttl)
+}
+
+func (b *cacheServiceBackend[T]) Delete(ctx context.Context, keys ...string) error {
+	return b.svc.Delete(ctx, keys...)
+}
@@ -0,0 +1,322 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type screenConfig struct {
+	Key     string   `json:"key"`
+	Widgets []string `json:"widgets"`
+}
+
+func TestCache_GetOrLoadRedisBackend(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	c := NewCache(NewCacheServiceBackend[screenConfig](NewCacheService(client)), CacheConfig{KeyPrefix: "screens:"})
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	loader := func(context.Context) (screenConfig, error) {
+		loads.Add(1)
+		return screenConfig{Key: "home", Widgets: []string{"menu", "news"}}, nil
+	}
+
+	for range 3 {
+		got, err := c.GetOrLoad(ctx, "home", time.Minute, loader)
+		if err != nil {
+			t.Fatalf("GetOrLoad: %v", err)
+		}
+		if got.Key != "home" || len(got.Widgets) != 2 {
+			t.Fatalf("unexpected value %+v", got)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	if ttl := mr.TTL("screens:home"); ttl != time.Minute {
+		t.Fatalf("key TTL = %v, want 1m", ttl)
+	}
+
+	// Delete forces the next call to load again.
+	if err := c.Delete(ctx, "home"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.GetOrLoad(ctx, "home", time.Minute, loader); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("loader called %d times after Delete, want 2", n)
+	}
+
+	// Set replaces the cached value without calling the loader.
+	if err := c.Set(ctx, "home", screenConfig{Key: "home-v2"}, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, _ := c.GetOrLoad(ctx, "home", time.Minute, loader)
+	if got.Key != "home-v2" || loads.Load() != 2 {
+		t.Fatalf("Set value not served from cache: %+v, loads=%d", got, loads.Load())
+	}
+}
+
+func TestCache_SingleflightCollapsesMisses(t *testing.T) {
+	c := NewCache(NewLocalBackend[int](10), CacheConfig{})
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	loader := func(context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make(chan int, callers)
+	for range callers {
+		wg.Go(func() {
+			v, err := c.GetOrLoad(ctx, "k", time.Minute, loader)
+			if err != nil {
+				t.Errorf("GetOrLoad: %v", err)
+			}
+			results <- v
+		})
+	}
+	eventually(t, func() bool { return loads.Load() == 1 })
+	time.Sleep(20 * time.Millisecond) // let the other callers join the flight
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	for v := range results {
+		if v != 42 {
+			t.Fatalf("got %d, want 42", v)
+		}
+	}
+}
+
+func TestCache_CallerCancellationDoesNotFailOthers(t *testing.T) {
+	c := NewCache(NewLocalBackend[int](10), CacheConfig{})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	loader := func(ctx context.Context) (int, error) {
+		close(started)
+		select {
+		case <-release:
+			return 7, ctx.Err()
+		case <-time.After(2 * time.Second):
+			return 0, errors.New("loader not released")
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(cancelled, "k", time.Minute, loader)
+		errc <- err
+	}()
+	<-started
+
+	other := make(chan int, 1)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), "k", time.Minute, loader)
+		other <- v
+	}()
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if v := <-other; v != 7 {
+		t.Fatalf("other caller got %d, want 7", v)
+	}
+}
+
+func TestCache_NegativeCaching(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	c := NewCache(NewCacheServiceBackend[string](NewCacheService(client)), CacheConfig{NegativeTTL: 5 * time.Second})
+	ctx := context.Background()
+
+	var loads atomic.Int32
+	missing := func(context.Context) (string, error) {
+		loads.Add(1)
+		return "", fmt.Errorf("menu tree school-9: %w", ErrNotFound)
+	}
+	for range 3 {
+		if _, err := c.GetOrLoad(ctx, "menu:school-9", time.Minute, missing); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("got %v, want ErrNotFound", err)
+		}
+	}
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loader called %d times, want 1", n)
+	}
+	if ttl := mr.TTL("menu:school-9"); ttl != 5*time.Second {
+		t.Fatalf("negative entry TTL = %v, want NegativeTTL", ttl)
+	}
+
+	// Other errors are not cached.
+	boom := errors.New("db down")
+	failing := func(context.Context) (string, error) {
+		loads.Add(1)
+		return "", boom
+	}
+	for range 2 {
+		if _, err := c.GetOrLoad(ctx, "menu:school-1", time.Minute, failing); !errors.Is(err, boom) {
+			t.Fatalf("got %v, want loader error", err)
+		}
+	}
+	if n := loads.Load(); n != 3 {
+		t.Fatalf("failed loads should not be cached, loader called %d times", n)
+	}
+
+	// A negative NegativeTTL disables negative caching.
+	off := NewCache(NewLocalBackend[string](10), CacheConfig{NegativeTTL: -1})
+	for range 2 {
+		_, _ = off.GetOrLoad(ctx, "x", time.Minute, missing) //nolint:errcheck // only the loader count matters
+	}
+	if n := loads.Load(); n != 5 {
+		t.Fatalf("negative caching should be off, loader called %d times", n)
+	}
+}
+
+func TestCache_EarlyRefresh(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+
+	var loads atomic.Int32
+	loader := func(context.Context) (int, error) {
+		clock.Advance(time.Second) // each load "takes" 1s
+		return int(loads.Add(1)), nil
+	}
+
+	// Without beta a fresh hit never reloads.
+	plain := NewCache(NewLocalBackend[int](10), CacheConfig{})
+	plain.now = clock.Now
+	for range 5 {
+		if v, _ := plain.GetOrLoad(context.Background(), "k", time.Hour, loader); v != 1 {
+			t.Fatalf("got %d, want the cached 1", v)
+		}
+	}
+
+	// With a huge beta the first hit schedules a background reload and
+	// keeps serving the cached value meanwhile.
+	loads.Store(0)
+	c := NewCache(NewLocalBackend[int](10), CacheConfig{EarlyRefreshBeta: 1e9})
+	c.now = clock.Now
+	if v, _ := c.GetOrLoad(context.Background(), "k", time.Hour, loader); v != 1 {
+		t.Fatalf("first load got %d, want 1", v)
+	}
+	if v, _ := c.GetOrLoad(context.Background(), "k", time.Hour, loader); v != 1 {
+		t.Fatalf("hit got %d, want the cached 1", v)
+	}
+	eventually(t, func() bool {
+		e, _, _ := c.backend.Get(context.Background(), "k") //nolint:errcheck // local backend never fails
+		return e.Value == 2
+	})
+}
+
+func TestCache_BackendErrorFallsBackToLoader(t *testing.T) {
+	var reported atomic.Int32
+	c := NewCache(NewCacheServiceBackend[int](NewCacheService(newUnreachableClient())), CacheConfig{
+		OnError: func(error) { reported.Add(1) },
+	})
+	v, err := c.GetOrLoad(context.Background(), "k", time.Minute, func(context.Context) (int, error) { return 3, nil })
+	if err != nil || v != 3 {
+		t.Fatalf("GetOrLoad = (%d, %v), want the loaded value", v, err)
+	}
+	if reported.Load() != 2 {
+		t.Fatalf("OnError called %d times, want 2 (read and write)", reported.Load())
+	}
+}
+
+func TestCache_SetWithoutTTLDeletes(t *testing.T) {
+	client, mr := startMiniRedis(t)
+	c := NewCache(NewCacheServiceBackend[string](NewCacheService(client)), CacheConfig{})
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "k", "v1", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.Set(ctx, "k", "v2", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if mr.Exists("k") {
+		t.Fatal("Set with ttl <= 0 should not leave the key cached")
+	}
+}
+
+func TestCache_LoaderPanicIsReturnedAsError(t *testing.T) {
+	var reported atomic.Int32
+	c := NewCache(NewLocalBackend[int](10), CacheConfig{
+		EarlyRefreshBeta: 1e9,
+		OnError:          func(error) { reported.Add(1) },
+	})
+	ctx := context.Background()
+	panics := func(context.Context) (int, error) { panic("boom") }
+
+	if _, err := c.GetOrLoad(ctx, "k", time.Minute, panics); err == nil {
+		t.Fatal("expected the loader panic as an error")
+	}
+
+	// A panic in a background refresh is reported, not raised.
+	slow := func(context.Context) (int, error) {
+		time.Sleep(time.Millisecond) // a LoadTime > 0 enables early refresh
+		return 1, nil
+	}
+	if _, err := c.GetOrLoad(ctx, "r", time.Hour, slow); err != nil {
+		t.Fatalf("GetOrLoad: %v", err)
+	}
+	if v, err := c.GetOrLoad(ctx, "r", time.Hour, panics); err != nil || v != 1 {
+		t.Fatalf("GetOrLoad = (%d, %v), want the cached 1", v, err)
+	}
+	eventually(t, func() bool { return reported.Load() == 1 })
+}
+
+func TestCache_LoadDoesNotOverwriteConcurrentWrite(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		write func(c *Cache[int]) error
+		want  func(v int, ok bool) bool
+	}{
+		{"Set", func(c *Cache[int]) error { return c.Set(ctx, "k", 2, time.Minute) }, func(v int, ok bool) bool { return ok && v == 2 }},
+		{"Delete", func(c *Cache[int]) error { return c.Delete(ctx, "k") }, func(_ int, ok bool) bool { return !ok }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewLocalBackend[int](10)
+			c := NewCache(backend, CacheConfig{})
+			release := make(chan struct{})
+			started := make(chan struct{})
+			stale := func(context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = c.GetOrLoad(ctx, "k", time.Minute, stale)
+			}()
+			<-started
+			if err := tt.write(c); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			close(release)
+			<-done
+
+			entry, ok, _ := backend.Get(ctx, "k")
+			if !tt.want(entry.Value, ok) {
+				t.Fatalf("backend has (%d, %v): the stale load overwrote the %s", entry.Value, ok, tt.name)
+			}
+		})
+	}
+}
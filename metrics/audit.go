@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// Nombres de métricas del pipeline asíncrono de auditoría.
+const (
+	MetricAuditEventsDropped  = "audit_events_dropped_total"
+	MetricAuditQueueOverflows = "audit_queue_overflows_total"
+	MetricAuditQueueDepth     = "audit_queue_depth"
+	MetricAuditBatchFlushes   = "audit_batch_flushes_total"
+	MetricAuditBatchDuration  = "audit_batch_flush_duration_seconds"
+	MetricAuditBatchSize      = "audit_batch_size"
+)
+
+// RecordAuditEventDropped registra un evento de auditoría descartado sin persistir.
+// reason: "queue_full", "closed", "context_canceled", "flush_error".
+func (m *Metrics) RecordAuditEventDropped(reason string, count int) {
+	m.recorder.CounterAdd(MetricAuditEventsDropped, float64(count), map[string]string{
+		"service": m.service,
+		"reason":  reason,
+	})
+}
+
+// RecordAuditQueueOverflow registra que un evento encontró la cola de auditoría llena.
+// blocked indica si el productor esperó espacio (backpressure) en lugar de descartar.
+func (m *Metrics) RecordAuditQueueOverflow(blocked bool) {
+	m.recorder.CounterAdd(MetricAuditQueueOverflows, 1, map[string]string{
+		"service": m.service,
+		"blocked": strconv.FormatBool(blocked),
+	})
+}
+
+// SetAuditQueueDepth establece el número de eventos pendientes en la cola de auditoría.
+func (m *Metrics) SetAuditQueueDepth(depth int) {
+	m.recorder.GaugeSet(MetricAuditQueueDepth, float64(depth), map[string]string{
+		"service": m.service,
+	})
+}
+
+// RecordAuditBatchFlush registra la escritura de un lote de eventos de auditoría.
+func (m *Metrics) RecordAuditBatchFlush(size int, duration time.Duration, err error) {
+	labels := map[string]string{
+		"service": m.service,
+		"status":  statusLabel(err),
+	}
+	m.recorder.CounterAdd(MetricAuditBatchFlushes, 1, labels)
+	m.recorder.HistogramObserve(MetricAuditBatchDuration, durationSeconds(duration), labels)
+	m.recorder.HistogramObserve(MetricAuditBatchSize, float64(size), labels)
+}
@@ -63,6 +63,10 @@ func TestNoop_NeverPanics(t *testing.T) {
 	m.SetCircuitBreakerState("openai", "open")
 	m.SetHTTPActiveRequests(5)
 	m.SetDBConnectionsOpen("postgres", 10)
+	m.RecordAuditEventDropped("queue_full", 1)
+	m.RecordAuditQueueOverflow(true)
+	m.SetAuditQueueDepth(7)
+	m.RecordAuditBatchFlush(50, time.Millisecond, nil)
 }
 
 func TestSpyRecorder_CapturesMetrics(t *testing.T) {
@@ -89,6 +93,34 @@ func TestSpyRecorder_CapturesMetrics(t *testing.T) {
 	}
 }
 
+func TestSpyRecorder_CapturesAuditMetrics(t *testing.T) {
+	rec := &spyRecorder{}
+	m := New("test", rec)
+
+	m.RecordAuditEventDropped("flush_error", 25)
+	m.RecordAuditQueueOverflow(false)
+	m.RecordAuditBatchFlush(25, 10*time.Millisecond, errors.New("db down"))
+
+	if len(rec.counters) != 3 {
+		t.Fatalf("expected 3 counters, got %d", len(rec.counters))
+	}
+	if rec.counters[0].name != MetricAuditEventsDropped || rec.counters[0].value != 25 {
+		t.Errorf("expected %s=25, got %s=%f", MetricAuditEventsDropped, rec.counters[0].name, rec.counters[0].value)
+	}
+	if rec.counters[0].labels["reason"] != "flush_error" {
+		t.Errorf("expected reason 'flush_error', got '%s'", rec.counters[0].labels["reason"])
+	}
+	if rec.counters[1].labels["blocked"] != "false" {
+		t.Errorf("expected blocked 'false', got '%s'", rec.counters[1].labels["blocked"])
+	}
+	if rec.counters[2].labels["status"] != "error" {
+		t.Errorf("expected status 'error', got '%s'", rec.counters[2].labels["status"])
+	}
+	if len(rec.histograms) != 2 || rec.histograms[1].value != 25 {
+		t.Errorf("expected batch size histogram with value 25, got %+v", rec.histograms)
+	}
+}
+
 func TestStatusLabel(t *testing.T) {
 	if statusLabel(nil) != "success" {
 		t.Error("nil error should be 'success'")
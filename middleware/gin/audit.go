@@ -5,6 +5,7 @@ import (
 
 	"github.com/EduGoGroup/edugo-shared/audit"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // AuditMiddleware registra automáticamente todas las peticiones mutantes
@@ -52,22 +53,36 @@ func AuditMiddleware(logger audit.AuditLogger) gin.HandlerFunc { //nolint:gocycl
 			}
 		}
 
-		// Extraer school_id y unit_id de los claims JWT
+		// Extraer school_id y unit_id de los claims JWT. Se conservan en
+		// Metadata por compatibilidad con consumidores existentes y, si son
+		// UUID válidos, se asignan a las columnas del evento (filtrables por
+		// audit.Reader); un valor inválido haría fallar el INSERT.
 		if claims, err := GetClaims(c); err == nil && claims.ActiveContext != nil {
-			if claims.ActiveContext.SchoolID != "" {
+			if schoolID := claims.ActiveContext.SchoolID; schoolID != "" {
 				if event.Metadata == nil {
 					event.Metadata = make(map[string]any)
 				}
-				event.Metadata["school_id"] = claims.ActiveContext.SchoolID
+				event.Metadata["school_id"] = schoolID
+				if _, err := uuid.Parse(schoolID); err == nil {
+					event.SchoolID = schoolID
+				}
 			}
-			if claims.ActiveContext.AcademicUnitID != "" {
+			if unitID := claims.ActiveContext.AcademicUnitID; unitID != "" {
 				if event.Metadata == nil {
 					event.Metadata = make(map[string]any)
 				}
-				event.Metadata["unit_id"] = claims.ActiveContext.AcademicUnitID
+				event.Metadata["unit_id"] = unitID
+				if _, err := uuid.Parse(unitID); err == nil {
+					event.UnitID = unitID
+				}
 			}
 		}
 
+		// En modo representante: guardián que actúa y acudido (ADR 0026).
+		for _, opt := range WardAuditOptions(c) {
+			opt(&event)
+		}
+
 		_ = logger.Log(c.Request.Context(), event) //nolint:errcheck
 	}
 }
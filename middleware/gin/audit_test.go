@@ -9,6 +9,7 @@ import (
 	"github.com/EduGoGroup/edugo-shared/audit"
 	"github.com/EduGoGroup/edugo-shared/auth"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -218,13 +219,14 @@ func TestAuditMiddleware_ConContextoUsuario(t *testing.T) {
 func TestAuditMiddleware_ConClaimsYSchoolID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	schoolID, unitID := uuid.NewString(), uuid.NewString()
 	logger := &capturingLogger{}
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set(ContextKeyClaims, &auth.Claims{
 			ActiveContext: &auth.UserContext{
-				SchoolID:       "school-123",
-				AcademicUnitID: "unit-456",
+				SchoolID:       schoolID,
+				AcademicUnitID: unitID,
 				RoleName:       "admin",
 			},
 		})
@@ -242,8 +244,37 @@ func TestAuditMiddleware_ConClaimsYSchoolID(t *testing.T) {
 
 	require.Len(t, logger.events, 1)
 	require.NotNil(t, logger.events[0].Metadata)
+	assert.Equal(t, schoolID, logger.events[0].Metadata["school_id"])
+	assert.Equal(t, unitID, logger.events[0].Metadata["unit_id"])
+	assert.Equal(t, schoolID, logger.events[0].SchoolID)
+	assert.Equal(t, unitID, logger.events[0].UnitID)
+}
+
+func TestAuditMiddleware_ClaimsSinUUIDSoloEnMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := &capturingLogger{}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(ContextKeyClaims, &auth.Claims{
+			ActiveContext: &auth.UserContext{SchoolID: "school-123", AcademicUnitID: "unit-456"},
+		})
+		c.Next()
+	})
+	router.Use(AuditMiddleware(logger))
+	router.POST("/api/v1/roles", func(c *gin.Context) {
+		c.JSON(200, gin.H{})
+	})
+
+	req, err := http.NewRequest("POST", "/api/v1/roles", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, logger.events, 1)
 	assert.Equal(t, "school-123", logger.events[0].Metadata["school_id"])
 	assert.Equal(t, "unit-456", logger.events[0].Metadata["unit_id"])
+	assert.Empty(t, logger.events[0].SchoolID)
+	assert.Empty(t, logger.events[0].UnitID)
 }
 
 func TestAuditMiddleware_ConClaimsSinActiveContext(t *testing.T) {
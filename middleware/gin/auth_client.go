@@ -26,6 +26,11 @@ type TokenInfo struct {
 }
 
 // AuthClientConfig configura el cliente de autenticacion.
+//
+// La validacion local usa JWKSURL (claves publicas del emisor, RS256/EdDSA)
+// si esta definido, o JWTSecret (HMAC compartido) en caso contrario. La
+// validacion remota contra BaseURL puede combinarse con cualquiera de ellas
+// como fallback.
 type AuthClientConfig struct {
 	JWTSecret       string
 	JWTIssuer       string
@@ -35,9 +40,14 @@ type AuthClientConfig struct {
 	FallbackEnabled bool
 	CacheTTL        time.Duration
 	CacheEnabled    bool
+	// JWKSURL es la URL del JWKS del emisor (p. ej. "https://auth.edugo.com"+auth.JWKSPath).
+	JWKSURL string
+	// JWKSRefreshInterval es la vigencia del JWKS en cache (default auth.DefaultJWKSRefreshInterval).
+	JWKSRefreshInterval time.Duration
 }
 
-// AuthClient valida tokens JWT localmente con fallback remoto opcional.
+// AuthClient valida tokens JWT localmente (JWKS o secreto compartido) con
+// fallback remoto opcional.
 type AuthClient struct {
 	jwtManager *auth.JWTManager
 	baseURL    string
@@ -58,15 +68,25 @@ func NewAuthClient(cfg AuthClientConfig) *AuthClient {
 		cfg.JWTIssuer = "edugo-central"
 	}
 
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+
 	var jwtMgr *auth.JWTManager
-	if cfg.JWTSecret != "" {
+	switch {
+	case cfg.JWKSURL != "":
+		jwks := auth.NewJWKSClient(cfg.JWKSURL, auth.JWKSClientConfig{
+			HTTPClient:      httpClient,
+			RefreshInterval: cfg.JWKSRefreshInterval,
+			FetchTimeout:    cfg.Timeout,
+		})
+		jwtMgr = auth.NewJWTManagerWithResolver(jwks, cfg.JWTIssuer)
+	case cfg.JWTSecret != "":
 		jwtMgr = auth.NewJWTManager(cfg.JWTSecret, cfg.JWTIssuer)
 	}
 
 	return &AuthClient{
 		jwtManager: jwtMgr,
 		baseURL:    cfg.BaseURL,
-		httpClient: &http.Client{Timeout: cfg.Timeout},
+		httpClient: httpClient,
 		cache:      newTokenCache(cfg.CacheTTL),
 		config:     cfg,
 	}
@@ -2,6 +2,8 @@ package gin
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -182,3 +184,40 @@ func TestValidateToken_RemoteInvalidJSON(t *testing.T) {
 	assert.False(t, info.Valid)
 	assert.True(t, strings.Contains(info.Error, "remote validation failed"))
 }
+
+func TestValidateToken_JWKS(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := auth.NewSigningKey("ed-1", priv)
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet(key)
+	require.NoError(t, err)
+
+	jwksServer := httptest.NewServer(auth.NewJWKSHandler(keys, 0))
+	defer jwksServer.Close()
+
+	client := NewAuthClient(AuthClientConfig{
+		JWKSURL:      jwksServer.URL + auth.JWKSPath,
+		JWTSecret:    "ignored-when-jwks-is-set",
+		CacheEnabled: true,
+	})
+
+	issuer := auth.NewJWTManagerWithKeySet(keys, "edugo-central")
+	ctx := &auth.UserContext{RoleID: "r1", RoleName: "teacher"}
+	token, _, err := issuer.GenerateTokenWithContext("user-1", "user@test.com", ctx, 10*time.Minute)
+	require.NoError(t, err)
+
+	info, err := client.ValidateToken(context.Background(), token)
+	require.NoError(t, err)
+	assert.True(t, info.Valid)
+	assert.Equal(t, "user-1", info.UserID)
+
+	// Un token HMAC no es aceptado cuando se valida con el JWKS.
+	//nolint:gosec // Secreto de prueba para tokens en tests unitarios.
+	hmacToken, _, err := auth.NewJWTManager("ignored-when-jwks-is-set", "edugo-central").
+		GenerateTokenWithContext("user-1", "user@test.com", ctx, 10*time.Minute)
+	require.NoError(t, err)
+	info, err = client.ValidateToken(context.Background(), hmacToken)
+	require.NoError(t, err)
+	assert.False(t, info.Valid)
+}
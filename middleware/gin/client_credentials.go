@@ -0,0 +1,131 @@
+package gin
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Códigos de error OAuth2 del token endpoint (RFC 6749 §5.2).
+const (
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidScope         = "invalid_scope"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorServerError          = "server_error"
+)
+
+// ServiceTokenIssuer es la interfaz mínima que ClientCredentialsHandler
+// necesita para emitir service JWTs. La implementa
+// `auth.ClientCredentialsIssuer`.
+type ServiceTokenIssuer interface {
+	Issue(ctx context.Context, clientID, clientSecret string, scopes []string) (*auth.ServiceToken, error)
+}
+
+// ClientCredentialsHandler es el token endpoint OAuth2 del grant
+// client_credentials (RFC 6749 §4.4), p. ej. `POST /api/v1/auth/token`.
+// Recibe un formulario con grant_type=client_credentials y scope opcional
+// separado por espacios; el cliente se autentica con HTTP Basic
+// (client_secret_basic) o con client_id/client_secret en el formulario
+// (client_secret_post), nunca con ambos.
+//
+// Responde 200 con access_token, token_type, expires_in y scope, y errores
+// con el formato OAuth2 {"error": ...}: 400 invalid_request,
+// unsupported_grant_type o invalid_scope; 401 invalid_client (con
+// WWW-Authenticate si se usó Basic) y 500 server_error.
+func ClientCredentialsHandler(issuer ServiceTokenIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Las respuestas del token endpoint nunca se cachean (RFC 6749 §5.1).
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		if err := c.Request.ParseForm(); err != nil {
+			oauthError(c, http.StatusBadRequest, OAuthErrorInvalidRequest, "malformed form body")
+			return
+		}
+		form := c.Request.PostForm
+
+		grantType := form.Get("grant_type")
+		switch grantType {
+		case "":
+			oauthError(c, http.StatusBadRequest, OAuthErrorInvalidRequest, "grant_type is required")
+			return
+		case auth.GrantTypeClientCredentials:
+		default:
+			oauthError(c, http.StatusBadRequest, OAuthErrorUnsupportedGrantType, "")
+			return
+		}
+
+		clientID, clientSecret, usedBasic, ok := clientCredentials(c)
+		if !ok {
+			oauthError(c, http.StatusBadRequest, OAuthErrorInvalidRequest, "exactly one client authentication method is required")
+			return
+		}
+
+		token, err := issuer.Issue(c.Request.Context(), clientID, clientSecret, strings.Fields(form.Get("scope")))
+		switch {
+		case err == nil:
+		case stdErrors.Is(err, auth.ErrInvalidClient):
+			GetLogger(c).Warn("client credentials rejected",
+				"client_id", clientID,
+				logger.FieldPath, requestPath(c),
+				logger.FieldIP, c.ClientIP(),
+			)
+			if usedBasic {
+				c.Header("WWW-Authenticate", `Basic realm="edugo"`)
+			}
+			oauthError(c, http.StatusUnauthorized, OAuthErrorInvalidClient, "")
+			return
+		case stdErrors.Is(err, auth.ErrInvalidScope):
+			oauthError(c, http.StatusBadRequest, OAuthErrorInvalidScope, "")
+			return
+		default:
+			GetLogger(c).Error("client credentials token issuance failed",
+				"client_id", clientID,
+				logger.FieldPath, requestPath(c),
+				logger.FieldError, err.Error(),
+			)
+			oauthError(c, http.StatusInternalServerError, OAuthErrorServerError, "")
+			return
+		}
+
+		c.JSON(http.StatusOK, auth.NewTokenResponse(token, time.Now()))
+	}
+}
+
+// clientCredentials extrae las credenciales del cliente de HTTP Basic o del
+// formulario. ok es false si faltan o si vienen por ambos métodos.
+func clientCredentials(c *gin.Context) (clientID, clientSecret string, usedBasic, ok bool) {
+	formID := c.Request.PostForm.Get("client_id")
+	formSecret := c.Request.PostForm.Get("client_secret")
+
+	basicID, basicSecret, hasBasic := c.Request.BasicAuth()
+	if hasBasic {
+		if formSecret != "" {
+			return "", "", true, false
+		}
+		// RFC 6749 §2.3.1: client_id y secret van form-urlencoded en Basic.
+		id, errID := url.QueryUnescape(basicID)
+		secret, errSecret := url.QueryUnescape(basicSecret)
+		if errID != nil || errSecret != nil || id == "" {
+			return "", "", true, false
+		}
+		return id, secret, true, true
+	}
+	if formID == "" || formSecret == "" {
+		return "", "", false, false
+	}
+	return formID, formSecret, false, true
+}
+
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, auth.TokenErrorResponse{Error: code, ErrorDescription: description})
+	c.Abort()
+}
@@ -0,0 +1,160 @@
+package gin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTokenRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	hasher, err := auth.NewArgon2idHasher(auth.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	require.NoError(t, err)
+	hash, err := hasher.Hash("worker-secret")
+	require.NoError(t, err)
+
+	store := auth.NewInMemoryServiceClientStore(auth.ServiceClient{
+		ClientID:   "edugo-worker",
+		SecretHash: hash,
+		Scopes:     []enum.Scope{enum.ScopeNotificationsDispatch},
+	})
+	issuer := auth.NewClientCredentialsIssuer(store, newServiceManager(), auth.ClientCredentialsConfig{Hasher: hasher})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/auth/token", ClientCredentialsHandler(issuer))
+	return router
+}
+
+func doTokenRequest(router *gin.Engine, form url.Values, basicID, basicSecret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if basicID != "" {
+		req.SetBasicAuth(basicID, basicSecret)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestClientCredentialsHandler_IssuesValidServiceToken(t *testing.T) {
+	router := newTestTokenRouter(t)
+
+	for name, send := range map[string]func() *httptest.ResponseRecorder{
+		"client_secret_basic": func() *httptest.ResponseRecorder {
+			return doTokenRequest(router, url.Values{"grant_type": {"client_credentials"}, "scope": {dispatch}}, "edugo-worker", "worker-secret")
+		},
+		"client_secret_post": func() *httptest.ResponseRecorder {
+			return doTokenRequest(router, url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {"edugo-worker"},
+				"client_secret": {"worker-secret"},
+			}, "", "")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := send()
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+			var resp auth.TokenResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, "Bearer", resp.TokenType)
+			assert.Equal(t, dispatch, resp.Scope)
+			assert.InDelta(t, auth.DefaultServiceTokenTTL.Seconds(), resp.ExpiresIn, 2)
+
+			// El token emitido pasa ServiceJWTAuthMiddleware.
+			w = doServiceRequest(setupServiceRouter(newServiceManager(), dispatch), "Bearer "+resp.AccessToken)
+			assert.Equal(t, http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestClientCredentialsHandler_Errors(t *testing.T) {
+	router := newTestTokenRouter(t)
+	cc := url.Values{"grant_type": {"client_credentials"}}
+
+	tests := []struct {
+		name        string
+		form        url.Values
+		basicID     string
+		basicSecret string
+		wantStatus  int
+		wantError   string
+	}{
+		{"sin grant_type", url.Values{}, "edugo-worker", "worker-secret", http.StatusBadRequest, OAuthErrorInvalidRequest},
+		{"grant_type no soportado", url.Values{"grant_type": {"password"}}, "edugo-worker", "worker-secret", http.StatusBadRequest, OAuthErrorUnsupportedGrantType},
+		{"sin credenciales", cc, "", "", http.StatusBadRequest, OAuthErrorInvalidRequest},
+		{
+			"credenciales por ambos métodos",
+			url.Values{"grant_type": {"client_credentials"}, "client_secret": {"worker-secret"}},
+			"edugo-worker", "worker-secret", http.StatusBadRequest, OAuthErrorInvalidRequest,
+		},
+		{"secret incorrecto", cc, "edugo-worker", "otro", http.StatusUnauthorized, OAuthErrorInvalidClient},
+		{"cliente inexistente", cc, "edugo-ghost", "worker-secret", http.StatusUnauthorized, OAuthErrorInvalidClient},
+		{
+			"scope no permitido",
+			url.Values{"grant_type": {"client_credentials"}, "scope": {"admin.everything"}},
+			"edugo-worker", "worker-secret", http.StatusBadRequest, OAuthErrorInvalidScope,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doTokenRequest(router, tt.form, tt.basicID, tt.basicSecret)
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var resp auth.TokenErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantError, resp.Error)
+			if tt.wantStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+type failingTokenIssuer struct{}
+
+func (failingTokenIssuer) Issue(context.Context, string, string, []string) (*auth.ServiceToken, error) {
+	return nil, errors.New("db down")
+}
+
+func TestClientCredentialsHandler_IssuerFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/auth/token", ClientCredentialsHandler(failingTokenIssuer{}))
+
+	w := doTokenRequest(router, url.Values{"grant_type": {"client_credentials"}}, "edugo-worker", "worker-secret")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), OAuthErrorServerError)
+	assert.NotContains(t, w.Body.String(), "db down")
+}
+
+func TestClientCredentialsClient_AgainstHandler(t *testing.T) {
+	srv := httptest.NewServer(newTestTokenRouter(t))
+	defer srv.Close()
+
+	client := auth.NewClientCredentialsClient(auth.ClientCredentialsClientConfig{
+		TokenURL:     srv.URL + "/api/v1/auth/token",
+		ClientID:     "edugo-worker",
+		ClientSecret: "worker-secret",
+		Scopes:       []string{dispatch},
+	})
+	token, err := client.Token(context.Background())
+	require.NoError(t, err)
+
+	claims, err := newServiceManager().ValidateServiceToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "edugo-worker", claims.ClientID)
+}
@@ -84,7 +84,8 @@ func JWTAuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 }
 
 // JWTAuthMiddlewareWithBlacklist creates a JWT auth middleware that also checks
-// if the token has been revoked via the blacklist.
+// if the token has been revoked via the blacklist, by JTI or, when the
+// blacklist implements auth.UserTokenRevoker, by the user's bulk revocation.
 func JWTAuthMiddlewareWithBlacklist(jwtManager *auth.JWTManager, blacklist auth.TokenBlacklist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. Verificar header Authorization
@@ -139,7 +140,7 @@ func JWTAuthMiddlewareWithBlacklist(jwtManager *auth.JWTManager, blacklist auth.
 		}
 
 		// 4. Verificar si el token fue revocado
-		if blacklist != nil && blacklist.IsRevoked(claims.ID) {
+		if auth.IsTokenRevoked(blacklist, claims) {
 			GetLogger(c).Warn("revoked token used",
 				logger.FieldPath, requestPath(c),
 				logger.FieldMethod, requestMethod(c),
@@ -408,6 +408,45 @@ func TestJWTAuthMiddlewareWithBlacklist_RevokedToken(t *testing.T) {
 	}
 }
 
+func TestJWTAuthMiddlewareWithBlacklist_UserRevokedAllBefore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtManager := auth.NewJWTManager("test-secret-key", "test-issuer")
+
+	activeContext := &auth.UserContext{
+		RoleID:   "role-student",
+		RoleName: "Student",
+		Grants:   auth.Grants{Allow: []string{"content.materials.read"}},
+	}
+	token, _, err := jwtManager.GenerateTokenWithContext("user-123", "test@edugo.com", activeContext, time.Hour)
+	require.NoError(t, err)
+
+	// Revoke every token of the user issued before a cutoff after the token's iat
+	blacklist := auth.NewInMemoryBlacklist(t.Context())
+	blacklist.RevokeAllBefore("user-123", time.Now().Add(time.Second), time.Now().Add(time.Hour))
+
+	router := gin.New()
+	router.Use(JWTAuthMiddlewareWithBlacklist(jwtManager, blacklist))
+	router.GET("/test", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+
+	req, err := http.NewRequest("GET", "/test", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 401 {
+		t.Errorf("Expected status 401 for bulk-revoked token, got %d", w.Code)
+	}
+
+	if !containsString(w.Body.String(), "TOKEN_REVOKED") {
+		t.Errorf("Expected TOKEN_REVOKED code, got: %s", w.Body.String())
+	}
+}
+
 func TestJWTAuthMiddlewareWithBlacklist_InvalidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,146 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/EduGoGroup/edugo-shared/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyPermissionScope guarda el auth.PermissionScope con que
+// RequirePermissionOrOwn dejó pasar la request, para que el handler sepa si
+// debe restringirse a los recursos propios.
+const ContextKeyPermissionScope = "permission_scope"
+
+// OwnerResolver confirma la propiedad de un recurso para
+// RequirePermissionOrOwn. ResourceParam es el route param con el ID del
+// recurso; IsOwner reporta si alguno de ownerIDs (ver auth.Claims.OwnerIDs)
+// es su dueño.
+type OwnerResolver interface {
+	ResourceParam() string
+	IsOwner(ctx context.Context, resourceID string, ownerIDs []string) (bool, error)
+}
+
+// OwnerResolverFunc adapta una función a OwnerResolver sobre el route param
+// dado.
+func OwnerResolverFunc(param string, isOwner func(ctx context.Context, resourceID string, ownerIDs []string) (bool, error)) OwnerResolver {
+	return ownerResolverFunc{param: param, isOwner: isOwner}
+}
+
+type ownerResolverFunc struct {
+	param   string
+	isOwner func(ctx context.Context, resourceID string, ownerIDs []string) (bool, error)
+}
+
+func (r ownerResolverFunc) ResourceParam() string { return r.param }
+
+func (r ownerResolverFunc) IsOwner(ctx context.Context, resourceID string, ownerIDs []string) (bool, error) {
+	return r.isOwner(ctx, resourceID, ownerIDs)
+}
+
+// SelfOwnerResolver resuelve recursos que son el propio usuario (o el
+// acudido), como en `admin.users.read:own` sobre /users/:id: el recurso es
+// propio si el param coincide con alguno de los ownerIDs.
+func SelfOwnerResolver(param string) OwnerResolver {
+	return OwnerResolverFunc(param, func(_ context.Context, resourceID string, ownerIDs []string) (bool, error) {
+		return slices.Contains(ownerIDs, resourceID), nil
+	})
+}
+
+// RequirePermissionOrOwn deja pasar si los grants cubren permission, o si
+// cubren su variante `:own` y resolver confirma que el recurso del route
+// param es del usuario o, en modo representante, de su acudido:
+//
+//	r.GET("/users/:id",
+//	    RequirePermissionOrOwn(enum.PermissionUsersRead, SelfOwnerResolver("id")),
+//	    handler)
+//
+// El alcance concedido queda en ContextKeyPermissionScope. Responde 403
+// INSUFFICIENT_PERMISSIONS sin ninguno de los dos permisos, 403
+// NOT_RESOURCE_OWNER si el recurso es ajeno y 500 OWNERSHIP_CHECK_FAILED si
+// el resolver falla.
+func RequirePermissionOrOwn(permission enum.Permission, resolver OwnerResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userClaims := getValidatedClaims(c)
+		if userClaims == nil {
+			c.Abort()
+			return
+		}
+
+		scope := compiledGrants(c, userClaims).Scope(permission.String())
+		if scope == auth.PermissionScopeOwn {
+			scope = resolveOwnership(c, userClaims, permission, resolver)
+			if c.IsAborted() {
+				return
+			}
+		}
+		recordPermissionCheck(permission.String(), scope != auth.PermissionScopeNone)
+
+		if scope == auth.PermissionScopeNone {
+			fields := []any{
+				"required_permission", permission.String(),
+				logger.FieldPath, requestPath(c),
+				logger.FieldMethod, requestMethod(c),
+			}
+			fields = append(fields, explainDenied(c, userClaims,
+				[]string{permission.String(), auth.OwnPermission(permission.String())})...)
+			GetLogger(c).Warn("permission denied", fields...)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+				"code":  "INSUFFICIENT_PERMISSIONS",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(ContextKeyPermissionScope, scope)
+		c.Next()
+	}
+}
+
+// resolveOwnership confirma con resolver el alcance `:own`. Retorna
+// PermissionScopeOwn si el recurso es propio; si es ajeno o la consulta
+// falla responde y aborta la request.
+func resolveOwnership(c *gin.Context, claims *auth.Claims, permission enum.Permission, resolver OwnerResolver) auth.PermissionScope {
+	reqLogger := GetLogger(c)
+	resourceID := c.Param(resolver.ResourceParam())
+	owned := false
+	if resourceID != "" {
+		var err error
+		owned, err = resolver.IsOwner(c.Request.Context(), resourceID, claims.OwnerIDs())
+		if err != nil {
+			reqLogger.Error("ownership check failed",
+				"required_permission", permission.String(),
+				"resource_id", resourceID,
+				logger.FieldError, err.Error(),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "ownership check failed",
+				"code":  "OWNERSHIP_CHECK_FAILED",
+			})
+			c.Abort()
+			return auth.PermissionScopeNone
+		}
+	}
+	if owned {
+		return auth.PermissionScopeOwn
+	}
+
+	recordPermissionCheck(permission.String(), false)
+	reqLogger.Warn("resource not owned",
+		"required_permission", auth.OwnPermission(permission.String()),
+		"resource_id", resourceID,
+		logger.FieldPath, requestPath(c),
+		logger.FieldMethod, requestMethod(c),
+	)
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": "forbidden",
+		"code":  "NOT_RESOURCE_OWNER",
+	})
+	c.Abort()
+	return auth.PermissionScopeNone
+}
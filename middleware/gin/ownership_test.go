@@ -0,0 +1,88 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveOwnership(claims *auth.Claims, resolver OwnerResolver, path string) (*httptest.ResponseRecorder, any) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var scope any
+	router.GET("/users/:id", func(c *gin.Context) {
+		c.Set(ContextKeyClaims, claims)
+		c.Next()
+	}, RequirePermissionOrOwn(enum.PermissionUsersRead, resolver), func(c *gin.Context) {
+		scope, _ = c.Get(ContextKeyPermissionScope)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w, scope
+}
+
+func TestRequirePermissionOrOwn(t *testing.T) {
+	ownClaims := func(ctx *auth.UserContext) *auth.Claims {
+		ctx.RoleID = "role-student"
+		ctx.Grants = auth.Grants{Allow: []string{"admin.users.read:own"}}
+		return &auth.Claims{UserID: "user-1", ActiveContext: ctx}
+	}
+
+	t.Run("permiso completo no consulta el resolver", func(t *testing.T) {
+		claims := &auth.Claims{UserID: "user-1", ActiveContext: &auth.UserContext{
+			Grants: auth.Grants{Allow: []string{"admin.users.*"}},
+		}}
+		resolver := OwnerResolverFunc("id", func(context.Context, string, []string) (bool, error) {
+			t.Fatal("no debe consultarse el resolver")
+			return false, nil
+		})
+		w, scope := serveOwnership(claims, resolver, "/users/user-2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, auth.PermissionScopeAll, scope)
+	})
+
+	t.Run(":own sobre el propio recurso", func(t *testing.T) {
+		w, scope := serveOwnership(ownClaims(&auth.UserContext{}), SelfOwnerResolver("id"), "/users/user-1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, auth.PermissionScopeOwn, scope)
+	})
+
+	t.Run(":own sobre recurso ajeno", func(t *testing.T) {
+		w, _ := serveOwnership(ownClaims(&auth.UserContext{}), SelfOwnerResolver("id"), "/users/user-2")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "NOT_RESOURCE_OWNER")
+	})
+
+	t.Run(":own del acudido en modo representante", func(t *testing.T) {
+		claims := ownClaims(&auth.UserContext{SubjectStudentID: "student-9", ActorMode: auth.ActorModeWard})
+		w, scope := serveOwnership(claims, SelfOwnerResolver("id"), "/users/student-9")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, auth.PermissionScopeOwn, scope)
+	})
+
+	t.Run("error del resolver", func(t *testing.T) {
+		resolver := OwnerResolverFunc("id", func(context.Context, string, []string) (bool, error) {
+			return false, errors.New("db down")
+		})
+		w, _ := serveOwnership(ownClaims(&auth.UserContext{}), resolver, "/users/user-1")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "OWNERSHIP_CHECK_FAILED")
+	})
+
+	t.Run("sin permiso ni variante :own", func(t *testing.T) {
+		claims := &auth.Claims{UserID: "user-1", ActiveContext: &auth.UserContext{
+			Grants: auth.Grants{Allow: []string{"reports.read"}},
+		}}
+		w, _ := serveOwnership(claims, SelfOwnerResolver("id"), "/users/user-1")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "INSUFFICIENT_PERMISSIONS")
+	})
+}
@@ -93,6 +93,72 @@ func getValidatedClaims(c *gin.Context) *auth.Claims {
 	return userClaims
 }
 
+// ContextKeyCompiledGrants guarda los grants del contexto activo ya
+// compilados (auth.CompiledGrants), para que los checks encadenados de una
+// misma request no vuelvan a indexarlos.
+const ContextKeyCompiledGrants = "compiled_grants"
+
+// compiledGrants compila los grants del contexto activo la primera vez que
+// se piden en la request y reutiliza el resultado en los checks siguientes.
+func compiledGrants(c *gin.Context, claims *auth.Claims) *auth.CompiledGrants {
+	if v, ok := c.Get(ContextKeyCompiledGrants); ok {
+		if cg, ok := v.(*auth.CompiledGrants); ok {
+			return cg
+		}
+	}
+	cg := auth.CompileGrants(claims.ActiveContext.Grants)
+	c.Set(ContextKeyCompiledGrants, cg)
+	return cg
+}
+
+// HeaderGrantsExplanation es el header de debug que, si se habilita con
+// SetGrantsExplanation, acompaña los 403 de permisos con el resumen de
+// auth.ExplainGrants de cada permiso denegado.
+const HeaderGrantsExplanation = "X-Grants-Explanation"
+
+// GrantsExplanationConfig habilita la explicación de los 403 de
+// RequirePermission, RequireAnyPermission y RequireAllPermissions. Log
+// agrega el campo grants_explanation al warning; Header además la expone al
+// cliente en HeaderGrantsExplanation (solo para entornos de soporte: revela
+// los patterns del rol).
+type GrantsExplanationConfig struct {
+	Log    bool
+	Header bool
+}
+
+var grantsExplanationConfig atomic.Value // GrantsExplanationConfig
+
+// SetGrantsExplanation configura la explicación de denegaciones; por
+// defecto está deshabilitada. Afecta también a los middlewares ya
+// construidos.
+func SetGrantsExplanation(cfg GrantsExplanationConfig) {
+	grantsExplanationConfig.Store(cfg)
+}
+
+func loadGrantsExplanationConfig() GrantsExplanationConfig {
+	cfg, _ := grantsExplanationConfig.Load().(GrantsExplanationConfig)
+	return cfg
+}
+
+// explainDenied retorna los campos de log adicionales para un 403 y, si
+// está habilitado, fija HeaderGrantsExplanation. Sin configuración no
+// retorna nada y no evalúa los grants de nuevo.
+func explainDenied(c *gin.Context, claims *auth.Claims, permissions []string) []any {
+	cfg := loadGrantsExplanationConfig()
+	if !cfg.Log && !cfg.Header {
+		return nil
+	}
+	parts := make([]string, len(permissions))
+	for i, p := range permissions {
+		parts[i] = p + ": " + auth.ExplainGrants(claims.ActiveContext.Grants, p).String()
+	}
+	explanation := strings.Join(parts, " | ")
+	if cfg.Header {
+		c.Header(HeaderGrantsExplanation, explanation)
+	}
+	return []any{"grants_explanation", explanation}
+}
+
 func recordPermissionCheck(permission string, granted bool) {
 	rec := loadPermissionMetricsRecorder()
 	if rec == nil {
@@ -103,7 +169,7 @@ func recordPermissionCheck(permission string, granted bool) {
 
 // RequirePermission valida que los grants del usuario cubran el
 // permission dado. Aplica deny precedence + glob matching path-based
-// (auth.EvaluateGrants).
+// (auth.EvaluateGrants, sobre los grants compilados de la request).
 func RequirePermission(permission enum.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userClaims := getValidatedClaims(c)
@@ -112,16 +178,18 @@ func RequirePermission(permission enum.Permission) gin.HandlerFunc {
 			return
 		}
 
-		granted := auth.EvaluateGrants(userClaims.ActiveContext.Grants, permission.String())
+		granted := compiledGrants(c, userClaims).Evaluate(permission.String())
 		recordPermissionCheck(permission.String(), granted)
 
 		if !granted {
 			reqLogger := GetLogger(c)
-			reqLogger.Warn("permission denied",
+			fields := []any{
 				"required_permission", permission.String(),
 				logger.FieldPath, requestPath(c),
 				logger.FieldMethod, requestMethod(c),
-			)
+			}
+			fields = append(fields, explainDenied(c, userClaims, []string{permission.String()})...)
+			reqLogger.Warn("permission denied", fields...)
 			c.JSON(http.StatusForbidden, gin.H{
 				"error": "forbidden",
 				"code":  "INSUFFICIENT_PERMISSIONS",
@@ -143,9 +211,9 @@ func RequireAnyPermission(permissions ...enum.Permission) gin.HandlerFunc {
 			return
 		}
 
-		grants := userClaims.ActiveContext.Grants
+		grants := compiledGrants(c, userClaims)
 		for _, requiredPerm := range permissions {
-			if auth.EvaluateGrants(grants, requiredPerm.String()) {
+			if grants.Evaluate(requiredPerm.String()) {
 				c.Next()
 				return
 			}
@@ -156,11 +224,13 @@ func RequireAnyPermission(permissions ...enum.Permission) gin.HandlerFunc {
 			permNames[i] = p.String()
 		}
 		reqLogger := GetLogger(c)
-		reqLogger.Warn("permission denied",
+		fields := []any{
 			"required_permissions", strings.Join(permNames, ","),
 			logger.FieldPath, requestPath(c),
 			logger.FieldMethod, requestMethod(c),
-		)
+		}
+		fields = append(fields, explainDenied(c, userClaims, permNames)...)
+		reqLogger.Warn("permission denied", fields...)
 		c.JSON(http.StatusForbidden, gin.H{
 			"error": "insufficient permissions",
 			"code":  "INSUFFICIENT_PERMISSIONS",
@@ -178,21 +248,23 @@ func RequireAllPermissions(permissions ...enum.Permission) gin.HandlerFunc {
 			return
 		}
 
-		grants := userClaims.ActiveContext.Grants
+		grants := compiledGrants(c, userClaims)
 		missing := []string{}
 		for _, requiredPerm := range permissions {
-			if !auth.EvaluateGrants(grants, requiredPerm.String()) {
+			if !grants.Evaluate(requiredPerm.String()) {
 				missing = append(missing, requiredPerm.String())
 			}
 		}
 
 		if len(missing) > 0 {
 			reqLogger := GetLogger(c)
-			reqLogger.Warn("permission denied",
+			fields := []any{
 				"missing_permissions", strings.Join(missing, ","),
 				logger.FieldPath, requestPath(c),
 				logger.FieldMethod, requestMethod(c),
-			)
+			}
+			fields = append(fields, explainDenied(c, userClaims, missing)...)
+			reqLogger.Warn("permission denied", fields...)
 			c.JSON(http.StatusForbidden, gin.H{
 				"error":   "insufficient permissions",
 				"code":    "INSUFFICIENT_PERMISSIONS",
@@ -604,3 +604,76 @@ func TestPermissionMiddleware_Integration(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, w.Code)
 	})
 }
+
+func TestPermissionMiddlewares_CompileGrantsOncePerRequest(t *testing.T) {
+	c := createTestContextWithClaims(&auth.Claims{
+		UserID: "user-123",
+		ActiveContext: &auth.UserContext{
+			RoleID: "role-admin",
+			Grants: auth.Grants{
+				Allow: []string{"admin.users.*", "content.*.read"},
+				Deny:  []string{"admin.users.delete"},
+			},
+		},
+	})
+
+	RequirePermission(enum.PermissionUsersRead)(c)
+	require.False(t, c.IsAborted())
+	first, ok := c.Get(ContextKeyCompiledGrants)
+	require.True(t, ok)
+
+	RequireAllPermissions(enum.PermissionUsersUpdate, enum.PermissionMaterialsRead)(c)
+	require.False(t, c.IsAborted())
+	second, _ := c.Get(ContextKeyCompiledGrants)
+	assert.Same(t, first, second, "la segunda verificación debe reutilizar los grants compilados")
+
+	RequireAnyPermission(enum.PermissionUsersDelete)(c)
+	assert.True(t, c.IsAborted(), "deny debe ganar también sobre los grants compilados")
+}
+
+func TestRequirePermission_GrantsExplanation(t *testing.T) {
+	claims := &auth.Claims{
+		UserID: "user-123",
+		ActiveContext: &auth.UserContext{
+			RoleID: "role-admin",
+			Grants: auth.Grants{Allow: []string{"admin.users.*"}, Deny: []string{"*.delete"}},
+		},
+	}
+	serve := func(mw gin.HandlerFunc) *httptest.ResponseRecorder {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/test", func(c *gin.Context) {
+			c.Set(ContextKeyClaims, claims)
+			c.Next()
+		}, mw, func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+		return w
+	}
+
+	t.Run("deshabilitada por defecto", func(t *testing.T) {
+		w := serve(RequirePermission(enum.PermissionUsersDelete))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get(HeaderGrantsExplanation))
+	})
+
+	t.Run("header con el pattern que decidió", func(t *testing.T) {
+		SetGrantsExplanation(GrantsExplanationConfig{Log: true, Header: true})
+		t.Cleanup(func() { SetGrantsExplanation(GrantsExplanationConfig{}) })
+
+		w := serve(RequirePermission(enum.PermissionUsersDelete))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "admin.users.delete: denied by deny:*.delete; matched allow:admin.users.*",
+			w.Header().Get(HeaderGrantsExplanation))
+
+		w = serve(RequireAllPermissions(enum.PermissionUsersRead, enum.PermissionMaterialsRead))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "content.materials.read: default_deny", w.Header().Get(HeaderGrantsExplanation))
+
+		w = serve(RequirePermission(enum.PermissionUsersRead))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(HeaderGrantsExplanation))
+	})
+}
@@ -0,0 +1,164 @@
+package gin
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/EduGoGroup/edugo-shared/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// FreshAuthWindow es la antigüedad máxima del login que DefaultAuthSensitivity
+// admite para las acciones administrativas destructivas: en la práctica exige
+// volver a ingresar la contraseña.
+const FreshAuthWindow = 5 * time.Minute
+
+// AuthSensitivity asigna a cada permiso la antigüedad máxima del login
+// (claim auth_time) con la que se puede ejercer. RequireRecentAuth usa la
+// ventana más estricta entre la suya y las de los permisos de la ruta.
+type AuthSensitivity map[enum.Permission]time.Duration
+
+// DefaultAuthSensitivity retorna la tabla por defecto: borrar usuarios,
+// escuelas, roles, permisos, pantallas y tipos de concepto, y administrar
+// grants o escuelas exigen un login de a lo sumo FreshAuthWindow.
+func DefaultAuthSensitivity() AuthSensitivity {
+	return AuthSensitivity{
+		enum.PermissionUsersDelete:           FreshAuthWindow,
+		enum.PermissionUsersGrantsManage:     FreshAuthWindow,
+		enum.PermissionSchoolsDelete:         FreshAuthWindow,
+		enum.PermissionSchoolsManage:         FreshAuthWindow,
+		enum.PermissionRolesDelete:           FreshAuthWindow,
+		enum.PermissionPermissionsMgmtDelete: FreshAuthWindow,
+		enum.PermissionScreenTemplatesDelete: FreshAuthWindow,
+		enum.PermissionScreenInstancesDelete: FreshAuthWindow,
+		enum.PermissionConceptTypesDelete:    FreshAuthWindow,
+	}
+}
+
+var authSensitivity atomic.Value // AuthSensitivity
+
+func init() {
+	authSensitivity.Store(DefaultAuthSensitivity())
+}
+
+// SetAuthSensitivity reemplaza la tabla de sensibilidad que consulta
+// RequireRecentAuth; nil restaura DefaultAuthSensitivity. Se toma una copia,
+// de modo que modificar el mapa después no tiene efecto. Afecta también a
+// los middlewares ya construidos.
+func SetAuthSensitivity(table AuthSensitivity) {
+	if table == nil {
+		table = DefaultAuthSensitivity()
+	}
+	authSensitivity.Store(maps.Clone(table))
+}
+
+func loadAuthSensitivity() AuthSensitivity {
+	table, ok := authSensitivity.Load().(AuthSensitivity)
+	if !ok {
+		return nil
+	}
+	return table
+}
+
+// recentAuthWindow retorna la ventana efectiva: la menor positiva entre
+// maxAge y las de los permisos en la tabla. 0 significa sin límite.
+func recentAuthWindow(maxAge time.Duration, permissions []enum.Permission) time.Duration {
+	window := maxAge
+	table := loadAuthSensitivity()
+	for _, p := range permissions {
+		if w, ok := table[p]; ok && w > 0 && (window <= 0 || w < window) {
+			window = w
+		}
+	}
+	return window
+}
+
+// RequireMFA exige que el token tenga un segundo factor verificado hace a lo
+// sumo maxAge (claim mfa_at). Con maxAge <= 0 basta con que el login haya
+// incluido MFA. Se usa en rutas sensibles junto a RequirePermission, p. ej.
+// admin.users.grants.manage:
+//
+//	r.POST("/users/:id/grants",
+//	    RequirePermission(enum.PermissionUsersGrantsManage),
+//	    RequireMFA(15*time.Minute),
+//	    handler)
+//
+// Responde 403 con código MFA_REQUIRED para que el cliente pida el segundo
+// factor y reintente con el token nuevo.
+func RequireMFA(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userClaims := getValidatedClaims(c)
+		if userClaims == nil {
+			c.Abort()
+			return
+		}
+
+		if !userClaims.MFAFresh(maxAge, time.Now()) {
+			GetLogger(c).Warn("mfa required",
+				logger.FieldPath, requestPath(c),
+				logger.FieldMethod, requestMethod(c),
+			)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "multi-factor authentication required",
+				"code":    "MFA_REQUIRED",
+				"max_age": int(maxAge / time.Second),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRecentAuth exige que el login del token (claim auth_time) sea
+// reciente. La ventana es maxAge, o la de la tabla de sensibilidad (ver
+// SetAuthSensitivity) para los permissions que ejerce la ruta si es más
+// estricta; con maxAge <= 0 solo rige la tabla. Un token sin auth_time
+// (emitido antes de que existiera el claim) siempre se rechaza.
+//
+//	r.DELETE("/users/:id",
+//	    RequirePermission(enum.PermissionUsersDelete),
+//	    RequireRecentAuth(time.Hour, enum.PermissionUsersDelete),
+//	    handler)
+//
+// Responde 401 con código STEP_UP_REQUIRED y el header WWW-Authenticate de
+// RFC 9470 (error="insufficient_user_authentication") para que el cliente
+// pida volver a autenticarse y reintente con el token nuevo. Un refresh no
+// alcanza: auth_time se preserva al rotar.
+func RequireRecentAuth(maxAge time.Duration, permissions ...enum.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userClaims := getValidatedClaims(c)
+		if userClaims == nil {
+			c.Abort()
+			return
+		}
+
+		window := recentAuthWindow(maxAge, permissions)
+		if !userClaims.AuthFresh(window, time.Now()) {
+			seconds := int(window / time.Second)
+			GetLogger(c).Warn("step-up authentication required",
+				logger.FieldPath, requestPath(c),
+				logger.FieldMethod, requestMethod(c),
+				"max_age", seconds,
+			)
+			c.Header("WWW-Authenticate", fmt.Sprintf(
+				`Bearer error="insufficient_user_authentication", error_description="a more recent authentication is required", max_age=%d`,
+				seconds,
+			))
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "recent authentication required",
+				"code":    "STEP_UP_REQUIRED",
+				"max_age": seconds,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,214 @@
+package gin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/common/types/enum"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mfaClaims(mfaAt *time.Time) *auth.Claims {
+	claims := &auth.Claims{
+		UserID: "user-123",
+		Email:  "admin@edugo.com",
+		ActiveContext: &auth.UserContext{
+			RoleID: "role-admin",
+			Grants: auth.Grants{Allow: []string{"admin.users.grants.manage"}},
+		},
+	}
+	if mfaAt != nil {
+		claims.MFAAt = jwt.NewNumericDate(*mfaAt)
+		claims.AMR = []string{auth.AMRPassword, auth.AMRMultiFactor}
+	}
+	return claims
+}
+
+func TestRequireMFA(t *testing.T) {
+	recent := time.Now().Add(-time.Minute)
+	old := time.Now().Add(-time.Hour)
+
+	t.Run("permite MFA reciente", func(t *testing.T) {
+		c := createTestContextWithClaims(mfaClaims(&recent))
+		RequireMFA(15 * time.Minute)(c)
+		assert.False(t, c.IsAborted())
+	})
+
+	t.Run("permite cualquier MFA con maxAge 0", func(t *testing.T) {
+		c := createTestContextWithClaims(mfaClaims(&old))
+		RequireMFA(0)(c)
+		assert.False(t, c.IsAborted())
+	})
+
+	for name, claims := range map[string]*auth.Claims{
+		"rechaza MFA vencido": mfaClaims(&old),
+		"rechaza sin MFA":     mfaClaims(nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := createTestContextWithClaims(claims)
+			RequireMFA(15 * time.Minute)(c)
+			require.True(t, c.IsAborted())
+			assert.Equal(t, http.StatusForbidden, c.Writer.Status())
+		})
+	}
+
+	t.Run("responde MFA_REQUIRED", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.POST("/users/:id/grants",
+			func(c *gin.Context) { c.Set(ContextKeyClaims, mfaClaims(nil)) },
+			RequireMFA(15*time.Minute),
+			func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/u1/grants", nil))
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "MFA_REQUIRED", body["code"])
+		assert.InDelta(t, 900, body["max_age"], 0)
+	})
+}
+
+func TestRequireMFA_AfterRefreshRotation(t *testing.T) {
+	manager := auth.NewJWTManager("test-secret-32-chars-minimum-123456", "edugo-central")
+	issuer := func(_ context.Context, refresh auth.RefreshTokenRecord) (string, time.Time, error) {
+		return manager.GenerateTokenWithContext(refresh.UserID, "admin@edugo.com", &auth.UserContext{RoleID: "role-admin"},
+			15*time.Minute, refresh.AuthOptions()...)
+	}
+	svc := auth.NewRefreshTokenService(auth.NewInMemoryRefreshTokenStore(), issuer, auth.RefreshTokenServiceConfig{})
+
+	login, err := svc.IssueWithMFA(t.Context(), "user-123", time.Now(), auth.AMRPassword)
+	require.NoError(t, err)
+	rotated, err := svc.Rotate(t.Context(), login.RefreshToken)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateToken(rotated.AccessToken)
+	require.NoError(t, err)
+	c := createTestContextWithClaims(claims)
+	RequireMFA(15 * time.Minute)(c)
+	assert.False(t, c.IsAborted(), "el access token rotado conserva el MFA del login")
+}
+
+func authTimeClaims(authAt *time.Time) *auth.Claims {
+	claims := mfaClaims(nil)
+	if authAt != nil {
+		claims.AuthTime = jwt.NewNumericDate(*authAt)
+		claims.AMR = []string{auth.AMRPassword}
+	}
+	return claims
+}
+
+func TestRequireRecentAuth(t *testing.T) {
+	t.Cleanup(func() { SetAuthSensitivity(nil) })
+	ago := func(d time.Duration) *time.Time {
+		at := time.Now().Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name        string
+		claims      *auth.Claims
+		maxAge      time.Duration
+		permissions []enum.Permission
+		allowed     bool
+	}{
+		{"login reciente", authTimeClaims(ago(10 * time.Minute)), time.Hour, nil, true},
+		{"login vencido", authTimeClaims(ago(2 * time.Hour)), time.Hour, nil, false},
+		{"sin auth_time", authTimeClaims(nil), time.Hour, nil, false},
+		{"sin auth_time ni límite", authTimeClaims(nil), 0, nil, false},
+		{"permiso no sensible usa maxAge", authTimeClaims(ago(10 * time.Minute)), time.Hour,
+			[]enum.Permission{enum.PermissionUsersRead}, true},
+		{"permiso destructivo exige login fresco", authTimeClaims(ago(10 * time.Minute)), time.Hour,
+			[]enum.Permission{enum.PermissionUsersRead, enum.PermissionUsersDelete}, false},
+		{"permiso destructivo con login fresco", authTimeClaims(ago(time.Minute)), time.Hour,
+			[]enum.Permission{enum.PermissionUsersDelete}, true},
+		{"maxAge 0 solo rige la tabla", authTimeClaims(ago(24 * time.Hour)), 0,
+			[]enum.Permission{enum.PermissionUsersRead}, true},
+		{"maxAge más estricto que la tabla", authTimeClaims(ago(3 * time.Minute)), time.Minute,
+			[]enum.Permission{enum.PermissionUsersDelete}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := createTestContextWithClaims(tt.claims)
+			RequireRecentAuth(tt.maxAge, tt.permissions...)(c)
+			if tt.allowed {
+				assert.False(t, c.IsAborted())
+				return
+			}
+			require.True(t, c.IsAborted())
+			assert.Equal(t, http.StatusUnauthorized, c.Writer.Status())
+		})
+	}
+
+	t.Run("sin claims", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodDelete, "/users/u1", nil)
+		RequireRecentAuth(time.Hour)(c)
+		assert.True(t, c.IsAborted())
+	})
+}
+
+func TestRequireRecentAuth_ConfigurableSensitivity(t *testing.T) {
+	t.Cleanup(func() { SetAuthSensitivity(nil) })
+	authAt := time.Now().Add(-10 * time.Minute)
+	handler := RequireRecentAuth(time.Hour, enum.PermissionUsersRead)
+
+	c := createTestContextWithClaims(authTimeClaims(&authAt))
+	handler(c)
+	assert.False(t, c.IsAborted())
+
+	// La tabla se consulta en cada request: afecta a middlewares ya construidos.
+	table := AuthSensitivity{enum.PermissionUsersRead: time.Minute}
+	SetAuthSensitivity(table)
+	table[enum.PermissionUsersRead] = 24 * time.Hour // el setter copia el mapa
+
+	c = createTestContextWithClaims(authTimeClaims(&authAt))
+	handler(c)
+	assert.True(t, c.IsAborted())
+
+	// Quitar un permiso de la tabla lo deja con la ventana de la ruta.
+	SetAuthSensitivity(AuthSensitivity{})
+	c = createTestContextWithClaims(authTimeClaims(&authAt))
+	RequireRecentAuth(time.Hour, enum.PermissionUsersDelete)(c)
+	assert.False(t, c.IsAborted())
+
+	SetAuthSensitivity(nil)
+	assert.Equal(t, DefaultAuthSensitivity(), loadAuthSensitivity())
+	for p, w := range DefaultAuthSensitivity() {
+		assert.True(t, p.IsValid(), p)
+		assert.Equal(t, FreshAuthWindow, w)
+	}
+}
+
+func TestRequireRecentAuth_StepUpResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authAt := time.Now().Add(-time.Hour)
+	router := gin.New()
+	router.DELETE("/users/:id",
+		func(c *gin.Context) { c.Set(ContextKeyClaims, authTimeClaims(&authAt)) },
+		RequireRecentAuth(12*time.Hour, enum.PermissionUsersDelete),
+		func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/u1", nil))
+
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "STEP_UP_REQUIRED", body["code"])
+	assert.InDelta(t, 300, body["max_age"], 0)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), `error="insufficient_user_authentication"`)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "max_age=300")
+}
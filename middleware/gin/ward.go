@@ -0,0 +1,99 @@
+package gin
+
+import (
+	stdErrors "errors"
+	"net/http"
+
+	"github.com/EduGoGroup/edugo-shared/audit"
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/EduGoGroup/edugo-shared/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyWardVerified marca que RequireValidWard confirmó el vínculo
+// representante → acudido del token en esta request.
+const ContextKeyWardVerified = "ward_verified"
+
+// Claves de Metadata con que WardAuditOptions describe una acción hecha en
+// modo representante.
+const (
+	AuditMetadataActorMode          = "actor_mode"
+	AuditMetadataGuardianID         = "guardian_id"
+	AuditMetadataSubjectStudentID   = "subject_student_id"
+	AuditMetadataSubjectStudentName = "subject_student_name"
+	AuditMetadataWardVerified       = "ward_verified"
+)
+
+// RequireValidWard valida los tokens en modo representante
+// (auth.ActorModeWard) contra resolver: el usuario debe ser representante
+// del acudido del token. Los tokens en contexto propio pasan sin consultar.
+// Conviene pasar un auth.CachedWardResolver para no consultar identity en
+// cada request.
+//
+// Responde 403 WARD_NOT_LINKED si no hay vínculo o falta el acudido y 500
+// WARD_CHECK_FAILED si el resolver falla.
+func RequireValidWard(resolver auth.WardResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userClaims := getValidatedClaims(c)
+		if userClaims == nil {
+			c.Abort()
+			return
+		}
+		if !userClaims.IsWard() {
+			c.Next()
+			return
+		}
+
+		err := auth.ValidateWard(c.Request.Context(), resolver, userClaims)
+		switch {
+		case err == nil:
+			c.Set(ContextKeyWardVerified, true)
+			c.Next()
+		case stdErrors.Is(err, auth.ErrWardNotLinked), stdErrors.Is(err, auth.ErrWardSubjectMissing):
+			GetLogger(c).Warn("ward not linked",
+				AuditMetadataGuardianID, userClaims.UserID,
+				AuditMetadataSubjectStudentID, userClaims.WardSubjectID(),
+				logger.FieldPath, requestPath(c),
+				logger.FieldMethod, requestMethod(c),
+			)
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+				"code":  "WARD_NOT_LINKED",
+			})
+			c.Abort()
+		default:
+			GetLogger(c).Error("ward check failed",
+				AuditMetadataGuardianID, userClaims.UserID,
+				AuditMetadataSubjectStudentID, userClaims.WardSubjectID(),
+				logger.FieldError, err.Error(),
+			)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "ward check failed",
+				"code":  "WARD_CHECK_FAILED",
+			})
+			c.Abort()
+		}
+	}
+}
+
+// WardAuditOptions retorna las opciones de auditoría que identifican al
+// representante que actúa y al acudido sobre el que actúa, o nil si la
+// request no es en modo representante. AuditMiddleware las aplica; los
+// handlers que registran sus propios eventos pueden pasarlas a
+// PostgresAuditLogger.LogFromGin o aplicarlas al AuditEvent.
+func WardAuditOptions(c *gin.Context) []audit.AuditOption {
+	claims, err := GetClaims(c)
+	if err != nil || !claims.IsWard() {
+		return nil
+	}
+	opts := []audit.AuditOption{
+		audit.WithMetadata(AuditMetadataActorMode, auth.ActorModeWard),
+		audit.WithMetadata(AuditMetadataGuardianID, claims.UserID),
+		audit.WithMetadata(AuditMetadataSubjectStudentID, claims.WardSubjectID()),
+		audit.WithMetadata(AuditMetadataWardVerified, c.GetBool(ContextKeyWardVerified)),
+	}
+	if claims.ActiveContext != nil && claims.ActiveContext.SubjectStudentName != "" {
+		opts = append(opts, audit.WithMetadata(AuditMetadataSubjectStudentName, claims.ActiveContext.SubjectStudentName))
+	}
+	return opts
+}
@@ -0,0 +1,97 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EduGoGroup/edugo-shared/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wardTestClaims(studentID string) *auth.Claims {
+	return &auth.Claims{
+		UserID: "guardian-1",
+		ActiveContext: &auth.UserContext{
+			RoleID:             "role-guardian",
+			SchoolID:           "school-1",
+			SubjectStudentID:   studentID,
+			SubjectStudentName: "Ana",
+			ActorMode:          auth.ActorModeWard,
+		},
+	}
+}
+
+func serveWard(claims *auth.Claims, resolver auth.WardResolver, logger *capturingLogger) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(AuditMiddleware(logger))
+	router.Use(func(c *gin.Context) {
+		c.Set(ContextKeyClaims, claims)
+		c.Next()
+	})
+	router.Use(RequireValidWard(resolver))
+	router.POST("/api/v1/assessments/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/assessments/a1", nil))
+	return w
+}
+
+func TestRequireValidWard(t *testing.T) {
+	resolver := auth.WardResolverFunc(func(_ context.Context, guardianID, studentID string) (bool, error) {
+		if studentID == "student-err" {
+			return false, errors.New("db down")
+		}
+		return guardianID == "guardian-1" && studentID == "student-1", nil
+	})
+
+	t.Run("vínculo válido audita guardián y acudido", func(t *testing.T) {
+		logger := &capturingLogger{}
+		w := serveWard(wardTestClaims("student-1"), resolver, logger)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		require.Len(t, logger.events, 1)
+		md := logger.events[0].Metadata
+		assert.Equal(t, auth.ActorModeWard, md[AuditMetadataActorMode])
+		assert.Equal(t, "guardian-1", md[AuditMetadataGuardianID])
+		assert.Equal(t, "student-1", md[AuditMetadataSubjectStudentID])
+		assert.Equal(t, "Ana", md[AuditMetadataSubjectStudentName])
+		assert.Equal(t, true, md[AuditMetadataWardVerified])
+		assert.Equal(t, "school-1", md["school_id"])
+	})
+
+	t.Run("vínculo inexistente", func(t *testing.T) {
+		logger := &capturingLogger{}
+		w := serveWard(wardTestClaims("student-2"), resolver, logger)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "WARD_NOT_LINKED")
+
+		// El intento rechazado también queda auditado, sin verificación.
+		require.Len(t, logger.events, 1)
+		assert.Equal(t, false, logger.events[0].Metadata[AuditMetadataWardVerified])
+	})
+
+	t.Run("error del resolver", func(t *testing.T) {
+		w := serveWard(wardTestClaims("student-err"), resolver, &capturingLogger{})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "WARD_CHECK_FAILED")
+	})
+
+	t.Run("contexto propio no consulta ni agrega metadata", func(t *testing.T) {
+		claims := &auth.Claims{UserID: "user-1", ActiveContext: &auth.UserContext{RoleID: "role-teacher"}}
+		logger := &capturingLogger{}
+		w := serveWard(claims, auth.WardResolverFunc(func(context.Context, string, string) (bool, error) {
+			t.Fatal("no debe consultarse el resolver")
+			return false, nil
+		}), logger)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, logger.events, 1)
+		assert.NotContains(t, logger.events[0].Metadata, AuditMetadataGuardianID)
+	})
+}